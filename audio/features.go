@@ -0,0 +1,349 @@
+package audio
+
+import (
+	"math"
+
+	"github.com/unixpickle/num-analysis/linalg"
+)
+
+const (
+	defaultFrameTime   = 0.025
+	defaultHopTime     = 0.01
+	defaultMelCount    = 40
+	defaultMFCCCount   = 13
+	defaultDeltaWindow = 2
+
+	minLogEnergy = 1e-10
+)
+
+// A Config specifies how features are computed.
+// A zero value for any field indicates that a default
+// should be used.
+type Config struct {
+	// FrameTime is the duration of each frame in seconds.
+	// The default is 0.025.
+	FrameTime float64
+
+	// HopTime is the time in seconds between the starts
+	// of consecutive frames.
+	// The default is 0.01.
+	HopTime float64
+
+	// PreEmphasis is the coefficient of the pre-emphasis
+	// filter y[t] = x[t] - PreEmphasis*x[t-1].
+	// The default is 0, meaning no pre-emphasis.
+	// A common value is 0.97.
+	PreEmphasis float64
+
+	// MelCount is the number of mel filters.
+	// The default is 40.
+	MelCount int
+
+	// MinFreq and MaxFreq bound the frequencies (in Hz)
+	// covered by the mel filters.
+	// The default MaxFreq is half the sample rate.
+	MinFreq float64
+	MaxFreq float64
+
+	// MFCCCount is the number of cepstral coefficients,
+	// including the 0th one.
+	// The default is 13.
+	MFCCCount int
+
+	// DeltaWindow is the number of frames on each side
+	// used to compute deltas.
+	// The default is 2.
+	DeltaWindow int
+}
+
+// Spectrogram computes the power spectrum of each frame
+// of a sound.
+//
+// Each frame is multiplied by a Hamming window and
+// zero-padded to a power of 2 before the FFT.
+// The last frame is zero-padded if it extends past the
+// end of the sound.
+// The resulting vectors contain the squared magnitudes
+// of the non-negative frequencies.
+//
+// The c argument may be nil to use the defaults.
+func Spectrogram(s *Sound, c *Config) []linalg.Vector {
+	config := c.withDefaults(s.SampleRate)
+	frameSize := config.frameSize(s.SampleRate)
+	hopSize := config.hopSize(s.SampleRate)
+	fftSize := nextPowerOf2(frameSize)
+
+	samples := s.Samples
+	if config.PreEmphasis != 0 && len(samples) > 0 {
+		samples = make([]float64, len(s.Samples))
+		samples[0] = s.Samples[0]
+		for i := 1; i < len(samples); i++ {
+			samples[i] = s.Samples[i] - config.PreEmphasis*s.Samples[i-1]
+		}
+	}
+
+	window := hammingWindow(frameSize)
+	real := make([]float64, fftSize)
+	imag := make([]float64, fftSize)
+	var res []linalg.Vector
+	for start := 0; start < len(samples); start += hopSize {
+		for i := range real {
+			real[i], imag[i] = 0, 0
+		}
+		for i, w := range window {
+			if start+i < len(samples) {
+				real[i] = samples[start+i] * w
+			}
+		}
+		fft(real, imag)
+		power := make(linalg.Vector, fftSize/2+1)
+		for i := range power {
+			power[i] = real[i]*real[i] + imag[i]*imag[i]
+		}
+		res = append(res, power)
+		if start+frameSize >= len(samples) {
+			break
+		}
+	}
+	return res
+}
+
+// LogMel computes the log energies of a bank of
+// triangular mel-scale filters applied to each frame's
+// power spectrum.
+//
+// The c argument may be nil to use the defaults.
+func LogMel(s *Sound, c *Config) []linalg.Vector {
+	config := c.withDefaults(s.SampleRate)
+	spec := Spectrogram(s, &config)
+	if len(spec) == 0 {
+		return nil
+	}
+	bank := melFilterBank(&config, s.SampleRate, len(spec[0]))
+	res := make([]linalg.Vector, len(spec))
+	for i, power := range spec {
+		res[i] = make(linalg.Vector, len(bank))
+		for j, filter := range bank {
+			energy := filter.Dot(power)
+			res[i][j] = math.Log(math.Max(energy, minLogEnergy))
+		}
+	}
+	return res
+}
+
+// MFCC computes the mel-frequency cepstral coefficients
+// of each frame, using an orthonormal DCT of the log mel
+// energies.
+//
+// The c argument may be nil to use the defaults.
+func MFCC(s *Sound, c *Config) []linalg.Vector {
+	config := c.withDefaults(s.SampleRate)
+	logMel := LogMel(s, &config)
+	res := make([]linalg.Vector, len(logMel))
+	for i, energies := range logMel {
+		res[i] = dct(energies, config.MFCCCount)
+	}
+	return res
+}
+
+// SpeechFeatures computes MFCCs with deltas and
+// delta-deltas, normalized for the utterance.
+// This is a common choice of inputs for speech
+// recognition models.
+//
+// The c argument may be nil to use the defaults.
+func SpeechFeatures(s *Sound, c *Config) []linalg.Vector {
+	config := c.withDefaults(s.SampleRate)
+	return Normalize(AddDeltas(MFCC(s, &config), config.DeltaWindow))
+}
+
+// Deltas computes the time derivatives of a sequence of
+// vectors using a linear regression over the window
+// frames on either side of each frame.
+// Frames beyond the ends of the sequence are replaced by
+// the first or last frame.
+func Deltas(frames []linalg.Vector, window int) []linalg.Vector {
+	if window < 1 {
+		panic("delta window must be positive")
+	}
+	var denom float64
+	for n := 1; n <= window; n++ {
+		denom += 2 * float64(n*n)
+	}
+	res := make([]linalg.Vector, len(frames))
+	for t := range frames {
+		res[t] = make(linalg.Vector, len(frames[t]))
+		for n := 1; n <= window; n++ {
+			next := frames[clampIndex(t+n, len(frames))]
+			last := frames[clampIndex(t-n, len(frames))]
+			for i := range res[t] {
+				res[t][i] += float64(n) * (next[i] - last[i]) / denom
+			}
+		}
+	}
+	return res
+}
+
+// AddDeltas appends the deltas and delta-deltas of each
+// frame to the frame, tripling its size.
+func AddDeltas(frames []linalg.Vector, window int) []linalg.Vector {
+	deltas := Deltas(frames, window)
+	deltaDeltas := Deltas(deltas, window)
+	res := make([]linalg.Vector, len(frames))
+	for i, frame := range frames {
+		vec := make(linalg.Vector, 0, len(frame)*3)
+		vec = append(vec, frame...)
+		vec = append(vec, deltas[i]...)
+		vec = append(vec, deltaDeltas[i]...)
+		res[i] = vec
+	}
+	return res
+}
+
+// Normalize subtracts the mean of each component across
+// all the frames and divides by its standard deviation.
+// Components with no variance are only centered.
+func Normalize(frames []linalg.Vector) []linalg.Vector {
+	if len(frames) == 0 {
+		return nil
+	}
+	size := len(frames[0])
+	mean := make(linalg.Vector, size)
+	for _, frame := range frames {
+		mean.Add(frame)
+	}
+	mean.Scale(1 / float64(len(frames)))
+
+	variance := make(linalg.Vector, size)
+	for _, frame := range frames {
+		for i, x := range frame {
+			variance[i] += (x - mean[i]) * (x - mean[i])
+		}
+	}
+	variance.Scale(1 / float64(len(frames)))
+
+	res := make([]linalg.Vector, len(frames))
+	for t, frame := range frames {
+		res[t] = make(linalg.Vector, size)
+		for i, x := range frame {
+			res[t][i] = x - mean[i]
+			if variance[i] > 0 {
+				res[t][i] /= math.Sqrt(variance[i])
+			}
+		}
+	}
+	return res
+}
+
+func (c *Config) withDefaults(sampleRate int) Config {
+	var res Config
+	if c != nil {
+		res = *c
+	}
+	if res.FrameTime == 0 {
+		res.FrameTime = defaultFrameTime
+	}
+	if res.HopTime == 0 {
+		res.HopTime = defaultHopTime
+	}
+	if res.MelCount == 0 {
+		res.MelCount = defaultMelCount
+	}
+	if res.MaxFreq == 0 {
+		res.MaxFreq = float64(sampleRate) / 2
+	}
+	if res.MFCCCount == 0 {
+		res.MFCCCount = defaultMFCCCount
+	}
+	if res.DeltaWindow == 0 {
+		res.DeltaWindow = defaultDeltaWindow
+	}
+	return res
+}
+
+func (c *Config) frameSize(sampleRate int) int {
+	return int(math.Max(1, math.Floor(c.FrameTime*float64(sampleRate)+0.5)))
+}
+
+func (c *Config) hopSize(sampleRate int) int {
+	return int(math.Max(1, math.Floor(c.HopTime*float64(sampleRate)+0.5)))
+}
+
+// melFilterBank creates triangular filters which are
+// evenly spaced on the mel scale.
+// Each filter is a vector of weights for the bins of a
+// power spectrum.
+func melFilterBank(c *Config, sampleRate, numBins int) []linalg.Vector {
+	minMel, maxMel := hzToMel(c.MinFreq), hzToMel(c.MaxFreq)
+	binFreq := float64(sampleRate) / float64(2*(numBins-1))
+
+	points := make([]float64, c.MelCount+2)
+	for i := range points {
+		mel := minMel + (maxMel-minMel)*float64(i)/float64(c.MelCount+1)
+		points[i] = melToHz(mel)
+	}
+
+	res := make([]linalg.Vector, c.MelCount)
+	for i := range res {
+		left, center, right := points[i], points[i+1], points[i+2]
+		filter := make(linalg.Vector, numBins)
+		for bin := range filter {
+			freq := float64(bin) * binFreq
+			if freq > left && freq <= center {
+				filter[bin] = (freq - left) / (center - left)
+			} else if freq > center && freq < right {
+				filter[bin] = (right - freq) / (right - center)
+			}
+		}
+		res[i] = filter
+	}
+	return res
+}
+
+func hzToMel(hz float64) float64 {
+	return 2595 * math.Log10(1+hz/700)
+}
+
+func melToHz(mel float64) float64 {
+	return 700 * (math.Pow(10, mel/2595) - 1)
+}
+
+func hammingWindow(size int) []float64 {
+	res := make([]float64, size)
+	if size == 1 {
+		res[0] = 1
+		return res
+	}
+	for i := range res {
+		res[i] = 0.54 - 0.46*math.Cos(2*math.Pi*float64(i)/float64(size-1))
+	}
+	return res
+}
+
+// dct computes the first n coefficients of the
+// orthonormal DCT-II of a vector.
+func dct(v linalg.Vector, n int) linalg.Vector {
+	res := make(linalg.Vector, n)
+	size := float64(len(v))
+	for k := range res {
+		var sum float64
+		for i, x := range v {
+			sum += x * math.Cos(math.Pi*float64(k)*(float64(i)+0.5)/size)
+		}
+		scale := math.Sqrt(2 / size)
+		if k == 0 {
+			scale = math.Sqrt(1 / size)
+		}
+		res[k] = sum * scale
+	}
+	return res
+}
+
+func clampIndex(i, n int) int {
+	if i < 0 {
+		return 0
+	} else if i >= n {
+		return n - 1
+	}
+	return i
+}
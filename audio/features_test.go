@@ -0,0 +1,139 @@
+package audio
+
+import (
+	"math"
+	"math/cmplx"
+	"math/rand"
+	"testing"
+
+	"github.com/unixpickle/num-analysis/linalg"
+)
+
+func TestFFT(t *testing.T) {
+	real := make([]float64, 16)
+	imag := make([]float64, 16)
+	for i := range real {
+		real[i], imag[i] = rand.NormFloat64(), rand.NormFloat64()
+	}
+	expected := make([]complex128, len(real))
+	for k := range expected {
+		for i := range real {
+			angle := -2 * math.Pi * float64(k*i) / float64(len(real))
+			expected[k] += complex(real[i], imag[i]) * cmplx.Exp(complex(0, angle))
+		}
+	}
+	fft(real, imag)
+	for k, x := range expected {
+		if cmplx.Abs(x-complex(real[k], imag[k])) > 1e-8 {
+			t.Errorf("bin %d: expected %v but got %v", k, x, complex(real[k], imag[k]))
+		}
+	}
+}
+
+func TestSpectrogram(t *testing.T) {
+	sound := sineSound(16000, 1000, 0.5)
+	spec := Spectrogram(sound, nil)
+
+	// 400-sample frames with a hop of 160.
+	expectedFrames := 1 + (8000-400+159)/160
+	if len(spec) != expectedFrames {
+		t.Fatalf("expected %d frames but got %d", expectedFrames, len(spec))
+	}
+	if len(spec[0]) != 257 {
+		t.Fatalf("expected 257 bins but got %d", len(spec[0]))
+	}
+	peak := 1000 * 512 / 16000
+	for i, frame := range spec[:len(spec)-1] {
+		if maxIndex(frame) != peak {
+			t.Errorf("frame %d: expected peak at %d but got %d", i, peak, maxIndex(frame))
+			break
+		}
+	}
+}
+
+func TestLogMelAndMFCC(t *testing.T) {
+	sound := sineSound(16000, 300, 0.2)
+	config := &Config{MelCount: 20, MFCCCount: 10}
+	logMel := LogMel(sound, config)
+	if len(logMel[0]) != 20 {
+		t.Fatalf("expected 20 mel energies but got %d", len(logMel[0]))
+	}
+	peak := maxIndex(logMel[0])
+	if melToHz(hzToMel(8000)*float64(peak+1)/21) > 450 {
+		t.Errorf("peak filter %d does not cover 300 Hz", peak)
+	}
+
+	mfcc := MFCC(sound, config)
+	if len(mfcc) != len(logMel) || len(mfcc[0]) != 10 {
+		t.Fatalf("unexpected MFCC shape")
+	}
+	var energySum float64
+	for _, x := range logMel[0] {
+		energySum += x
+	}
+	if math.Abs(mfcc[0][0]-energySum/math.Sqrt(20)) > 1e-8 {
+		t.Errorf("expected c0 %f but got %f", energySum/math.Sqrt(20), mfcc[0][0])
+	}
+
+	features := SpeechFeatures(sound, config)
+	if len(features) != len(mfcc) || len(features[0]) != 30 {
+		t.Errorf("unexpected feature shape")
+	}
+}
+
+func TestDeltas(t *testing.T) {
+	var frames []linalg.Vector
+	for i := 0; i < 10; i++ {
+		frames = append(frames, linalg.Vector{float64(i) * 3, 7})
+	}
+	withDeltas := AddDeltas(frames, 2)
+	for i := 2; i < 8; i++ {
+		expected := []float64{float64(i) * 3, 7, 3, 0}
+		for j, x := range expected {
+			if math.Abs(withDeltas[i][j]-x) > 1e-8 {
+				t.Errorf("frame %d: expected %v but got %v", i, expected, withDeltas[i][:4])
+				break
+			}
+		}
+		if i >= 4 && i < 6 && math.Abs(withDeltas[i][4]) > 1e-8 {
+			t.Errorf("frame %d: expected zero delta-delta but got %f", i, withDeltas[i][4])
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	var frames []linalg.Vector
+	for i := 0; i < 50; i++ {
+		frames = append(frames, linalg.Vector{rand.NormFloat64()*3 + 2, 5})
+	}
+	normalized := Normalize(frames)
+	var mean, sqMean float64
+	for _, f := range normalized {
+		mean += f[0] / 50
+		sqMean += f[0] * f[0] / 50
+		if f[1] != 0 {
+			t.Fatalf("constant component should be 0 but got %f", f[1])
+		}
+	}
+	if math.Abs(mean) > 1e-8 || math.Abs(sqMean-1) > 1e-8 {
+		t.Errorf("bad statistics: mean %f, second moment %f", mean, sqMean)
+	}
+}
+
+func sineSound(rate int, freq, duration float64) *Sound {
+	res := &Sound{SampleRate: rate}
+	for i := 0; i < int(float64(rate)*duration); i++ {
+		res.Samples = append(res.Samples, math.Sin(2*math.Pi*freq*float64(i)/float64(rate)))
+	}
+	return res
+}
+
+func maxIndex(v linalg.Vector) int {
+	var res int
+	for i, x := range v {
+		if x > v[res] {
+			res = i
+		}
+	}
+	return res
+}
@@ -0,0 +1,52 @@
+package audio
+
+import "math"
+
+// fft computes an in-place radix-2 discrete Fourier
+// transform.
+// The length of the input must be a power of 2.
+func fft(real, imag []float64) {
+	n := len(real)
+	if n&(n-1) != 0 {
+		panic("FFT size must be a power of 2")
+	}
+
+	// Bit-reversal permutation.
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j |= bit
+		if i < j {
+			real[i], real[j] = real[j], real[i]
+			imag[i], imag[j] = imag[j], imag[i]
+		}
+	}
+
+	for size := 2; size <= n; size <<= 1 {
+		angle := -2 * math.Pi / float64(size)
+		stepReal, stepImag := math.Cos(angle), math.Sin(angle)
+		for start := 0; start < n; start += size {
+			wReal, wImag := 1.0, 0.0
+			for k := 0; k < size/2; k++ {
+				i, j := start+k, start+k+size/2
+				tReal := wReal*real[j] - wImag*imag[j]
+				tImag := wReal*imag[j] + wImag*real[j]
+				real[j], imag[j] = real[i]-tReal, imag[i]-tImag
+				real[i], imag[i] = real[i]+tReal, imag[i]+tImag
+				wReal, wImag = wReal*stepReal-wImag*stepImag, wReal*stepImag+wImag*stepReal
+			}
+		}
+	}
+}
+
+// nextPowerOf2 returns the smallest power of 2 which is
+// at least n.
+func nextPowerOf2(n int) int {
+	res := 1
+	for res < n {
+		res <<= 1
+	}
+	return res
+}
@@ -0,0 +1,207 @@
+// Package audio implements feature extraction for speech
+// and other audio models.
+//
+// The features are sequences of vectors, one per frame,
+// which can be used directly as the inputs of
+// seqtoseq.Samples.
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+)
+
+const (
+	wavFormatPCM        = 1
+	wavFormatFloat      = 3
+	wavFormatExtensible = 0xfffe
+)
+
+// A Sound is a mono audio signal.
+type Sound struct {
+	// SampleRate is the number of samples per second.
+	SampleRate int
+
+	// Samples contains the amplitudes of the signal,
+	// which are generally between -1 and 1.
+	Samples []float64
+}
+
+// Duration returns the length of the sound in seconds.
+func (s *Sound) Duration() float64 {
+	return float64(len(s.Samples)) / float64(s.SampleRate)
+}
+
+// ReadWAVFile reads a WAV file from a path.
+// See ReadWAV for details.
+func ReadWAVFile(path string) (*Sound, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadWAV(f)
+}
+
+// ReadWAV decodes a WAV file.
+//
+// The file may contain 8, 16, 24, or 32-bit integer PCM
+// data or 32 or 64-bit floating-point data.
+// Multiple channels are averaged to produce a mono
+// Sound.
+func ReadWAV(r io.Reader) (*Sound, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return nil, errors.New("not a RIFF WAVE file")
+	}
+
+	var format *wavFormat
+	var samples []byte
+	var haveSamples bool
+	for chunks := data[12:]; len(chunks) >= 8; {
+		id := string(chunks[:4])
+		size := int(binary.LittleEndian.Uint32(chunks[4:8]))
+		if size > len(chunks)-8 {
+			return nil, fmt.Errorf("chunk %q is truncated", id)
+		}
+		body := chunks[8 : 8+size]
+		switch id {
+		case "fmt ":
+			format, err = parseWAVFormat(body)
+			if err != nil {
+				return nil, err
+			}
+		case "data":
+			samples = body
+			haveSamples = true
+		}
+		// Chunks are padded to an even number of bytes.
+		size += size & 1
+		if size > len(chunks)-8 {
+			break
+		}
+		chunks = chunks[8+size:]
+	}
+	if format == nil {
+		return nil, errors.New("missing fmt chunk")
+	} else if !haveSamples {
+		return nil, errors.New("missing data chunk")
+	}
+	return format.decode(samples)
+}
+
+// WriteWAV encodes a Sound as a 16-bit mono WAV file.
+// Samples outside of [-1, 1] are clipped.
+func WriteWAV(w io.Writer, s *Sound) error {
+	var buf bytes.Buffer
+	dataSize := 2 * len(s.Samples)
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(36+dataSize))
+	buf.WriteString("WAVEfmt ")
+	fields := []interface{}{
+		uint32(16), uint16(wavFormatPCM), uint16(1), uint32(s.SampleRate),
+		uint32(s.SampleRate * 2), uint16(2), uint16(16),
+	}
+	for _, field := range fields {
+		binary.Write(&buf, binary.LittleEndian, field)
+	}
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, uint32(dataSize))
+	for _, x := range s.Samples {
+		x = math.Max(-1, math.Min(1, x))
+		binary.Write(&buf, binary.LittleEndian, int16(math.Floor(x*math.MaxInt16+0.5)))
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+type wavFormat struct {
+	Format        int
+	Channels      int
+	SampleRate    int
+	BitsPerSample int
+}
+
+func parseWAVFormat(body []byte) (*wavFormat, error) {
+	if len(body) < 16 {
+		return nil, errors.New("fmt chunk is too short")
+	}
+	res := &wavFormat{
+		Format:        int(binary.LittleEndian.Uint16(body)),
+		Channels:      int(binary.LittleEndian.Uint16(body[2:])),
+		SampleRate:    int(binary.LittleEndian.Uint32(body[4:])),
+		BitsPerSample: int(binary.LittleEndian.Uint16(body[14:])),
+	}
+	if res.Format == wavFormatExtensible {
+		if len(body) < 26 {
+			return nil, errors.New("extensible fmt chunk is too short")
+		}
+		res.Format = int(binary.LittleEndian.Uint16(body[24:]))
+	}
+	if res.Channels == 0 {
+		return nil, errors.New("no channels")
+	}
+	switch res.Format {
+	case wavFormatPCM:
+		switch res.BitsPerSample {
+		case 8, 16, 24, 32:
+		default:
+			return nil, fmt.Errorf("unsupported PCM bit depth: %d", res.BitsPerSample)
+		}
+	case wavFormatFloat:
+		if res.BitsPerSample != 32 && res.BitsPerSample != 64 {
+			return nil, fmt.Errorf("unsupported float bit depth: %d", res.BitsPerSample)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported WAV format: %d", res.Format)
+	}
+	return res, nil
+}
+
+func (w *wavFormat) decode(data []byte) (*Sound, error) {
+	sampleSize := w.BitsPerSample / 8
+	frameSize := sampleSize * w.Channels
+	res := &Sound{
+		SampleRate: w.SampleRate,
+		Samples:    make([]float64, len(data)/frameSize),
+	}
+	scale := 1 / float64(w.Channels)
+	for i := range res.Samples {
+		frame := data[i*frameSize : (i+1)*frameSize]
+		var sum float64
+		for ch := 0; ch < w.Channels; ch++ {
+			sum += w.decodeSample(frame[ch*sampleSize : (ch+1)*sampleSize])
+		}
+		res.Samples[i] = sum * scale
+	}
+	return res, nil
+}
+
+func (w *wavFormat) decodeSample(b []byte) float64 {
+	if w.Format == wavFormatFloat {
+		if w.BitsPerSample == 32 {
+			return float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(b))
+	}
+	switch w.BitsPerSample {
+	case 8:
+		return (float64(b[0]) - 128) / 128
+	case 16:
+		return float64(int16(binary.LittleEndian.Uint16(b))) / (1 << 15)
+	case 24:
+		x := int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24) >> 8
+		return float64(x) / (1 << 23)
+	default:
+		return float64(int32(binary.LittleEndian.Uint32(b))) / (1 << 31)
+	}
+}
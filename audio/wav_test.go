@@ -0,0 +1,102 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+func TestWAVRoundTrip(t *testing.T) {
+	sound := &Sound{SampleRate: 8000}
+	for i := 0; i < 1001; i++ {
+		sound.Samples = append(sound.Samples, math.Sin(float64(i)/10))
+	}
+	var buf bytes.Buffer
+	if err := WriteWAV(&buf, sound); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := ReadWAV(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.SampleRate != sound.SampleRate {
+		t.Errorf("expected sample rate %d but got %d", sound.SampleRate, decoded.SampleRate)
+	}
+	if len(decoded.Samples) != len(sound.Samples) {
+		t.Fatalf("expected %d samples but got %d", len(sound.Samples), len(decoded.Samples))
+	}
+	for i, x := range sound.Samples {
+		if math.Abs(x-decoded.Samples[i]) > 1e-4 {
+			t.Fatalf("sample %d: expected %f but got %f", i, x, decoded.Samples[i])
+		}
+	}
+}
+
+func TestReadWAVFormats(t *testing.T) {
+	tests := []struct {
+		Format   uint16
+		Bits     uint16
+		Data     []byte
+		Expected []float64
+	}{
+		{wavFormatPCM, 8, []byte{128, 192, 0, 64}, []float64{0.25, -0.75}},
+		{wavFormatPCM, 24, []byte{0, 0, 0x40, 0, 0, 0x20, 0, 0, 0xc0, 0, 0, 0xe0},
+			[]float64{0.375, -0.375}},
+		{wavFormatFloat, 32, float32Bytes(0.5, -0.25, 1, 0), []float64{0.125, 0.5}},
+	}
+	for i, test := range tests {
+		var buf bytes.Buffer
+		buf.WriteString("RIFF")
+		binary.Write(&buf, binary.LittleEndian, uint32(0))
+		buf.WriteString("WAVE")
+
+		// An unknown, odd-sized chunk which must be skipped.
+		buf.WriteString("LIST")
+		binary.Write(&buf, binary.LittleEndian, uint32(3))
+		buf.Write([]byte{1, 2, 3, 0})
+
+		buf.WriteString("fmt ")
+		frameSize := 2 * test.Bits / 8
+		for _, x := range []interface{}{uint32(16), test.Format, uint16(2), uint32(100),
+			uint32(100 * uint32(frameSize)), frameSize, test.Bits} {
+			binary.Write(&buf, binary.LittleEndian, x)
+		}
+		buf.WriteString("data")
+		binary.Write(&buf, binary.LittleEndian, uint32(len(test.Data)))
+		buf.Write(test.Data)
+
+		sound, err := ReadWAV(&buf)
+		if err != nil {
+			t.Errorf("test %d: %s", i, err)
+			continue
+		}
+		if len(sound.Samples) != len(test.Expected) {
+			t.Errorf("test %d: expected %v but got %v", i, test.Expected, sound.Samples)
+			continue
+		}
+		for j, x := range test.Expected {
+			if math.Abs(sound.Samples[j]-x) > 1e-6 {
+				t.Errorf("test %d: expected %v but got %v", i, test.Expected, sound.Samples)
+				break
+			}
+		}
+	}
+}
+
+func TestReadWAVErrors(t *testing.T) {
+	if _, err := ReadWAV(bytes.NewReader([]byte("RIFF\x00\x00\x00\x00WAVE"))); err == nil {
+		t.Error("expected error for missing chunks")
+	}
+	if _, err := ReadWAV(bytes.NewReader([]byte("not a wav file"))); err == nil {
+		t.Error("expected error for bad header")
+	}
+}
+
+func float32Bytes(vals ...float32) []byte {
+	var buf bytes.Buffer
+	for _, x := range vals {
+		binary.Write(&buf, binary.LittleEndian, x)
+	}
+	return buf.Bytes()
+}
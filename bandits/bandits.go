@@ -0,0 +1,98 @@
+// Package bandits implements online algorithms for
+// multi-armed and contextual bandit problems.
+//
+// All of the bandits in this package are safe to use
+// from multiple Goroutines and can be serialized with
+// the serializer package.
+package bandits
+
+import (
+	"encoding/json"
+	"math/rand"
+	"sync"
+
+	"github.com/unixpickle/num-analysis/linalg"
+	"github.com/unixpickle/serializer"
+)
+
+// A Bandit allocates trials between a fixed set of arms,
+// learning online which arms give the best rewards.
+type Bandit interface {
+	serializer.Serializer
+
+	// NumArms returns the number of arms.
+	NumArms() int
+
+	// Select chooses an arm to pull.
+	Select() int
+
+	// Update records the reward from pulling an arm.
+	Update(arm int, reward float64)
+}
+
+// A ContextualBandit is like a Bandit, except that the
+// expected reward of each arm depends on a context
+// vector which is observed before every selection.
+type ContextualBandit interface {
+	serializer.Serializer
+
+	// NumArms returns the number of arms.
+	NumArms() int
+
+	// Select chooses an arm to pull in the context.
+	Select(context linalg.Vector) int
+
+	// Update records the reward from pulling an arm in
+	// the context.
+	Update(context linalg.Vector, arm int, reward float64)
+}
+
+// ArmStats stores the number of pulls and the total
+// reward for each arm.
+type ArmStats struct {
+	Counts []float64
+	Sums   []float64
+}
+
+func newArmStats(numArms int) ArmStats {
+	return ArmStats{
+		Counts: make([]float64, numArms),
+		Sums:   make([]float64, numArms),
+	}
+}
+
+// Mean returns the average reward of an arm, or 0 if the
+// arm has never been pulled.
+func (a *ArmStats) Mean(arm int) float64 {
+	if a.Counts[arm] == 0 {
+		return 0
+	}
+	return a.Sums[arm] / a.Counts[arm]
+}
+
+func (a *ArmStats) update(arm int, reward float64) {
+	a.Counts[arm]++
+	a.Sums[arm] += reward
+}
+
+// argmax returns the index of the largest value,
+// breaking ties randomly.
+func argmax(values []float64) int {
+	var best []int
+	for i, x := range values {
+		if len(best) == 0 || x > values[best[0]] {
+			best = append(best[:0], i)
+		} else if x == values[best[0]] {
+			best = append(best, i)
+		}
+	}
+	return best[rand.Intn(len(best))]
+}
+
+// serializeLocked encodes a bandit's exported fields as
+// JSON while holding its lock.
+func serializeLocked(lock *sync.Mutex, obj interface{}) ([]byte, error) {
+	lock.Lock()
+	defer lock.Unlock()
+	return json.Marshal(obj)
+}
@@ -0,0 +1,134 @@
+package bandits
+
+import (
+	"bytes"
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/unixpickle/num-analysis/linalg"
+	"github.com/unixpickle/serializer"
+)
+
+func TestBanditRegret(t *testing.T) {
+	arms := []Arm{BernoulliArm{P: 0.2}, BernoulliArm{P: 0.5}, BernoulliArm{P: 0.7}}
+	bandits := map[string]Bandit{
+		"epsilon":  NewEpsilonGreedy(3, 0.05),
+		"ucb1":     NewUCB1(3),
+		"beta":     NewBetaThompson(3),
+		"gaussian": NewGaussianThompson(3),
+	}
+	const steps = 5000
+	randomRegret := steps * (0.7 - (0.2+0.5+0.7)/3)
+	for name, b := range bandits {
+		curve := Simulate(b, arms, steps)
+		if len(curve) != steps {
+			t.Fatalf("%s: bad curve length", name)
+		}
+		final := curve[steps-1]
+		if final > randomRegret/4 {
+			t.Errorf("%s: regret %f is too high (random gives %f)", name, final, randomRegret)
+		}
+		// Epsilon-greedy keeps exploring at a constant rate.
+		if name == "epsilon" {
+			continue
+		}
+		if secondHalf := final - curve[steps/2]; secondHalf > curve[steps/2] {
+			t.Errorf("%s: regret is not sublinear", name)
+		}
+	}
+}
+
+func TestContextualRegret(t *testing.T) {
+	env := &LinearEnvironment{
+		Weights: []linalg.Vector{
+			{1, 0, 0.5, 0},
+			{0, 1, -0.5, 0},
+			{-1, -1, 0, 0.5},
+		},
+		Noise: 0.1,
+	}
+	const steps = 2000
+	baseline := SimulateContextual(&randomContextual{numArms: 3}, env, steps)[steps-1]
+	bandits := map[string]ContextualBandit{
+		"linucb":   NewLinUCB(3, 4, 1),
+		"thompson": NewLinearThompson(3, 4, 0.5),
+	}
+	for name, b := range bandits {
+		regret := SimulateContextual(b, env, steps)[steps-1]
+		if regret > baseline/10 {
+			t.Errorf("%s: regret %f is too high (random gives %f)", name, regret, baseline)
+		}
+	}
+}
+
+func TestSerialize(t *testing.T) {
+	arms := []Arm{BernoulliArm{P: 0.2}, BernoulliArm{P: 0.5}}
+	env := &LinearEnvironment{Weights: []linalg.Vector{{1, 0}, {0, 1}}}
+	bandits := []serializer.Serializer{
+		NewEpsilonGreedy(2, 0.1),
+		NewUCB1(2),
+		NewBetaThompson(2),
+		NewGaussianThompson(2),
+		NewLinUCB(2, 2, 1),
+		NewLinearThompson(2, 2, 1),
+	}
+	for _, b := range bandits {
+		if simple, ok := b.(Bandit); ok {
+			Simulate(simple, arms, 20)
+		} else {
+			SimulateContextual(b.(ContextualBandit), env, 20)
+		}
+		data, err := serializer.SerializeWithType(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := serializer.DeserializeWithType(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, _ := b.Serialize()
+		actual, _ := decoded.Serialize()
+		if !bytes.Equal(expected, actual) {
+			t.Errorf("%s: expected %s but got %s", b.SerializerType(), expected, actual)
+		}
+	}
+}
+
+func TestConcurrentUpdates(t *testing.T) {
+	b := NewBetaThompson(4)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				b.Update(b.Select(), 1)
+			}
+		}()
+	}
+	wg.Wait()
+	var total float64
+	for _, count := range b.Stats.Counts {
+		total += count
+	}
+	if total != 800 {
+		t.Errorf("expected 800 pulls but got %f", total)
+	}
+}
+
+type randomContextual struct {
+	numArms int
+	serializer.Serializer
+}
+
+func (r *randomContextual) NumArms() int {
+	return r.numArms
+}
+
+func (r *randomContextual) Select(context linalg.Vector) int {
+	return rand.Intn(r.numArms)
+}
+
+func (r *randomContextual) Update(context linalg.Vector, arm int, reward float64) {
+}
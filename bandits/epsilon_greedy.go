@@ -0,0 +1,96 @@
+package bandits
+
+import (
+	"encoding/json"
+	"math/rand"
+	"sync"
+
+	"github.com/unixpickle/serializer"
+)
+
+func init() {
+	var e EpsilonGreedy
+	serializer.RegisterTypedDeserializer(e.SerializerType(), DeserializeEpsilonGreedy)
+}
+
+// EpsilonGreedy pulls a random arm with probability
+// Epsilon and the arm with the best average reward
+// otherwise.
+//
+// The exported fields should not be modified while the
+// bandit is in use.
+type EpsilonGreedy struct {
+	Epsilon float64
+	Stats   ArmStats
+
+	lock sync.Mutex
+}
+
+// NewEpsilonGreedy creates an EpsilonGreedy bandit.
+func NewEpsilonGreedy(numArms int, epsilon float64) *EpsilonGreedy {
+	return &EpsilonGreedy{
+		Epsilon: epsilon,
+		Stats:   newArmStats(numArms),
+	}
+}
+
+// DeserializeEpsilonGreedy deserializes an
+// EpsilonGreedy bandit.
+func DeserializeEpsilonGreedy(d []byte) (*EpsilonGreedy, error) {
+	var res EpsilonGreedy
+	if err := json.Unmarshal(d, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (e *EpsilonGreedy) NumArms() int {
+	return len(e.Stats.Counts)
+}
+
+// Select chooses an arm.
+// Arms which have never been pulled are tried first.
+func (e *EpsilonGreedy) Select() int {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if arm, ok := unpulledArm(&e.Stats); ok {
+		return arm
+	}
+	if rand.Float64() < e.Epsilon {
+		return rand.Intn(e.NumArms())
+	}
+	means := make([]float64, e.NumArms())
+	for i := range means {
+		means[i] = e.Stats.Mean(i)
+	}
+	return argmax(means)
+}
+
+func (e *EpsilonGreedy) Update(arm int, reward float64) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.Stats.update(arm, reward)
+}
+
+func (e *EpsilonGreedy) Serialize() ([]byte, error) {
+	return serializeLocked(&e.lock, e)
+}
+
+func (e *EpsilonGreedy) SerializerType() string {
+	return "github.com/unixpickle/weakai/bandits.EpsilonGreedy"
+}
+
+// unpulledArm returns a random arm which has never been
+// pulled, if there is one.
+func unpulledArm(stats *ArmStats) (int, bool) {
+	var unpulled []int
+	for i, count := range stats.Counts {
+		if count == 0 {
+			unpulled = append(unpulled, i)
+		}
+	}
+	if len(unpulled) == 0 {
+		return 0, false
+	}
+	return unpulled[rand.Intn(len(unpulled))], true
+}
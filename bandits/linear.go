@@ -0,0 +1,217 @@
+package bandits
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"sync"
+
+	"github.com/unixpickle/num-analysis/linalg"
+	"github.com/unixpickle/serializer"
+)
+
+func init() {
+	var l LinUCB
+	serializer.RegisterTypedDeserializer(l.SerializerType(), DeserializeLinUCB)
+	var t LinearThompson
+	serializer.RegisterTypedDeserializer(t.SerializerType(), DeserializeLinearThompson)
+}
+
+// A LinearArm models the expected reward of an arm as a
+// linear function of the context using ridge regression.
+type LinearArm struct {
+	// InvCov is the inverse of the matrix
+	// Regularization*I + sum(x*x^T), stored in row-major
+	// order.
+	InvCov []float64
+
+	// Target is the sum of reward*x.
+	Target linalg.Vector
+}
+
+func newLinearArm(dim int, regularization float64) *LinearArm {
+	res := &LinearArm{
+		InvCov: make([]float64, dim*dim),
+		Target: make(linalg.Vector, dim),
+	}
+	for i := 0; i < dim; i++ {
+		res.InvCov[i*dim+i] = 1 / regularization
+	}
+	return res
+}
+
+// Weights returns the ridge regression solution.
+func (l *LinearArm) Weights() linalg.Vector {
+	return l.invCovProduct(l.Target)
+}
+
+// Variance returns x^T*A^-1*x, which measures the
+// uncertainty about the reward for the context x.
+func (l *LinearArm) Variance(x linalg.Vector) float64 {
+	return math.Max(0, x.Dot(l.invCovProduct(x)))
+}
+
+// update adds an observation, updating InvCov with the
+// Sherman-Morrison formula.
+func (l *LinearArm) update(x linalg.Vector, reward float64) {
+	if len(x) != len(l.Target) {
+		panic("context has incorrect dimensionality")
+	}
+	product := l.invCovProduct(x)
+	scale := 1 / (1 + x.Dot(product))
+	dim := len(x)
+	for i, a := range product {
+		for j, b := range product {
+			l.InvCov[i*dim+j] -= scale * a * b
+		}
+	}
+	l.Target.Add(x.Copy().Scale(reward))
+}
+
+func (l *LinearArm) invCovProduct(x linalg.Vector) linalg.Vector {
+	if len(x) != len(l.Target) {
+		panic("context has incorrect dimensionality")
+	}
+	dim := len(x)
+	res := make(linalg.Vector, dim)
+	for i := range res {
+		res[i] = linalg.Vector(l.InvCov[i*dim : (i+1)*dim]).Dot(x)
+	}
+	return res
+}
+
+// LinUCB is a contextual bandit which pulls the arm with
+// the highest upper confidence bound according to a
+// per-arm linear model, as described in Li et al.
+// (2010).
+//
+// The exported fields should not be modified while the
+// bandit is in use.
+type LinUCB struct {
+	// Alpha scales the width of the confidence bounds.
+	Alpha float64
+
+	Arms []*LinearArm
+
+	lock sync.Mutex
+}
+
+// NewLinUCB creates a LinUCB bandit for contexts of the
+// given dimensionality.
+func NewLinUCB(numArms, dim int, alpha float64) *LinUCB {
+	res := &LinUCB{Alpha: alpha}
+	for i := 0; i < numArms; i++ {
+		res.Arms = append(res.Arms, newLinearArm(dim, 1))
+	}
+	return res
+}
+
+// DeserializeLinUCB deserializes a LinUCB bandit.
+func DeserializeLinUCB(d []byte) (*LinUCB, error) {
+	var res LinUCB
+	if err := json.Unmarshal(d, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (l *LinUCB) NumArms() int {
+	return len(l.Arms)
+}
+
+func (l *LinUCB) Select(context linalg.Vector) int {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	bounds := make([]float64, len(l.Arms))
+	for i, arm := range l.Arms {
+		bounds[i] = arm.Weights().Dot(context) + l.Alpha*math.Sqrt(arm.Variance(context))
+	}
+	return argmax(bounds)
+}
+
+func (l *LinUCB) Update(context linalg.Vector, arm int, reward float64) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.Arms[arm].update(context, reward)
+}
+
+func (l *LinUCB) Serialize() ([]byte, error) {
+	return serializeLocked(&l.lock, l)
+}
+
+func (l *LinUCB) SerializerType() string {
+	return "github.com/unixpickle/weakai/bandits.LinUCB"
+}
+
+// LinearThompson is a contextual bandit which performs
+// Thompson sampling with a per-arm Bayesian linear
+// model, as described in Agrawal and Goyal (2013).
+//
+// The exported fields should not be modified while the
+// bandit is in use.
+type LinearThompson struct {
+	// Scale is the standard deviation of the posterior
+	// for a unit of uncertainty, which controls the
+	// amount of exploration.
+	Scale float64
+
+	Arms []*LinearArm
+
+	lock sync.Mutex
+}
+
+// NewLinearThompson creates a LinearThompson bandit for
+// contexts of the given dimensionality.
+func NewLinearThompson(numArms, dim int, scale float64) *LinearThompson {
+	res := &LinearThompson{Scale: scale}
+	for i := 0; i < numArms; i++ {
+		res.Arms = append(res.Arms, newLinearArm(dim, 1))
+	}
+	return res
+}
+
+// DeserializeLinearThompson deserializes a
+// LinearThompson bandit.
+func DeserializeLinearThompson(d []byte) (*LinearThompson, error) {
+	var res LinearThompson
+	if err := json.Unmarshal(d, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (l *LinearThompson) NumArms() int {
+	return len(l.Arms)
+}
+
+// Select samples each arm's expected reward for the
+// context from its posterior and chooses the best arm.
+//
+// Since only the reward for the given context matters,
+// the one-dimensional posterior of the reward is
+// sampled rather than the full posterior of the
+// weights.
+func (l *LinearThompson) Select(context linalg.Vector) int {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	samples := make([]float64, len(l.Arms))
+	for i, arm := range l.Arms {
+		stddev := l.Scale * math.Sqrt(arm.Variance(context))
+		samples[i] = arm.Weights().Dot(context) + rand.NormFloat64()*stddev
+	}
+	return argmax(samples)
+}
+
+func (l *LinearThompson) Update(context linalg.Vector, arm int, reward float64) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.Arms[arm].update(context, reward)
+}
+
+func (l *LinearThompson) Serialize() ([]byte, error) {
+	return serializeLocked(&l.lock, l)
+}
+
+func (l *LinearThompson) SerializerType() string {
+	return "github.com/unixpickle/weakai/bandits.LinearThompson"
+}
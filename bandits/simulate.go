@@ -0,0 +1,128 @@
+package bandits
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/unixpickle/num-analysis/linalg"
+)
+
+// An Arm is a simulated source of rewards.
+type Arm interface {
+	// Mean returns the expected reward.
+	Mean() float64
+
+	// Sample samples a reward.
+	Sample() float64
+}
+
+// A BernoulliArm gives a reward of 1 with probability P
+// and 0 otherwise.
+type BernoulliArm struct {
+	P float64
+}
+
+func (b BernoulliArm) Mean() float64 {
+	return b.P
+}
+
+func (b BernoulliArm) Sample() float64 {
+	if rand.Float64() < b.P {
+		return 1
+	}
+	return 0
+}
+
+// A GaussianArm gives normally distributed rewards.
+type GaussianArm struct {
+	Mu     float64
+	Stddev float64
+}
+
+func (g GaussianArm) Mean() float64 {
+	return g.Mu
+}
+
+func (g GaussianArm) Sample() float64 {
+	return g.Mu + rand.NormFloat64()*g.Stddev
+}
+
+// Simulate runs a bandit on simulated arms for some
+// number of steps.
+// It returns the cumulative expected regret after each
+// step, i.e. the regret curve.
+func Simulate(b Bandit, arms []Arm, steps int) []float64 {
+	best := math.Inf(-1)
+	for _, arm := range arms {
+		best = math.Max(best, arm.Mean())
+	}
+	res := make([]float64, steps)
+	var regret float64
+	for i := range res {
+		idx := b.Select()
+		arm := arms[idx]
+		b.Update(idx, arm.Sample())
+		regret += best - arm.Mean()
+		res[i] = regret
+	}
+	return res
+}
+
+// A ContextualEnvironment simulates a contextual bandit
+// problem.
+type ContextualEnvironment interface {
+	// Context samples a new context.
+	Context() linalg.Vector
+
+	// Mean returns the expected reward of an arm in a
+	// context.
+	Mean(context linalg.Vector, arm int) float64
+
+	// Sample samples a reward for an arm in a context.
+	Sample(context linalg.Vector, arm int) float64
+}
+
+// A LinearEnvironment is a ContextualEnvironment in which
+// each arm's expected reward is a dot product between
+// the context and the arm's weights.
+// Contexts are sampled from a standard normal
+// distribution.
+type LinearEnvironment struct {
+	Weights []linalg.Vector
+	Noise   float64
+}
+
+func (l *LinearEnvironment) Context() linalg.Vector {
+	res := make(linalg.Vector, len(l.Weights[0]))
+	for i := range res {
+		res[i] = rand.NormFloat64()
+	}
+	return res
+}
+
+func (l *LinearEnvironment) Mean(context linalg.Vector, arm int) float64 {
+	return l.Weights[arm].Dot(context)
+}
+
+func (l *LinearEnvironment) Sample(context linalg.Vector, arm int) float64 {
+	return l.Mean(context, arm) + rand.NormFloat64()*l.Noise
+}
+
+// SimulateContextual is like Simulate, but for a
+// contextual bandit.
+func SimulateContextual(b ContextualBandit, env ContextualEnvironment, steps int) []float64 {
+	res := make([]float64, steps)
+	var regret float64
+	for i := range res {
+		context := env.Context()
+		best := math.Inf(-1)
+		for arm := 0; arm < b.NumArms(); arm++ {
+			best = math.Max(best, env.Mean(context, arm))
+		}
+		arm := b.Select(context)
+		b.Update(context, arm, env.Sample(context, arm))
+		regret += best - env.Mean(context, arm)
+		res[i] = regret
+	}
+	return res
+}
@@ -0,0 +1,188 @@
+package bandits
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"sync"
+
+	"github.com/unixpickle/serializer"
+)
+
+func init() {
+	var b BetaThompson
+	serializer.RegisterTypedDeserializer(b.SerializerType(), DeserializeBetaThompson)
+	var g GaussianThompson
+	serializer.RegisterTypedDeserializer(g.SerializerType(), DeserializeGaussianThompson)
+}
+
+// BetaThompson performs Thompson sampling for rewards in
+// [0, 1] using a Beta-Bernoulli model.
+//
+// Each arm starts with a Beta(PriorAlpha, PriorBeta)
+// prior, and a reward r counts as r successes and 1-r
+// failures.
+//
+// The exported fields should not be modified while the
+// bandit is in use.
+type BetaThompson struct {
+	PriorAlpha float64
+	PriorBeta  float64
+	Stats      ArmStats
+
+	lock sync.Mutex
+}
+
+// NewBetaThompson creates a BetaThompson bandit with a
+// uniform prior.
+func NewBetaThompson(numArms int) *BetaThompson {
+	return &BetaThompson{
+		PriorAlpha: 1,
+		PriorBeta:  1,
+		Stats:      newArmStats(numArms),
+	}
+}
+
+// DeserializeBetaThompson deserializes a BetaThompson
+// bandit.
+func DeserializeBetaThompson(d []byte) (*BetaThompson, error) {
+	var res BetaThompson
+	if err := json.Unmarshal(d, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (b *BetaThompson) NumArms() int {
+	return len(b.Stats.Counts)
+}
+
+// Select samples a success probability for each arm from
+// its posterior and chooses the best arm.
+func (b *BetaThompson) Select() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	samples := make([]float64, b.NumArms())
+	for i, count := range b.Stats.Counts {
+		successes := b.Stats.Sums[i]
+		samples[i] = sampleBeta(b.PriorAlpha+successes, b.PriorBeta+count-successes)
+	}
+	return argmax(samples)
+}
+
+// Update records a reward, which must be in [0, 1].
+func (b *BetaThompson) Update(arm int, reward float64) {
+	if reward < 0 || reward > 1 {
+		panic("reward must be in [0, 1]")
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.Stats.update(arm, reward)
+}
+
+func (b *BetaThompson) Serialize() ([]byte, error) {
+	return serializeLocked(&b.lock, b)
+}
+
+func (b *BetaThompson) SerializerType() string {
+	return "github.com/unixpickle/weakai/bandits.BetaThompson"
+}
+
+// GaussianThompson performs Thompson sampling for
+// real-valued rewards with Gaussian noise of a known
+// variance.
+//
+// Each arm's mean starts with a Gaussian prior.
+//
+// The exported fields should not be modified while the
+// bandit is in use.
+type GaussianThompson struct {
+	PriorMean     float64
+	PriorVariance float64
+	NoiseVariance float64
+	Stats         ArmStats
+
+	lock sync.Mutex
+}
+
+// NewGaussianThompson creates a GaussianThompson bandit
+// with a standard normal prior and unit noise variance.
+func NewGaussianThompson(numArms int) *GaussianThompson {
+	return &GaussianThompson{
+		PriorVariance: 1,
+		NoiseVariance: 1,
+		Stats:         newArmStats(numArms),
+	}
+}
+
+// DeserializeGaussianThompson deserializes a
+// GaussianThompson bandit.
+func DeserializeGaussianThompson(d []byte) (*GaussianThompson, error) {
+	var res GaussianThompson
+	if err := json.Unmarshal(d, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (g *GaussianThompson) NumArms() int {
+	return len(g.Stats.Counts)
+}
+
+// Select samples a mean for each arm from its posterior
+// and chooses the best arm.
+func (g *GaussianThompson) Select() int {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	samples := make([]float64, g.NumArms())
+	for i, count := range g.Stats.Counts {
+		precision := 1/g.PriorVariance + count/g.NoiseVariance
+		mean := (g.PriorMean/g.PriorVariance + g.Stats.Sums[i]/g.NoiseVariance) / precision
+		samples[i] = mean + rand.NormFloat64()/math.Sqrt(precision)
+	}
+	return argmax(samples)
+}
+
+func (g *GaussianThompson) Update(arm int, reward float64) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.Stats.update(arm, reward)
+}
+
+func (g *GaussianThompson) Serialize() ([]byte, error) {
+	return serializeLocked(&g.lock, g)
+}
+
+func (g *GaussianThompson) SerializerType() string {
+	return "github.com/unixpickle/weakai/bandits.GaussianThompson"
+}
+
+// sampleBeta samples from a Beta distribution.
+func sampleBeta(alpha, beta float64) float64 {
+	x := sampleGamma(alpha)
+	y := sampleGamma(beta)
+	return x / (x + y)
+}
+
+// sampleGamma samples from a Gamma distribution with
+// unit scale using the method of Marsaglia and Tsang.
+func sampleGamma(shape float64) float64 {
+	if shape < 1 {
+		// Boost the shape and correct the sample.
+		return sampleGamma(shape+1) * math.Pow(rand.Float64(), 1/shape)
+	}
+	d := shape - 1.0/3
+	c := 1 / math.Sqrt(9*d)
+	for {
+		x := rand.NormFloat64()
+		v := 1 + c*x
+		if v <= 0 {
+			continue
+		}
+		v = v * v * v
+		u := rand.Float64()
+		if math.Log(u) < 0.5*x*x+d-d*v+d*math.Log(v) {
+			return d * v
+		}
+	}
+}
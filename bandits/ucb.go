@@ -0,0 +1,82 @@
+package bandits
+
+import (
+	"encoding/json"
+	"math"
+	"sync"
+
+	"github.com/unixpickle/serializer"
+)
+
+func init() {
+	var u UCB1
+	serializer.RegisterTypedDeserializer(u.SerializerType(), DeserializeUCB1)
+}
+
+// UCB1 pulls the arm with the highest upper confidence
+// bound on its mean reward, as described in Auer et al.
+// (2002).
+//
+// The confidence bounds assume rewards in [0, 1].
+// For other reward ranges, Scale should be set to the
+// width of the range.
+//
+// The exported fields should not be modified while the
+// bandit is in use.
+type UCB1 struct {
+	Scale float64
+	Stats ArmStats
+
+	lock sync.Mutex
+}
+
+// NewUCB1 creates a UCB1 bandit for rewards in [0, 1].
+func NewUCB1(numArms int) *UCB1 {
+	return &UCB1{Scale: 1, Stats: newArmStats(numArms)}
+}
+
+// DeserializeUCB1 deserializes a UCB1 bandit.
+func DeserializeUCB1(d []byte) (*UCB1, error) {
+	var res UCB1
+	if err := json.Unmarshal(d, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (u *UCB1) NumArms() int {
+	return len(u.Stats.Counts)
+}
+
+// Select chooses an arm.
+// Arms which have never been pulled are tried first.
+func (u *UCB1) Select() int {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	if arm, ok := unpulledArm(&u.Stats); ok {
+		return arm
+	}
+	var total float64
+	for _, count := range u.Stats.Counts {
+		total += count
+	}
+	bounds := make([]float64, u.NumArms())
+	for i, count := range u.Stats.Counts {
+		bounds[i] = u.Stats.Mean(i) + u.Scale*math.Sqrt(2*math.Log(total)/count)
+	}
+	return argmax(bounds)
+}
+
+func (u *UCB1) Update(arm int, reward float64) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	u.Stats.update(arm, reward)
+}
+
+func (u *UCB1) Serialize() ([]byte, error) {
+	return serializeLocked(&u.lock, u)
+}
+
+func (u *UCB1) SerializerType() string {
+	return "github.com/unixpickle/weakai/bandits.UCB1"
+}
@@ -0,0 +1,239 @@
+// Package bayesnet implements discrete Bayesian
+// networks, including exact and approximate inference
+// and parameter learning.
+package bayesnet
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+const probSumEpsilon = 1e-6
+
+// A Variable is a discrete random variable in a
+// Bayesian network.
+type Variable struct {
+	// Name is used when printing errors.
+	Name string
+
+	// Values names the values this variable can take.
+	// The number of values is len(Values), and values
+	// are referred to by their indices.
+	Values []string
+
+	// Parents lists the variables this variable is
+	// conditioned on.
+	Parents []*Variable
+
+	// CPT is the conditional probability table.
+	// There is one row per parent configuration, and
+	// each row is a distribution over the values of
+	// this variable.
+	//
+	// Parent configurations are ordered like the digits
+	// of a number, with the last parent varying fastest.
+	// See ParentConfig.
+	CPT [][]float64
+}
+
+// NewVariable creates a Variable with a uniform CPT.
+func NewVariable(name string, values []string, parents ...*Variable) *Variable {
+	res := &Variable{
+		Name:    name,
+		Values:  values,
+		Parents: parents,
+	}
+	res.CPT = make([][]float64, res.NumParentConfigs())
+	for i := range res.CPT {
+		res.CPT[i] = make([]float64, len(values))
+		for j := range res.CPT[i] {
+			res.CPT[i][j] = 1 / float64(len(values))
+		}
+	}
+	return res
+}
+
+// NumParentConfigs returns the number of possible
+// joint assignments to v's parents.
+func (v *Variable) NumParentConfigs() int {
+	res := 1
+	for _, p := range v.Parents {
+		res *= len(p.Values)
+	}
+	return res
+}
+
+// ParentConfig returns the row of the CPT which
+// corresponds to the parent values in a.
+// All of v's parents must be present in a.
+func (v *Variable) ParentConfig(a Assignment) int {
+	var res int
+	for _, p := range v.Parents {
+		res = res*len(p.Values) + a[p]
+	}
+	return res
+}
+
+// Prob returns the probability that v takes the
+// value it has in a, given its parents' values in a.
+func (v *Variable) Prob(a Assignment) float64 {
+	return v.CPT[v.ParentConfig(a)][a[v]]
+}
+
+// An Assignment maps variables to value indices.
+type Assignment map[*Variable]int
+
+// Copy creates a shallow copy of the assignment.
+func (a Assignment) Copy() Assignment {
+	res := Assignment{}
+	for k, v := range a {
+		res[k] = v
+	}
+	return res
+}
+
+// A Network is a Bayesian network.
+//
+// The variables in a Network must be listed in
+// topological order, so that every variable comes
+// after all of its parents.
+// This ordering also guarantees that the graph is
+// acyclic.
+type Network []*Variable
+
+// Validate makes sure that the network is ordered
+// topologically, that every parent is in the network,
+// and that every CPT has the right shape and holds
+// valid distributions.
+func (n Network) Validate() error {
+	seen := map[*Variable]bool{}
+	for _, v := range n {
+		if seen[v] {
+			return fmt.Errorf("variable %s appears twice", v.Name)
+		}
+		if len(v.Values) == 0 {
+			return fmt.Errorf("variable %s has no values", v.Name)
+		}
+		parentSet := map[*Variable]bool{}
+		for _, p := range v.Parents {
+			if !seen[p] {
+				return fmt.Errorf("parent %s of %s is missing or out of order",
+					p.Name, v.Name)
+			}
+			if parentSet[p] {
+				return fmt.Errorf("parent %s of %s is repeated", p.Name, v.Name)
+			}
+			parentSet[p] = true
+		}
+		if len(v.CPT) != v.NumParentConfigs() {
+			return fmt.Errorf("variable %s should have %d CPT rows but has %d",
+				v.Name, v.NumParentConfigs(), len(v.CPT))
+		}
+		for i, row := range v.CPT {
+			if len(row) != len(v.Values) {
+				return fmt.Errorf("CPT row %d of %s has %d entries (expected %d)",
+					i, v.Name, len(row), len(v.Values))
+			}
+			var sum float64
+			for _, x := range row {
+				if x < 0 || math.IsNaN(x) {
+					return fmt.Errorf("CPT row %d of %s has invalid entry", i, v.Name)
+				}
+				sum += x
+			}
+			if math.Abs(sum-1) > probSumEpsilon {
+				return fmt.Errorf("CPT row %d of %s sums to %f", i, v.Name, sum)
+			}
+		}
+		seen[v] = true
+	}
+	return nil
+}
+
+// JointProb computes the probability of a complete
+// assignment to the variables in the network.
+func (n Network) JointProb(a Assignment) float64 {
+	res := 1.0
+	for _, v := range n {
+		res *= v.Prob(a)
+	}
+	return res
+}
+
+// Sample draws a complete assignment from the joint
+// distribution using forward sampling.
+//
+// If r is nil, the math/rand package is used.
+func (n Network) Sample(r *rand.Rand) Assignment {
+	res := Assignment{}
+	for _, v := range n {
+		res[v] = sampleDist(r, v.CPT[v.ParentConfig(res)])
+	}
+	return res
+}
+
+func (n Network) children() map[*Variable][]*Variable {
+	res := map[*Variable][]*Variable{}
+	for _, v := range n {
+		for _, p := range v.Parents {
+			res[p] = append(res[p], v)
+		}
+	}
+	return res
+}
+
+func checkQuery(n Network, query *Variable, evidence Assignment) error {
+	var found bool
+	for _, v := range n {
+		if v == query {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return errors.New("query variable is not in the network")
+	}
+	if _, ok := evidence[query]; ok {
+		return errors.New("query variable is part of the evidence")
+	}
+	return nil
+}
+
+func normalizeDist(d []float64) error {
+	var sum float64
+	for _, x := range d {
+		sum += x
+	}
+	if sum == 0 || math.IsNaN(sum) {
+		return errors.New("evidence has zero probability")
+	}
+	for i := range d {
+		d[i] /= sum
+	}
+	return nil
+}
+
+func sampleDist(r *rand.Rand, d []float64) int {
+	var x float64
+	if r == nil {
+		x = rand.Float64()
+	} else {
+		x = r.Float64()
+	}
+	for i, p := range d {
+		x -= p
+		if x < 0 {
+			return i
+		}
+	}
+	// Deal with rounding errors by picking the last
+	// value with non-zero probability.
+	for i := len(d) - 1; i > 0; i-- {
+		if d[i] > 0 {
+			return i
+		}
+	}
+	return 0
+}
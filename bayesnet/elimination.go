@@ -0,0 +1,113 @@
+package bayesnet
+
+// VariableElimination computes the posterior
+// distribution of query given the evidence using
+// exact variable elimination.
+//
+// Variables which are not ancestors of the query or
+// the evidence are pruned before elimination, and the
+// remaining variables are eliminated in a greedy
+// order which keeps intermediate factors small.
+func (n Network) VariableElimination(query *Variable, evidence Assignment) ([]float64,
+	error) {
+	if err := checkQuery(n, query, evidence); err != nil {
+		return nil, err
+	}
+
+	relevant := n.ancestors(query, evidence)
+	var factors []*factor
+	var hidden []*Variable
+	for _, v := range n {
+		if !relevant[v] {
+			continue
+		}
+		factors = append(factors, cptFactor(v).reduce(evidence))
+		if _, ok := evidence[v]; !ok && v != query {
+			hidden = append(hidden, v)
+		}
+	}
+
+	for len(hidden) > 0 {
+		idx := cheapestElimination(hidden, factors)
+		v := hidden[idx]
+		hidden[idx] = hidden[len(hidden)-1]
+		hidden = hidden[:len(hidden)-1]
+		factors = eliminateVar(v, factors)
+	}
+
+	joint := newFactor([]*Variable{query}, 1)
+	for _, f := range factors {
+		joint = joint.product(f)
+	}
+	res := joint.project([]*Variable{query}).table
+	if err := normalizeDist(res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ancestors returns the set of variables which are
+// the query, in the evidence, or ancestors thereof.
+func (n Network) ancestors(query *Variable, evidence Assignment) map[*Variable]bool {
+	res := map[*Variable]bool{}
+	var visit func(v *Variable)
+	visit = func(v *Variable) {
+		if res[v] {
+			return
+		}
+		res[v] = true
+		for _, p := range v.Parents {
+			visit(p)
+		}
+	}
+	visit(query)
+	for v := range evidence {
+		visit(v)
+	}
+	return res
+}
+
+// eliminateVar multiplies together every factor that
+// mentions v and then sums v out of the product.
+func eliminateVar(v *Variable, factors []*factor) []*factor {
+	var res []*factor
+	var product *factor
+	for _, f := range factors {
+		if !f.contains(v) {
+			res = append(res, f)
+		} else if product == nil {
+			product = f
+		} else {
+			product = product.product(f)
+		}
+	}
+	if product != nil {
+		res = append(res, product.sumOut(v))
+	}
+	return res
+}
+
+// cheapestElimination finds the variable whose
+// elimination would create the smallest factor.
+func cheapestElimination(vars []*Variable, factors []*factor) int {
+	var bestIdx, bestSize int
+	for i, v := range vars {
+		neighbors := map[*Variable]bool{}
+		for _, f := range factors {
+			if f.contains(v) {
+				for _, x := range f.vars {
+					neighbors[x] = true
+				}
+			}
+		}
+		size := 1
+		for x := range neighbors {
+			size *= len(x.Values)
+		}
+		if i == 0 || size < bestSize {
+			bestIdx = i
+			bestSize = size
+		}
+	}
+	return bestIdx
+}
@@ -0,0 +1,163 @@
+package bayesnet
+
+// A factor is a non-negative function of a set of
+// discrete variables, stored as a dense table.
+// Entries are ordered with the last variable varying
+// fastest.
+type factor struct {
+	vars  []*Variable
+	table []float64
+}
+
+func newFactor(vars []*Variable, fill float64) *factor {
+	size := 1
+	for _, v := range vars {
+		size *= len(v.Values)
+	}
+	res := &factor{vars: vars, table: make([]float64, size)}
+	for i := range res.table {
+		res.table[i] = fill
+	}
+	return res
+}
+
+// cptFactor creates a factor over a variable and its
+// parents which is equivalent to the variable's CPT.
+func cptFactor(v *Variable) *factor {
+	vars := append(append([]*Variable{}, v.Parents...), v)
+	res := newFactor(vars, 0)
+	for i, row := range v.CPT {
+		copy(res.table[i*len(v.Values):], row)
+	}
+	return res
+}
+
+func (f *factor) contains(v *Variable) bool {
+	for _, x := range f.vars {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// forEach calls fn for every entry in the table,
+// passing the value of each variable.
+// The values slice is reused between calls.
+func (f *factor) forEach(fn func(idx int, values []int)) {
+	values := make([]int, len(f.vars))
+	for idx := range f.table {
+		fn(idx, values)
+		for i := len(values) - 1; i >= 0; i-- {
+			values[i]++
+			if values[i] < len(f.vars[i].Values) {
+				break
+			}
+			values[i] = 0
+		}
+	}
+}
+
+// indexer returns a function which maps the values of
+// superVars to an index in f's table.
+// Every variable in f must be in superVars.
+func (f *factor) indexer(superVars []*Variable) func(values []int) int {
+	positions := make([]int, len(f.vars))
+	for i, v := range f.vars {
+		positions[i] = -1
+		for j, s := range superVars {
+			if s == v {
+				positions[i] = j
+				break
+			}
+		}
+		if positions[i] < 0 {
+			panic("variable missing from superset")
+		}
+	}
+	return func(values []int) int {
+		var idx int
+		for i, p := range positions {
+			idx = idx*len(f.vars[i].Values) + values[p]
+		}
+		return idx
+	}
+}
+
+// product computes the pointwise product of two
+// factors.
+func (f *factor) product(f1 *factor) *factor {
+	vars := append([]*Variable{}, f.vars...)
+	for _, v := range f1.vars {
+		if !f.contains(v) {
+			vars = append(vars, v)
+		}
+	}
+	res := newFactor(vars, 0)
+	idx1 := f.indexer(vars)
+	idx2 := f1.indexer(vars)
+	res.forEach(func(idx int, values []int) {
+		res.table[idx] = f.table[idx1(values)] * f1.table[idx2(values)]
+	})
+	return res
+}
+
+// sumOut marginalizes a variable out of the factor.
+func (f *factor) sumOut(v *Variable) *factor {
+	var vars []*Variable
+	for _, x := range f.vars {
+		if x != v {
+			vars = append(vars, x)
+		}
+	}
+	res := newFactor(vars, 0)
+	resIdx := res.indexer(f.vars)
+	f.forEach(func(idx int, values []int) {
+		res.table[resIdx(values)] += f.table[idx]
+	})
+	return res
+}
+
+// project marginalizes out every variable which is
+// not in keep.
+func (f *factor) project(keep []*Variable) *factor {
+	res := f
+	for _, v := range f.vars {
+		var found bool
+		for _, k := range keep {
+			if k == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			res = res.sumOut(v)
+		}
+	}
+	return res
+}
+
+// reduce conditions the factor on the evidence,
+// removing the observed variables.
+func (f *factor) reduce(evidence Assignment) *factor {
+	var vars []*Variable
+	for _, v := range f.vars {
+		if _, ok := evidence[v]; !ok {
+			vars = append(vars, v)
+		}
+	}
+	if len(vars) == len(f.vars) {
+		return f
+	}
+	res := newFactor(vars, 0)
+	resIdx := res.indexer(f.vars)
+	f.forEach(func(idx int, values []int) {
+		for i, v := range f.vars {
+			if val, ok := evidence[v]; ok && values[i] != val {
+				return
+			}
+		}
+		res.table[resIdx(values)] = f.table[idx]
+	})
+	return res
+}
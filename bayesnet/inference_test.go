@@ -0,0 +1,160 @@
+package bayesnet
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func sprinklerNetwork() Network {
+	boolVals := []string{"false", "true"}
+	cloudy := NewVariable("cloudy", boolVals)
+	cloudy.CPT = [][]float64{{0.5, 0.5}}
+	sprinkler := NewVariable("sprinkler", boolVals, cloudy)
+	sprinkler.CPT = [][]float64{{0.5, 0.5}, {0.9, 0.1}}
+	rain := NewVariable("rain", boolVals, cloudy)
+	rain.CPT = [][]float64{{0.8, 0.2}, {0.2, 0.8}}
+	wet := NewVariable("wet", boolVals, sprinkler, rain)
+	wet.CPT = [][]float64{{1, 0}, {0.1, 0.9}, {0.1, 0.9}, {0.01, 0.99}}
+	weather := NewVariable("weather", []string{"sun", "fog", "storm"}, rain, cloudy)
+	weather.CPT = [][]float64{
+		{0.7, 0.2, 0.1},
+		{0.3, 0.5, 0.2},
+		{0.2, 0.3, 0.5},
+		{0.05, 0.15, 0.8},
+	}
+	return Network{cloudy, sprinkler, rain, wet, weather}
+}
+
+// enumerationMarginal computes a posterior by brute
+// force summation over the joint distribution.
+func enumerationMarginal(n Network, query *Variable, evidence Assignment) []float64 {
+	res := make([]float64, len(query.Values))
+	f := newFactor(n, 0)
+	f.forEach(func(idx int, values []int) {
+		a := Assignment{}
+		for i, v := range n {
+			a[v] = values[i]
+		}
+		for v, val := range evidence {
+			if a[v] != val {
+				return
+			}
+		}
+		res[a[query]] += n.JointProb(a)
+	})
+	normalizeDist(res)
+	return res
+}
+
+func TestValidate(t *testing.T) {
+	net := sprinklerNetwork()
+	if err := net.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	reversed := Network{net[1], net[0]}
+	if reversed.Validate() == nil {
+		t.Error("expected error for bad ordering")
+	}
+	net[2].CPT[1][0] = 0.5
+	if net.Validate() == nil {
+		t.Error("expected error for bad CPT")
+	}
+}
+
+func TestVariableElimination(t *testing.T) {
+	net := sprinklerNetwork()
+	cloudy, sprinkler, rain, wet, weather := net[0], net[1], net[2], net[3], net[4]
+	evidences := []Assignment{
+		{},
+		{wet: 1},
+		{wet: 1, sprinkler: 0},
+		{weather: 2},
+		{weather: 1, wet: 0},
+	}
+	for i, evidence := range evidences {
+		for _, query := range []*Variable{cloudy, rain, wet, weather} {
+			if _, ok := evidence[query]; ok {
+				continue
+			}
+			actual, err := net.VariableElimination(query, evidence)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := enumerationMarginal(net, query, evidence)
+			if !distsClose(actual, expected, 1e-8) {
+				t.Errorf("evidence %d query %s: expected %v but got %v", i, query.Name,
+					expected, actual)
+			}
+		}
+	}
+
+	if _, err := net.VariableElimination(wet, Assignment{wet: 1}); err == nil {
+		t.Error("expected error for observed query")
+	}
+	_, err := net.VariableElimination(cloudy, Assignment{sprinkler: 0, rain: 0, wet: 1})
+	if err == nil {
+		t.Error("expected error for impossible evidence")
+	}
+}
+
+func TestJunctionTree(t *testing.T) {
+	net := sprinklerNetwork()
+	sprinkler, wet, weather := net[1], net[3], net[4]
+	jt, err := NewJunctionTree(net)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, evidence := range []Assignment{{}, {wet: 1}, {weather: 0, sprinkler: 1}} {
+		marginals, err := jt.Marginals(evidence)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, v := range net {
+			if _, ok := evidence[v]; ok {
+				continue
+			}
+			expected := enumerationMarginal(net, v, evidence)
+			if !distsClose(marginals[v], expected, 1e-8) {
+				t.Errorf("evidence %d var %s: expected %v but got %v", i, v.Name,
+					expected, marginals[v])
+			}
+		}
+	}
+}
+
+func TestApproximateInference(t *testing.T) {
+	net := sprinklerNetwork()
+	cloudy, wet := net[0], net[3]
+	evidence := Assignment{wet: 1}
+	expected := enumerationMarginal(net, cloudy, evidence)
+
+	r := rand.New(rand.NewSource(1337))
+	lw, err := net.LikelihoodWeighting(r, cloudy, evidence, 20000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !distsClose(lw, expected, 0.02) {
+		t.Errorf("likelihood weighting: expected %v but got %v", expected, lw)
+	}
+
+	gibbs, err := net.Gibbs(r, cloudy, evidence, 100, 20000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !distsClose(gibbs, expected, 0.02) {
+		t.Errorf("Gibbs: expected %v but got %v", expected, gibbs)
+	}
+}
+
+func distsClose(d1, d2 []float64, prec float64) bool {
+	if len(d1) != len(d2) {
+		return false
+	}
+	for i, x := range d1 {
+		if math.Abs(x-d2[i]) > prec {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,293 @@
+package bayesnet
+
+import "errors"
+
+// A JunctionTree is a tree of cliques which can be
+// used to compute every posterior marginal in a
+// network at once.
+//
+// Building a JunctionTree is relatively expensive,
+// but the result can be reused for any evidence.
+type JunctionTree struct {
+	net Network
+
+	// cliques stores the variables in each clique.
+	cliques [][]*Variable
+
+	// neighbors stores the adjacency list of the tree.
+	neighbors [][]int
+
+	// families stores the variables whose CPTs have
+	// been assigned to each clique.
+	families [][]*Variable
+}
+
+// NewJunctionTree compiles a network into a junction
+// tree by moralizing the network, triangulating the
+// moral graph, and joining the maximal cliques with a
+// maximum-weight spanning tree.
+func NewJunctionTree(n Network) (*JunctionTree, error) {
+	if err := n.Validate(); err != nil {
+		return nil, err
+	}
+	if len(n) == 0 {
+		return nil, errors.New("empty network")
+	}
+	res := &JunctionTree{net: n}
+	res.cliques = triangulate(moralGraph(n), n)
+	res.neighbors = spanningTree(res.cliques)
+	res.families = make([][]*Variable, len(res.cliques))
+
+FamilyLoop:
+	for _, v := range n {
+		family := append([]*Variable{v}, v.Parents...)
+		for i, c := range res.cliques {
+			if isSubset(family, c) {
+				res.families[i] = append(res.families[i], v)
+				continue FamilyLoop
+			}
+		}
+		panic("family not covered by any clique")
+	}
+
+	return res, nil
+}
+
+// Cliques returns the variables in each clique of the
+// tree.
+// The result should not be modified.
+func (j *JunctionTree) Cliques() [][]*Variable {
+	return j.cliques
+}
+
+// Marginals computes the posterior distribution of
+// every variable in the network given the evidence.
+//
+// Observed variables get a distribution which puts
+// all of its mass on the observed value.
+func (j *JunctionTree) Marginals(evidence Assignment) (map[*Variable][]float64, error) {
+	potentials := make([]*factor, len(j.cliques))
+	for i, c := range j.cliques {
+		var unobserved []*Variable
+		for _, v := range c {
+			if _, ok := evidence[v]; !ok {
+				unobserved = append(unobserved, v)
+			}
+		}
+		potentials[i] = newFactor(unobserved, 1)
+		for _, v := range j.families[i] {
+			potentials[i] = potentials[i].product(cptFactor(v).reduce(evidence))
+		}
+	}
+
+	messages := make([]map[int]*factor, len(j.cliques))
+	for i := range messages {
+		messages[i] = map[int]*factor{}
+	}
+	j.collect(0, -1, potentials, messages)
+	j.distribute(0, -1, potentials, messages)
+
+	res := map[*Variable][]float64{}
+	for v, val := range evidence {
+		res[v] = make([]float64, len(v.Values))
+		res[v][val] = 1
+	}
+	for i, c := range j.cliques {
+		belief := potentials[i]
+		for _, msg := range messages[i] {
+			belief = belief.product(msg)
+		}
+		for _, v := range c {
+			if _, ok := res[v]; ok {
+				continue
+			}
+			dist := belief.project([]*Variable{v}).table
+			if err := normalizeDist(dist); err != nil {
+				return nil, err
+			}
+			res[v] = dist
+		}
+	}
+	return res, nil
+}
+
+// collect sends messages from the leaves up towards
+// the root of the tree.
+// The messages[i][k] entry is the message that clique
+// i received from clique k.
+func (j *JunctionTree) collect(node, parent int, potentials []*factor,
+	messages []map[int]*factor) {
+	for _, child := range j.neighbors[node] {
+		if child != parent {
+			j.collect(child, node, potentials, messages)
+		}
+	}
+	if parent >= 0 {
+		messages[parent][node] = j.message(node, parent, potentials, messages)
+	}
+}
+
+// distribute sends messages from the root back down
+// to the leaves.
+func (j *JunctionTree) distribute(node, parent int, potentials []*factor,
+	messages []map[int]*factor) {
+	for _, child := range j.neighbors[node] {
+		if child != parent {
+			messages[child][node] = j.message(node, child, potentials, messages)
+			j.distribute(child, node, potentials, messages)
+		}
+	}
+}
+
+func (j *JunctionTree) message(from, to int, potentials []*factor,
+	messages []map[int]*factor) *factor {
+	res := potentials[from]
+	for k, msg := range messages[from] {
+		if k != to {
+			res = res.product(msg)
+		}
+	}
+	res = res.project(intersection(j.cliques[from], j.cliques[to]))
+
+	// Rescale messages to avoid underflow in big trees.
+	var sum float64
+	for _, x := range res.table {
+		sum += x
+	}
+	if sum > 0 {
+		for i := range res.table {
+			res.table[i] /= sum
+		}
+	}
+	return res
+}
+
+// moralGraph connects every variable to its parents
+// and "marries" the parents of every variable.
+func moralGraph(n Network) map[*Variable]map[*Variable]bool {
+	res := map[*Variable]map[*Variable]bool{}
+	connect := func(v1, v2 *Variable) {
+		res[v1][v2] = true
+		res[v2][v1] = true
+	}
+	for _, v := range n {
+		res[v] = map[*Variable]bool{}
+	}
+	for _, v := range n {
+		for i, p := range v.Parents {
+			connect(v, p)
+			for _, p1 := range v.Parents[:i] {
+				connect(p, p1)
+			}
+		}
+	}
+	return res
+}
+
+// triangulate eliminates the variables of an
+// undirected graph in a greedy min-fill order and
+// returns the maximal cliques that this produces.
+// The graph is destroyed in the process.
+func triangulate(graph map[*Variable]map[*Variable]bool, order []*Variable) [][]*Variable {
+	remaining := append([]*Variable{}, order...)
+	var cliques [][]*Variable
+	for len(remaining) > 0 {
+		bestIdx := 0
+		bestFill := -1
+		for i, v := range remaining {
+			fill := fillInCount(graph, v)
+			if bestFill < 0 || fill < bestFill {
+				bestIdx = i
+				bestFill = fill
+			}
+		}
+		v := remaining[bestIdx]
+		remaining = append(remaining[:bestIdx], remaining[bestIdx+1:]...)
+
+		clique := []*Variable{v}
+		for _, x := range order {
+			if graph[v][x] {
+				clique = append(clique, x)
+			}
+		}
+		for _, x := range clique[1:] {
+			for _, y := range clique[1:] {
+				if x != y {
+					graph[x][y] = true
+				}
+			}
+			delete(graph[x], v)
+		}
+		delete(graph, v)
+
+		var subsumed bool
+		for _, c := range cliques {
+			if isSubset(clique, c) {
+				subsumed = true
+				break
+			}
+		}
+		if !subsumed {
+			cliques = append(cliques, clique)
+		}
+	}
+	return cliques
+}
+
+func fillInCount(graph map[*Variable]map[*Variable]bool, v *Variable) int {
+	var count int
+	for x := range graph[v] {
+		for y := range graph[v] {
+			if x != y && !graph[x][y] {
+				count++
+			}
+		}
+	}
+	return count / 2
+}
+
+// spanningTree connects the cliques with a maximum
+// spanning tree, where edges are weighted by the size
+// of the clique intersections.
+func spanningTree(cliques [][]*Variable) [][]int {
+	res := make([][]int, len(cliques))
+	inTree := make([]bool, len(cliques))
+	inTree[0] = true
+	for added := 1; added < len(cliques); added++ {
+		bestFrom, bestTo, bestWeight := -1, -1, -1
+		for i, c := range cliques {
+			if !inTree[i] {
+				continue
+			}
+			for k, c1 := range cliques {
+				if inTree[k] {
+					continue
+				}
+				if w := len(intersection(c, c1)); w > bestWeight {
+					bestFrom, bestTo, bestWeight = i, k, w
+				}
+			}
+		}
+		inTree[bestTo] = true
+		res[bestFrom] = append(res[bestFrom], bestTo)
+		res[bestTo] = append(res[bestTo], bestFrom)
+	}
+	return res
+}
+
+func intersection(v1, v2 []*Variable) []*Variable {
+	var res []*Variable
+	for _, x := range v1 {
+		for _, y := range v2 {
+			if x == y {
+				res = append(res, x)
+				break
+			}
+		}
+	}
+	return res
+}
+
+func isSubset(sub, super []*Variable) bool {
+	return len(intersection(sub, super)) == len(sub)
+}
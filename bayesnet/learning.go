@@ -0,0 +1,49 @@
+package bayesnet
+
+import "fmt"
+
+// LearnParameters sets every CPT in the network to
+// the maximum likelihood estimate given a list of
+// complete assignments.
+//
+// The pseudocount is added to every count before
+// normalizing, which is equivalent to MAP estimation
+// with a symmetric Dirichlet prior.
+// Parent configurations which never occur (and have no
+// pseudocounts) are given uniform distributions.
+func (n Network) LearnParameters(samples []Assignment, pseudocount float64) error {
+	for i, sample := range samples {
+		for _, v := range n {
+			val, ok := sample[v]
+			if !ok {
+				return fmt.Errorf("sample %d is missing variable %s", i, v.Name)
+			}
+			if val < 0 || val >= len(v.Values) {
+				return fmt.Errorf("sample %d has invalid value %d for %s", i, val, v.Name)
+			}
+		}
+	}
+
+	for _, v := range n {
+		counts := make([][]float64, v.NumParentConfigs())
+		for i := range counts {
+			counts[i] = make([]float64, len(v.Values))
+			for j := range counts[i] {
+				counts[i][j] = pseudocount
+			}
+		}
+		for _, sample := range samples {
+			counts[v.ParentConfig(sample)][sample[v]]++
+		}
+		for _, row := range counts {
+			if normalizeDist(row) != nil {
+				for j := range row {
+					row[j] = 1 / float64(len(row))
+				}
+			}
+		}
+		v.CPT = counts
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package bayesnet
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestLearnParameters(t *testing.T) {
+	expected := sprinklerNetwork()
+	r := rand.New(rand.NewSource(123))
+	samples := make([]Assignment, 50000)
+	for i := range samples {
+		samples[i] = expected.Sample(r)
+	}
+
+	learned := sprinklerNetwork()
+	for _, v := range learned {
+		for _, row := range v.CPT {
+			for j := range row {
+				row[j] = 1 / float64(len(row))
+			}
+		}
+	}
+
+	// Samples must refer to the learned variables.
+	for i, s := range samples {
+		mapped := Assignment{}
+		for j, v := range expected {
+			mapped[learned[j]] = s[v]
+		}
+		samples[i] = mapped
+	}
+
+	if err := learned.LearnParameters(samples, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := learned.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range learned {
+		for j, row := range v.CPT {
+			if !distsClose(row, expected[i].CPT[j], 0.03) {
+				t.Errorf("var %s row %d: expected %v but got %v", v.Name, j,
+					expected[i].CPT[j], row)
+			}
+		}
+	}
+
+	if learned.LearnParameters([]Assignment{{}}, 0) == nil {
+		t.Error("expected error for incomplete sample")
+	}
+}
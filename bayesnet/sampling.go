@@ -0,0 +1,125 @@
+package bayesnet
+
+import (
+	"errors"
+	"math/rand"
+)
+
+const gibbsInitAttempts = 1000
+
+// LikelihoodWeighting approximates the posterior
+// distribution of query given the evidence.
+//
+// Non-evidence variables are sampled in topological
+// order, and each sample is weighted by the likelihood
+// of the evidence given its parents.
+//
+// If r is nil, the math/rand package is used.
+func (n Network) LikelihoodWeighting(r *rand.Rand, query *Variable, evidence Assignment,
+	numSamples int) ([]float64, error) {
+	if err := checkQuery(n, query, evidence); err != nil {
+		return nil, err
+	}
+	res := make([]float64, len(query.Values))
+	for i := 0; i < numSamples; i++ {
+		sample, weight := n.weightedSample(r, evidence)
+		res[sample[query]] += weight
+	}
+	if err := normalizeDist(res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Gibbs approximates the posterior distribution of
+// query given the evidence using Gibbs sampling.
+//
+// Each sweep resamples every non-evidence variable
+// from its distribution given its Markov blanket.
+// The first burnIn sweeps are discarded, and the
+// value of query is recorded after each of the next
+// numSamples sweeps.
+//
+// If r is nil, the math/rand package is used.
+func (n Network) Gibbs(r *rand.Rand, query *Variable, evidence Assignment,
+	burnIn, numSamples int) ([]float64, error) {
+	if err := checkQuery(n, query, evidence); err != nil {
+		return nil, err
+	}
+
+	var state Assignment
+	for i := 0; i < gibbsInitAttempts; i++ {
+		sample, weight := n.weightedSample(r, evidence)
+		if weight > 0 {
+			state = sample
+			break
+		}
+	}
+	if state == nil {
+		return nil, errors.New("could not find state consistent with evidence")
+	}
+
+	children := n.children()
+	var hidden []*Variable
+	for _, v := range n {
+		if _, ok := evidence[v]; !ok {
+			hidden = append(hidden, v)
+		}
+	}
+
+	res := make([]float64, len(query.Values))
+	for i := 0; i < burnIn+numSamples; i++ {
+		for _, v := range hidden {
+			dist := markovBlanketDist(v, children[v], state)
+			if normalizeDist(dist) == nil {
+				state[v] = sampleDist(r, dist)
+			}
+		}
+		if i >= burnIn {
+			res[state[query]]++
+		}
+	}
+	if err := normalizeDist(res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// weightedSample produces a sample which agrees with
+// the evidence, along with the likelihood weight of
+// the sample.
+func (n Network) weightedSample(r *rand.Rand, evidence Assignment) (Assignment, float64) {
+	sample := Assignment{}
+	weight := 1.0
+	for _, v := range n {
+		dist := v.CPT[v.ParentConfig(sample)]
+		if val, ok := evidence[v]; ok {
+			sample[v] = val
+			weight *= dist[val]
+		} else {
+			sample[v] = sampleDist(r, dist)
+		}
+	}
+	return sample, weight
+}
+
+// markovBlanketDist computes the unnormalized
+// distribution of v given the rest of the state.
+// The state is temporarily modified, but it is
+// restored before the function returns.
+func markovBlanketDist(v *Variable, children []*Variable, state Assignment) []float64 {
+	oldVal := state[v]
+	defer func() {
+		state[v] = oldVal
+	}()
+	res := make([]float64, len(v.Values))
+	for val := range res {
+		state[v] = val
+		prob := v.Prob(state)
+		for _, child := range children {
+			prob *= child.Prob(state)
+		}
+		res[val] = prob
+	}
+	return res
+}
@@ -0,0 +1,287 @@
+package boosting
+
+import (
+	"math"
+	"sort"
+
+	"github.com/unixpickle/num-analysis/linalg"
+)
+
+const (
+	defaultCascadeDetectionRate     = 0.99
+	defaultCascadeFalsePositiveRate = 0.5
+	defaultCascadeMaxStages         = 10
+	defaultCascadeMaxStageSize      = 100
+)
+
+// A SubsetList is a SampleList which can produce lists
+// of some of its samples.
+type SubsetList interface {
+	SampleList
+
+	// Subset returns a list containing the samples at
+	// the given indices, in the given order.
+	Subset(indices []int) SampleList
+}
+
+// A CascadeStage is one stage of a Cascade.
+type CascadeStage struct {
+	Sum SumClassifier
+
+	// Threshold is the minimum output of Sum for which a
+	// sample passes the stage.
+	Threshold float64
+}
+
+// Classify returns the output of the stage's sum minus
+// the threshold, so that samples which pass the stage
+// have non-negative outputs.
+func (c *CascadeStage) Classify(list SampleList) linalg.Vector {
+	res := c.Sum.Classify(list)
+	for i := range res {
+		res[i] -= c.Threshold
+	}
+	return res
+}
+
+// A Cascade is an attentional cascade, as described in
+// Viola and Jones (2001).
+//
+// A sample is classified as positive if it passes every
+// stage, and it is rejected as soon as it fails any
+// stage.
+// Since most negative samples are rejected by the first
+// few stages, which tend to be small, a Cascade can be
+// much cheaper to evaluate than a single classifier of
+// comparable accuracy.
+type Cascade struct {
+	Stages []*CascadeStage
+}
+
+// Classify classifies the samples in the list.
+//
+// Each sample's output is the output of the stage where
+// it exited the cascade: the stage which rejected it,
+// or the last stage if it was accepted.
+// Thus, the sign of an output indicates whether the
+// sample passed every stage.
+//
+// If the list is a SubsetList or a FeatureList, each
+// stage is only evaluated on the samples which passed
+// the previous stages.
+// Otherwise, the list cannot be split up, so every
+// stage is evaluated on every sample, but the results
+// are the same.
+func (c *Cascade) Classify(list SampleList) linalg.Vector {
+	res := make(linalg.Vector, list.Len())
+	remaining := make([]int, list.Len())
+	for i := range remaining {
+		remaining[i] = i
+	}
+	var subset func(indices []int) SampleList
+	switch list := list.(type) {
+	case SubsetList:
+		subset = list.Subset
+	case FeatureList:
+		subset = func(indices []int) SampleList {
+			return &featureSubset{List: list, Indices: indices}
+		}
+	}
+	for _, stage := range c.Stages {
+		if len(remaining) == 0 {
+			break
+		}
+		var outputs linalg.Vector
+		if subset != nil {
+			outputs = stage.Classify(subset(remaining))
+		} else {
+			allOutputs := stage.Classify(list)
+			outputs = make(linalg.Vector, len(remaining))
+			for i, idx := range remaining {
+				outputs[i] = allOutputs[idx]
+			}
+		}
+		var passed []int
+		for i, idx := range remaining {
+			res[idx] = outputs[i]
+			if outputs[i] >= 0 {
+				passed = append(passed, idx)
+			}
+		}
+		remaining = passed
+	}
+	return res
+}
+
+// A CascadeTrainer builds a Cascade by boosting one
+// stage at a time.
+//
+// Each stage is trained on all of the positive samples,
+// but only on the negative samples which passed every
+// earlier stage.
+// Classifiers are added to a stage until its threshold
+// can be set to meet both the stage's detection rate
+// and its false positive rate.
+//
+// A zero value for any numeric field indicates that a
+// default should be used.
+type CascadeTrainer struct {
+	// NewPool creates a Pool for training a stage on the
+	// given samples and desired classifications.
+	NewPool func(list SampleList, desired linalg.Vector) Pool
+
+	// Loss is the loss function for boosting.
+	// If nil, ExpLoss is used.
+	Loss LossFunc
+
+	// DetectionRate is the minimum fraction of positive
+	// samples each stage must accept.
+	DetectionRate float64
+
+	// FalsePositiveRate is the maximum fraction of the
+	// remaining negative samples each stage may accept.
+	FalsePositiveRate float64
+
+	// TargetFalsePositiveRate is the overall false
+	// positive rate at which to stop adding stages.
+	// If it is 0, stages are added until MaxStages is
+	// reached or no negatives remain.
+	TargetFalsePositiveRate float64
+
+	// MaxStages is the maximum number of stages.
+	MaxStages int
+
+	// MaxStageSize is the maximum number of classifiers
+	// in a stage.
+	// A stage which reaches this size is used even if it
+	// does not meet the false positive rate.
+	MaxStageSize int
+}
+
+// Train builds a Cascade for the samples.
+// The desired vector has one entry per sample, with a
+// positive value for positive samples and a negative
+// value for negative ones.
+//
+// Stage thresholds are tuned on the training samples,
+// so the detection rate on new samples may be somewhat
+// lower than DetectionRate.
+func (c *CascadeTrainer) Train(list SubsetList, desired linalg.Vector) *Cascade {
+	var positives, negatives []int
+	for i, x := range desired {
+		if x > 0 {
+			positives = append(positives, i)
+		} else {
+			negatives = append(negatives, i)
+		}
+	}
+	if len(positives) == 0 {
+		panic("cannot train cascade without positive samples")
+	}
+
+	res := &Cascade{}
+	totalNegatives := len(negatives)
+	for len(res.Stages) < c.maxStages() && len(negatives) > 0 {
+		fpRate := float64(len(negatives)) / float64(totalNegatives)
+		if fpRate <= c.TargetFalsePositiveRate {
+			break
+		}
+		indices := append(append([]int{}, positives...), negatives...)
+		stage, outputs := c.trainStage(list.Subset(indices), len(positives))
+
+		var passed []int
+		for i, idx := range negatives {
+			if outputs[len(positives)+i] >= stage.Threshold {
+				passed = append(passed, idx)
+			}
+		}
+		if len(passed) == len(negatives) {
+			// The stage would only reject positives, and
+			// later stages would see the same samples.
+			break
+		}
+		res.Stages = append(res.Stages, stage)
+		negatives = passed
+	}
+	return res
+}
+
+// trainStage trains a stage on a list whose first
+// numPositive samples are positive and whose remaining
+// samples are negative.
+// It returns the stage and its sum's outputs.
+func (c *CascadeTrainer) trainStage(list SampleList, numPositive int) (*CascadeStage,
+	linalg.Vector) {
+	desired := make(linalg.Vector, list.Len())
+	for i := range desired {
+		desired[i] = stumpOutput(i < numPositive)
+	}
+	loss := c.Loss
+	if loss == nil {
+		loss = ExpLoss{}
+	}
+	grad := &Gradient{
+		Loss:    loss,
+		Desired: desired,
+		List:    list,
+		Pool:    c.NewPool(list, desired),
+	}
+
+	stage := &CascadeStage{}
+	for len(grad.Sum.Classifiers) < c.maxStageSize() {
+		grad.Step()
+		stage.Threshold = c.stageThreshold(grad.OutCache[:numPositive])
+		var falsePositives int
+		for _, x := range grad.OutCache[numPositive:] {
+			if x >= stage.Threshold {
+				falsePositives++
+			}
+		}
+		numNegative := list.Len() - numPositive
+		if float64(falsePositives) <= c.falsePositiveRate()*float64(numNegative) {
+			break
+		}
+	}
+	stage.Sum = grad.Sum
+	return stage, grad.OutCache
+}
+
+// stageThreshold finds the largest threshold which
+// accepts enough of the positive outputs.
+func (c *CascadeTrainer) stageThreshold(positiveOuts linalg.Vector) float64 {
+	sorted := append([]float64{}, positiveOuts...)
+	sort.Float64s(sorted)
+	maxRejected := int(math.Floor((1-c.detectionRate())*float64(len(sorted)) + 1e-8))
+	if maxRejected >= len(sorted) {
+		maxRejected = len(sorted) - 1
+	}
+	return sorted[maxRejected]
+}
+
+func (c *CascadeTrainer) detectionRate() float64 {
+	if c.DetectionRate == 0 {
+		return defaultCascadeDetectionRate
+	}
+	return c.DetectionRate
+}
+
+func (c *CascadeTrainer) falsePositiveRate() float64 {
+	if c.FalsePositiveRate == 0 {
+		return defaultCascadeFalsePositiveRate
+	}
+	return c.FalsePositiveRate
+}
+
+func (c *CascadeTrainer) maxStages() int {
+	if c.MaxStages == 0 {
+		return defaultCascadeMaxStages
+	}
+	return c.MaxStages
+}
+
+func (c *CascadeTrainer) maxStageSize() int {
+	if c.MaxStageSize == 0 {
+		return defaultCascadeMaxStageSize
+	}
+	return c.MaxStageSize
+}
@@ -0,0 +1,133 @@
+package boosting
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/unixpickle/num-analysis/linalg"
+)
+
+func TestCascadeTrainer(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	makeTable := func(count int) (*Table, linalg.Vector) {
+		table := &Table{}
+		var desired linalg.Vector
+		for i := 0; i < count; i++ {
+			x, y := r.Float64()*2-1, r.Float64()*2-1
+			table.NumericRows = append(table.NumericRows, []float64{x, y})
+			desired = append(desired, stumpOutput(x*x+y*y < 0.25))
+		}
+		return table, desired
+	}
+	table, desired := makeTable(1000)
+
+	trainer := &CascadeTrainer{
+		NewPool: func(l SampleList, d linalg.Vector) Pool {
+			return NewFeaturePool(l.(FeatureList), d, nil, r)
+		},
+		DetectionRate:           0.99,
+		FalsePositiveRate:       0.5,
+		TargetFalsePositiveRate: 0.05,
+		MaxStageSize:            20,
+	}
+	cascade := trainer.Train(table, desired)
+	if len(cascade.Stages) < 2 {
+		t.Fatalf("expected multiple stages but got %d", len(cascade.Stages))
+	}
+
+	outputs := cascade.Classify(table)
+	var positives, truePositives, negatives, falsePositives int
+	for i, x := range outputs {
+		if desired[i] > 0 {
+			positives++
+			if x >= 0 {
+				truePositives++
+			}
+		} else {
+			negatives++
+			if x >= 0 {
+				falsePositives++
+			}
+		}
+	}
+	if float64(truePositives) < 0.9*float64(positives) {
+		t.Errorf("detected %d/%d positives", truePositives, positives)
+	}
+	if float64(falsePositives) > 0.05*float64(negatives) {
+		t.Errorf("accepted %d/%d negatives", falsePositives, negatives)
+	}
+
+	// Evaluation on a FeatureList which is not a
+	// SubsetList should give the same results.
+	noSubset := cascade.Classify(struct{ FeatureList }{table})
+	for i, x := range outputs {
+		if noSubset[i] != x {
+			t.Errorf("sample %d: got %f with subsets and %f without", i, x, noSubset[i])
+			break
+		}
+	}
+}
+
+func TestCascadeEarlyExit(t *testing.T) {
+	table := &Table{NumericRows: [][]float64{{-1}, {0.5}, {2}}}
+	cascade := &Cascade{
+		Stages: []*CascadeStage{
+			{
+				Sum: SumClassifier{
+					Classifiers: []Classifier{&NumericStump{Feature: 0, Threshold: 0}},
+					Weights:     []float64{1},
+				},
+				Threshold: 0,
+			},
+			{
+				Sum: SumClassifier{
+					Classifiers: []Classifier{&NumericStump{Feature: 0, Threshold: 1}},
+					Weights:     []float64{2},
+				},
+				Threshold: 1,
+			},
+		},
+	}
+	counter := &subsetCounter{Table: table}
+	actual := cascade.Classify(counter)
+	expected := []float64{-1, -3, 1}
+	for i, x := range expected {
+		if actual[i] != x {
+			t.Errorf("sample %d: expected %f but got %f", i, x, actual[i])
+		}
+	}
+	if counter.Evaluated != 5 {
+		t.Errorf("expected 5 stage evaluations but got %d", counter.Evaluated)
+	}
+
+	features := &numericCounter{FeatureList: table}
+	actual = cascade.Classify(features)
+	for i, x := range expected {
+		if actual[i] != x {
+			t.Errorf("FeatureList sample %d: expected %f but got %f", i, x, actual[i])
+		}
+	}
+	if features.Evaluated != 5 {
+		t.Errorf("expected 5 FeatureList evaluations but got %d", features.Evaluated)
+	}
+}
+
+type subsetCounter struct {
+	*Table
+	Evaluated int
+}
+
+func (s *subsetCounter) Subset(indices []int) SampleList {
+	s.Evaluated += len(indices)
+	return s.Table.Subset(indices)
+}
+
+type numericCounter struct {
+	FeatureList
+	Evaluated int
+}
+
+func (n *numericCounter) Numeric(sample, feature int) float64 {
+	n.Evaluated++
+	return n.FeatureList.Numeric(sample, feature)
+}
@@ -0,0 +1,282 @@
+package boosting
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/unixpickle/num-analysis/linalg"
+)
+
+const (
+	defaultMaxPartition     = 16
+	defaultMinCategoryCount = 5
+	defaultPriorWeight      = 1
+)
+
+// A FeaturePool is a Pool of decision stumps on the
+// numeric and categorical features of a FeatureList.
+//
+// Categorical features with few distinct values are
+// split by partitioning their categories, while
+// high-cardinality features (e.g. user IDs) are split
+// on ordered target statistics.
+//
+// Like a StaticPool, a FeaturePool is tied to the list
+// it was created with and ignores the list passed to
+// BestClassifier.
+type FeaturePool struct {
+	list FeatureList
+
+	numericOrders [][]int
+	partitions    []*partitionFeature
+	targets       []*targetFeature
+}
+
+type partitionFeature struct {
+	Feature    int
+	Categories []string
+	Known      []bool
+	SampleCats []int
+}
+
+type targetFeature struct {
+	Feature  int
+	Values   []float64
+	Order    []int
+	Encoding *TargetEncoding
+}
+
+// A FeaturePoolConfig controls how a FeaturePool treats
+// categorical features.
+// A zero value for any field indicates that a default
+// should be used.
+type FeaturePoolConfig struct {
+	// MaxPartition is the maximum number of distinct
+	// categories a feature may have to be split by
+	// partitioning.
+	// Features with more categories are split on ordered
+	// target statistics instead.
+	MaxPartition int
+
+	// MinCategoryCount is the number of training samples
+	// a category needs to be partitioned on its own.
+	// Rarer categories are grouped together with unseen
+	// categories.
+	MinCategoryCount int
+
+	// PriorWeight is the smoothing parameter for target
+	// statistics.
+	// See TargetEncoding for more details.
+	PriorWeight float64
+}
+
+// NewFeaturePool creates a FeaturePool for the list.
+// The desired classifications are used to compute
+// target statistics for high-cardinality features.
+//
+// The config may be nil to use the defaults.
+//
+// If r is nil, this uses the rand package's default
+// generator.
+func NewFeaturePool(l FeatureList, desired linalg.Vector, c *FeaturePoolConfig,
+	r *rand.Rand) *FeaturePool {
+	var config FeaturePoolConfig
+	if c != nil {
+		config = *c
+	}
+	if config.MaxPartition == 0 {
+		config.MaxPartition = defaultMaxPartition
+	}
+	if config.MinCategoryCount == 0 {
+		config.MinCategoryCount = defaultMinCategoryCount
+	}
+	if config.PriorWeight == 0 {
+		config.PriorWeight = defaultPriorWeight
+	}
+
+	res := &FeaturePool{list: l}
+	for feature := 0; feature < l.NumNumeric(); feature++ {
+		values := make([]float64, l.Len())
+		for i := range values {
+			values[i] = l.Numeric(i, feature)
+		}
+		res.numericOrders = append(res.numericOrders, sortedOrder(values))
+	}
+
+	for feature := 0; feature < l.NumCategorical(); feature++ {
+		part := newPartitionFeature(l, feature, config.MinCategoryCount)
+		if len(part.Categories) <= config.MaxPartition {
+			res.partitions = append(res.partitions, part)
+			continue
+		}
+		values, enc := OrderedTargetStats(l, feature, desired, config.PriorWeight, r)
+		res.targets = append(res.targets, &targetFeature{
+			Feature:  feature,
+			Values:   values,
+			Order:    sortedOrder(values),
+			Encoding: enc,
+		})
+	}
+
+	return res
+}
+
+// BestClassifier returns the stump whose output has the
+// largest absolute dot product with the weights.
+func (f *FeaturePool) BestClassifier(list SampleList, weights linalg.Vector) Classifier {
+	var best Classifier
+	bestScore := math.Inf(-1)
+
+	for feature, order := range f.numericOrders {
+		score, threshold, ok := bestThreshold(order, func(i int) float64 {
+			return f.list.Numeric(i, feature)
+		}, weights)
+		if ok && score > bestScore {
+			bestScore = score
+			best = &NumericStump{Feature: feature, Threshold: threshold}
+		}
+	}
+
+	for _, part := range f.partitions {
+		score, stump := part.BestStump(weights)
+		if score > bestScore {
+			bestScore = score
+			best = stump
+		}
+	}
+
+	for _, target := range f.targets {
+		score, threshold, ok := bestThreshold(target.Order, func(i int) float64 {
+			return target.Values[i]
+		}, weights)
+		if ok && score > bestScore {
+			bestScore = score
+			best = &TargetStump{
+				Feature:     target.Feature,
+				Threshold:   threshold,
+				Encoding:    target.Encoding,
+				trainList:   f.list,
+				trainValues: target.Values,
+			}
+		}
+	}
+
+	if best == nil {
+		panic("no features to split on")
+	}
+	return best
+}
+
+func newPartitionFeature(l FeatureList, feature, minCount int) *partitionFeature {
+	res := &partitionFeature{
+		Feature:    feature,
+		SampleCats: make([]int, l.Len()),
+	}
+	indices := map[string]int{}
+	var counts []int
+	for i := range res.SampleCats {
+		cat := l.Categorical(i, feature)
+		idx, ok := indices[cat]
+		if !ok {
+			idx = len(res.Categories)
+			indices[cat] = idx
+			res.Categories = append(res.Categories, cat)
+			counts = append(counts, 0)
+		}
+		counts[idx]++
+		res.SampleCats[i] = idx
+	}
+	res.Known = make([]bool, len(counts))
+	for i, count := range counts {
+		res.Known[i] = count >= minCount
+	}
+	return res
+}
+
+// BestStump finds the optimal partition of the
+// categories.
+//
+// Since stumps output 1 or -1, the dot product with the
+// weights is maximized by putting each category on the
+// side matching the sign of its total weight.
+// Rare categories are treated as a single group, which
+// also decides the output for unseen categories.
+func (p *partitionFeature) BestStump(weights linalg.Vector) (float64, *CategoryStump) {
+	catWeights := make([]float64, len(p.Categories))
+	for i, cat := range p.SampleCats {
+		catWeights[cat] += weights[i]
+	}
+	var unknownWeight, score float64
+	stump := &CategoryStump{
+		Feature:   p.Feature,
+		Partition: map[string]bool{},
+	}
+	for i, w := range catWeights {
+		if p.Known[i] {
+			stump.Partition[p.Categories[i]] = w > 0
+			score += math.Abs(w)
+		} else {
+			unknownWeight += w
+		}
+	}
+	stump.UnknownPositive = unknownWeight > 0
+	score += math.Abs(unknownWeight)
+	return score, stump
+}
+
+// bestThreshold finds the threshold for a stump which
+// maximizes the absolute dot product between the
+// stump's output and the weights.
+//
+// The order argument lists the sample indices sorted by
+// their values.
+// If all the values are equal, ok is false.
+func bestThreshold(order []int, value func(int) float64,
+	weights linalg.Vector) (score, threshold float64, ok bool) {
+	var total float64
+	for _, w := range weights {
+		total += w
+	}
+	var below float64
+	for i := 1; i < len(order); i++ {
+		below += weights[order[i-1]]
+		lastVal, val := value(order[i-1]), value(order[i])
+		if lastVal == val {
+			continue
+		}
+		s := math.Abs(total - 2*below)
+		if !ok || s > score {
+			score = s
+			threshold = (lastVal + val) / 2
+			ok = true
+		}
+	}
+	return
+}
+
+func sortedOrder(values []float64) []int {
+	order := make([]int, len(values))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Sort(&valueSorter{values: values, order: order})
+	return order
+}
+
+type valueSorter struct {
+	values []float64
+	order  []int
+}
+
+func (v *valueSorter) Len() int {
+	return len(v.order)
+}
+
+func (v *valueSorter) Less(i, j int) bool {
+	return v.values[v.order[i]] < v.values[v.order[j]]
+}
+
+func (v *valueSorter) Swap(i, j int) {
+	v.order[i], v.order[j] = v.order[j], v.order[i]
+}
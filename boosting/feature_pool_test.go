@@ -0,0 +1,135 @@
+package boosting
+
+import (
+	"math"
+	"math/rand"
+	"strconv"
+	"testing"
+
+	"github.com/unixpickle/num-analysis/linalg"
+)
+
+func TestFeaturePoolPartition(t *testing.T) {
+	colors := []string{"red", "green", "blue", "yellow", "purple"}
+	positive := map[string]bool{"red": true, "blue": true, "purple": true}
+	r := rand.New(rand.NewSource(1337))
+
+	table := &Table{}
+	var desired linalg.Vector
+	for i := 0; i < 100; i++ {
+		color := colors[r.Intn(len(colors))]
+		table.CategoricalRows = append(table.CategoricalRows, []string{color})
+		table.NumericRows = append(table.NumericRows, []float64{r.NormFloat64()})
+		desired = append(desired, stumpOutput(positive[color]))
+	}
+
+	grad := Gradient{
+		Loss:    ExpLoss{},
+		Desired: desired,
+		List:    table,
+		Pool:    NewFeaturePool(table, desired, nil, r),
+	}
+	grad.Step()
+	if _, ok := grad.Sum.Classifiers[0].(*CategoryStump); !ok {
+		t.Fatalf("unexpected classifier: %T", grad.Sum.Classifiers[0])
+	}
+
+	test := &Table{NumericRows: make([][]float64, len(colors))}
+	for i, color := range colors {
+		test.CategoricalRows = append(test.CategoricalRows, []string{color})
+		test.NumericRows[i] = []float64{0}
+	}
+	for i, x := range grad.Sum.Classify(test) {
+		if (x > 0) != positive[colors[i]] {
+			t.Errorf("wrong classification for %s", colors[i])
+		}
+	}
+}
+
+func TestFeaturePoolTargetStats(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	userLabels := make([]float64, 100)
+	for i := range userLabels {
+		userLabels[i] = stumpOutput(r.Intn(2) == 0)
+	}
+	makeTable := func(count int) (*Table, linalg.Vector) {
+		table := &Table{}
+		var desired linalg.Vector
+		for i := 0; i < count; i++ {
+			user := r.Intn(len(userLabels))
+			table.CategoricalRows = append(table.CategoricalRows,
+				[]string{strconv.Itoa(user)})
+			desired = append(desired, userLabels[user])
+		}
+		return table, desired
+	}
+
+	train, desired := makeTable(2000)
+	grad := Gradient{
+		Loss:    ExpLoss{},
+		Desired: desired,
+		List:    train,
+		Pool:    NewFeaturePool(train, desired, nil, r),
+	}
+	for i := 0; i < 5; i++ {
+		grad.Step()
+	}
+	if _, ok := grad.Sum.Classifiers[0].(*TargetStump); !ok {
+		t.Fatalf("unexpected classifier: %T", grad.Sum.Classifiers[0])
+	}
+
+	test, testDesired := makeTable(200)
+	if acc := accuracy(grad.Sum.Classify(test), testDesired); acc < 0.95 {
+		t.Errorf("test accuracy too low: %f", acc)
+	}
+}
+
+func TestOrderedTargetStatsLeakage(t *testing.T) {
+	table := &Table{}
+	var desired linalg.Vector
+	for i := 0; i < 50; i++ {
+		table.CategoricalRows = append(table.CategoricalRows, []string{strconv.Itoa(i % 7)})
+		desired = append(desired, stumpOutput(i%3 == 0))
+	}
+	values, _ := OrderedTargetStats(table, 0, desired, 1, rand.New(rand.NewSource(42)))
+	for i := range desired {
+		flipped := desired.Copy()
+		flipped[i] *= -1
+		newValues, _ := OrderedTargetStats(table, 0, flipped, 1, rand.New(rand.NewSource(42)))
+		// Only the prior may depend on the sample's target.
+		if math.Abs(newValues[i]-values[i]) > 2/float64(len(desired)) {
+			t.Fatalf("sample %d: encoding depends on its own target", i)
+		}
+	}
+}
+
+func accuracy(actual, desired linalg.Vector) float64 {
+	var correct int
+	for i, x := range actual {
+		if (x > 0) == (desired[i] > 0) {
+			correct++
+		}
+	}
+	return float64(correct) / float64(len(actual))
+}
+
+func TestTableLen(t *testing.T) {
+	table := &Table{
+		NumericRows:     [][]float64{},
+		CategoricalRows: [][]string{{"a"}, {"b"}},
+	}
+	if n := table.Len(); n != 2 {
+		t.Errorf("expected length 2 but got %d", n)
+	}
+	if n := table.Subset([]int{1}).Len(); n != 1 {
+		t.Errorf("expected subset length 1 but got %d", n)
+	}
+
+	table.NumericRows = [][]float64{{1}}
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for mismatched row counts")
+		}
+	}()
+	table.Len()
+}
@@ -0,0 +1,137 @@
+package boosting
+
+import "reflect"
+
+// A FeatureList is a SampleList whose samples have a
+// fixed number of numeric and categorical features.
+type FeatureList interface {
+	SampleList
+
+	NumNumeric() int
+	NumCategorical() int
+
+	// Numeric returns a numeric feature of a sample.
+	Numeric(sample, feature int) float64
+
+	// Categorical returns a categorical feature of a
+	// sample, such as a color name or a user ID.
+	Categorical(sample, feature int) string
+}
+
+// A Table is an in-memory FeatureList.
+type Table struct {
+	// NumericRows contains one row of numeric features
+	// per sample.
+	// It may be nil if there are no numeric features.
+	NumericRows [][]float64
+
+	// CategoricalRows contains one row of categorical
+	// features per sample.
+	// It may be nil if there are no categorical features.
+	// If both kinds of rows are present, there must be
+	// the same number of each.
+	CategoricalRows [][]string
+}
+
+// Len returns the number of samples.
+// It panics if the numeric and categorical rows do not
+// agree on the number of samples.
+func (t *Table) Len() int {
+	numLen, catLen := len(t.NumericRows), len(t.CategoricalRows)
+	if numLen != 0 && catLen != 0 && numLen != catLen {
+		panic("numeric and categorical row counts do not match")
+	}
+	if numLen != 0 {
+		return numLen
+	}
+	return catLen
+}
+
+func (t *Table) NumNumeric() int {
+	if len(t.NumericRows) == 0 {
+		return 0
+	}
+	return len(t.NumericRows[0])
+}
+
+func (t *Table) NumCategorical() int {
+	if len(t.CategoricalRows) == 0 {
+		return 0
+	}
+	return len(t.CategoricalRows[0])
+}
+
+func (t *Table) Numeric(sample, feature int) float64 {
+	return t.NumericRows[sample][feature]
+}
+
+func (t *Table) Categorical(sample, feature int) string {
+	return t.CategoricalRows[sample][feature]
+}
+
+// Subset creates a Table with some of the rows of t.
+// The new Table shares rows with t.
+func (t *Table) Subset(indices []int) SampleList {
+	res := &Table{}
+	if len(t.NumericRows) != 0 {
+		res.NumericRows = make([][]float64, len(indices))
+		for i, idx := range indices {
+			res.NumericRows[i] = t.NumericRows[idx]
+		}
+	}
+	if len(t.CategoricalRows) != 0 {
+		res.CategoricalRows = make([][]string, len(indices))
+		for i, idx := range indices {
+			res.CategoricalRows[i] = t.CategoricalRows[idx]
+		}
+	}
+	return res
+}
+
+// A featureSubset is a FeatureList with some of the
+// samples of another FeatureList.
+type featureSubset struct {
+	List    FeatureList
+	Indices []int
+}
+
+func (f *featureSubset) Len() int {
+	return len(f.Indices)
+}
+
+func (f *featureSubset) NumNumeric() int {
+	return f.List.NumNumeric()
+}
+
+func (f *featureSubset) NumCategorical() int {
+	return f.List.NumCategorical()
+}
+
+func (f *featureSubset) Numeric(sample, feature int) float64 {
+	return f.List.Numeric(f.Indices[sample], feature)
+}
+
+func (f *featureSubset) Categorical(sample, feature int) string {
+	return f.List.Categorical(f.Indices[sample], feature)
+}
+
+// sameList checks if two sample lists refer to the same
+// underlying data.
+// Unlike ==, it never panics for list types which are
+// not comparable, such as slices.
+func sameList(l1, l2 SampleList) bool {
+	if l1 == nil || l2 == nil {
+		return false
+	}
+	v1, v2 := reflect.ValueOf(l1), reflect.ValueOf(l2)
+	if v1.Type() != v2.Type() {
+		return false
+	}
+	switch v1.Kind() {
+	case reflect.Ptr, reflect.Map:
+		return v1.Pointer() == v2.Pointer()
+	case reflect.Slice:
+		return v1.Pointer() == v2.Pointer() && v1.Len() == v2.Len()
+	}
+	return v1.Type().Comparable() && l1 == l2
+}
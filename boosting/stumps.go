@@ -0,0 +1,94 @@
+package boosting
+
+import "github.com/unixpickle/num-analysis/linalg"
+
+// A NumericStump classifies samples in a FeatureList by
+// comparing a numeric feature to a threshold.
+type NumericStump struct {
+	Feature   int
+	Threshold float64
+}
+
+// Classify returns 1 for samples whose feature is at
+// least the threshold and -1 for the rest.
+func (n *NumericStump) Classify(s SampleList) linalg.Vector {
+	l := s.(FeatureList)
+	res := make(linalg.Vector, l.Len())
+	for i := range res {
+		res[i] = stumpOutput(l.Numeric(i, n.Feature) >= n.Threshold)
+	}
+	return res
+}
+
+// A CategoryStump classifies samples in a FeatureList
+// by partitioning the values of a categorical feature.
+type CategoryStump struct {
+	Feature int
+
+	// Partition maps each category which was common
+	// enough in the training data to whether or not it
+	// is classified as positive.
+	Partition map[string]bool
+
+	// UnknownPositive indicates whether categories which
+	// are missing from Partition are classified as
+	// positive.
+	UnknownPositive bool
+}
+
+// Classify returns 1 for samples in the positive
+// partition and -1 for the rest.
+func (c *CategoryStump) Classify(s SampleList) linalg.Vector {
+	l := s.(FeatureList)
+	res := make(linalg.Vector, l.Len())
+	for i := range res {
+		if positive, ok := c.Partition[l.Categorical(i, c.Feature)]; ok {
+			res[i] = stumpOutput(positive)
+		} else {
+			res[i] = stumpOutput(c.UnknownPositive)
+		}
+	}
+	return res
+}
+
+// A TargetStump classifies samples in a FeatureList by
+// comparing the target statistic of a categorical
+// feature to a threshold.
+//
+// When it classifies the list it was trained on, it
+// uses ordered target statistics, so that a sample's
+// own target never leaks into its encoding.
+// For any other list, it uses Encoding directly.
+type TargetStump struct {
+	Feature   int
+	Threshold float64
+	Encoding  *TargetEncoding
+
+	trainList   SampleList
+	trainValues []float64
+}
+
+// Classify returns 1 for samples whose target statistic
+// is at least the threshold and -1 for the rest.
+func (t *TargetStump) Classify(s SampleList) linalg.Vector {
+	l := s.(FeatureList)
+	res := make(linalg.Vector, l.Len())
+	training := sameList(s, t.trainList)
+	for i := range res {
+		var value float64
+		if training {
+			value = t.trainValues[i]
+		} else {
+			value = t.Encoding.Encode(l.Categorical(i, t.Feature))
+		}
+		res[i] = stumpOutput(value >= t.Threshold)
+	}
+	return res
+}
+
+func stumpOutput(positive bool) float64 {
+	if positive {
+		return 1
+	}
+	return -1
+}
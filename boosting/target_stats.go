@@ -0,0 +1,86 @@
+package boosting
+
+import (
+	"math/rand"
+
+	"github.com/unixpickle/num-analysis/linalg"
+)
+
+// A TargetStat stores the sum of the desired outputs for
+// the samples with a given category.
+type TargetStat struct {
+	Sum   float64
+	Count float64
+}
+
+// A TargetEncoding maps categories to smoothed averages
+// of the desired output, making it possible to split
+// high-cardinality features like a numeric feature.
+type TargetEncoding struct {
+	Stats map[string]TargetStat
+
+	// Prior is the average desired output over all
+	// samples, which is used for rare or unseen
+	// categories.
+	Prior float64
+
+	// PriorWeight is the number of virtual samples with
+	// the prior's output added to every category.
+	PriorWeight float64
+}
+
+// Encode returns the smoothed target statistic for a
+// category.
+func (t *TargetEncoding) Encode(category string) float64 {
+	stat := t.Stats[category]
+	return (stat.Sum + t.PriorWeight*t.Prior) / (stat.Count + t.PriorWeight)
+}
+
+// OrderedTargetStats computes target statistics for a
+// categorical feature, as in CatBoost.
+//
+// The samples are visited in a random order, and each
+// sample is encoded using only the samples before it.
+// This prevents the encoding from leaking a sample's
+// own target, which would make the statistic look far
+// more predictive during training than it really is.
+//
+// It returns the ordered encoding of each sample along
+// with an encoding of all the samples which can be used
+// on new data.
+//
+// If r is nil, this uses the rand package's default
+// generator.
+func OrderedTargetStats(l FeatureList, feature int, desired linalg.Vector,
+	priorWeight float64, r *rand.Rand) ([]float64, *TargetEncoding) {
+	var perm []int
+	if r != nil {
+		perm = r.Perm(l.Len())
+	} else {
+		perm = rand.Perm(l.Len())
+	}
+
+	var prior float64
+	for _, x := range desired {
+		prior += x
+	}
+	if len(desired) > 0 {
+		prior /= float64(len(desired))
+	}
+
+	enc := &TargetEncoding{
+		Stats:       map[string]TargetStat{},
+		Prior:       prior,
+		PriorWeight: priorWeight,
+	}
+	values := make([]float64, l.Len())
+	for _, i := range perm {
+		cat := l.Categorical(i, feature)
+		values[i] = enc.Encode(cat)
+		stat := enc.Stats[cat]
+		stat.Sum += desired[i]
+		stat.Count++
+		enc.Stats[cat] = stat
+	}
+	return values, enc
+}
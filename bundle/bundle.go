@@ -0,0 +1,347 @@
+// Package bundle implements self-describing model
+// bundles, which store a serializable model alongside
+// metadata about its inputs, outputs, and training.
+//
+// The metadata is verified when a bundle is created or
+// deserialized, and inputs are verified against it
+// whenever the bundled model is used for prediction.
+package bundle
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/unixpickle/autofunc"
+	"github.com/unixpickle/num-analysis/linalg"
+	"github.com/unixpickle/serializer"
+	"github.com/unixpickle/weakai/idtrees"
+	"github.com/unixpickle/weakai/neuralnet"
+)
+
+// formatVersion is the version of the serialized
+// bundle format.
+const formatVersion = 1
+
+func init() {
+	var b Bundle
+	serializer.RegisterTypedDeserializer(b.SerializerType(), DeserializeBundle)
+}
+
+// A Bundle wraps a model with Metadata.
+//
+// The model may be any type which is registered with
+// the serializer package.
+// However, only models which implement autofunc.Func
+// (such as a neuralnet.Network) and idtrees models
+// (*idtrees.Tree and idtrees.Forest) can be used with
+// the Predict and Classify methods.
+//
+// An idtrees model must be trained on float64 attribute
+// values, and its classes must be the strings in the
+// metadata's Labels.
+// Its attribute keys must be the feature names if the
+// metadata has FeatureNames, or int feature indices
+// otherwise.
+// These requirements are checked by Verify.
+type Bundle struct {
+	Model    serializer.Serializer
+	Metadata Metadata
+}
+
+// NewBundle creates a Bundle after verifying that the
+// metadata is self-consistent and that it matches the
+// model.
+func NewBundle(model serializer.Serializer, meta Metadata) (*Bundle, error) {
+	res := &Bundle{Model: model, Metadata: meta}
+	if err := res.Verify(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// DeserializeBundle deserializes a Bundle and verifies
+// its metadata.
+func DeserializeBundle(d []byte) (*Bundle, error) {
+	var version serializer.Int
+	var metaData serializer.Bytes
+	var model serializer.Serializer
+	if err := serializer.DeserializeAny(d, &version, &metaData, &model); err != nil {
+		return nil, err
+	}
+	if version > formatVersion {
+		return nil, fmt.Errorf("unsupported bundle version: %d", version)
+	}
+	res := &Bundle{Model: model}
+	if err := json.Unmarshal(metaData, &res.Metadata); err != nil {
+		return nil, err
+	}
+	if err := res.Verify(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Verify checks that the metadata is self-consistent
+// and that the input size matches the model, if the
+// model's input size can be determined.
+// For idtrees models, it also checks the attributes and
+// classes used by every node against the metadata.
+func (b *Bundle) Verify() error {
+	if b.Model == nil {
+		return errors.New("bundle has no model")
+	}
+	if err := b.Metadata.Verify(); err != nil {
+		return err
+	}
+	metaSize := b.Metadata.InputSize()
+	if modelSize, ok := modelInputSize(b.Model); ok && metaSize >= 0 && modelSize != metaSize {
+		return fmt.Errorf("model input size is %d but metadata input size is %d",
+			modelSize, metaSize)
+	}
+	if roots, ok := treeRoots(b.Model); ok {
+		return b.verifyTrees(roots)
+	}
+	return nil
+}
+
+// Predict verifies and normalizes an input, applies the
+// model to it, and verifies the output.
+//
+// For idtrees models, the output contains the
+// probability of each label.
+func (b *Bundle) Predict(in linalg.Vector) (linalg.Vector, error) {
+	var out linalg.Vector
+	switch model := b.Model.(type) {
+	case autofunc.Func:
+		if err := b.prepareInput(&in); err != nil {
+			return nil, err
+		}
+		out = model.Apply(&autofunc.Variable{Vector: in}).Output()
+	case *idtrees.Tree, idtrees.Forest:
+		if err := b.prepareInput(&in); err != nil {
+			return nil, err
+		}
+		probs := model.(treeClassifier).Classify(&vectorAttrMap{
+			vec:   in,
+			names: b.Metadata.FeatureNames,
+		})
+		out = make(linalg.Vector, len(b.Metadata.Labels))
+		for i, label := range b.Metadata.Labels {
+			out[i] = probs[label]
+		}
+	default:
+		return nil, fmt.Errorf("model type %T cannot be applied", b.Model)
+	}
+	if err := b.Metadata.VerifyOutput(out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Classify is like Predict, but it returns the label
+// corresponding to the largest output.
+// The metadata must include labels.
+func (b *Bundle) Classify(in linalg.Vector) (string, error) {
+	if b.Metadata.Labels == nil {
+		return "", errors.New("bundle has no labels")
+	}
+	out, err := b.Predict(in)
+	if err != nil {
+		return "", err
+	}
+	var maxIdx int
+	for i, x := range out {
+		if x > out[maxIdx] {
+			maxIdx = i
+		}
+	}
+	return b.Metadata.Labels[maxIdx], nil
+}
+
+func (b *Bundle) prepareInput(in *linalg.Vector) error {
+	if err := b.Metadata.VerifyInput(*in); err != nil {
+		return err
+	}
+	if norm := b.Metadata.Normalization; norm != nil {
+		*in = norm.Apply(*in)
+	}
+	return nil
+}
+
+// SerializerType returns the unique ID used to serialize
+// a Bundle with the serializer package.
+func (b *Bundle) SerializerType() string {
+	return "github.com/unixpickle/weakai/bundle.Bundle"
+}
+
+// Serialize serializes the bundle.
+// This fails if the model cannot be serialized.
+func (b *Bundle) Serialize() ([]byte, error) {
+	metaData, err := json.Marshal(&b.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	return serializer.SerializeAny(
+		serializer.Int(formatVersion),
+		serializer.Bytes(metaData),
+		b.Model,
+	)
+}
+
+// modelInputSize attempts to determine the input size
+// of a model.
+// For a neuralnet.Network, this is based on the first
+// layer.
+func modelInputSize(model interface{}) (int, bool) {
+	switch model := model.(type) {
+	case neuralnet.Network:
+		if len(model) == 0 {
+			return 0, false
+		}
+		return modelInputSize(model[0])
+	case *neuralnet.DenseLayer:
+		return model.InputCount, true
+	case *neuralnet.ConvLayer:
+		return model.InputWidth * model.InputHeight * model.InputDepth, true
+	case *neuralnet.MaxPoolingLayer:
+		return model.InputWidth * model.InputHeight * model.InputDepth, true
+	case *neuralnet.BorderLayer:
+		return model.InputWidth * model.InputHeight * model.InputDepth, true
+	}
+	return 0, false
+}
+
+// verifyTrees checks that idtrees models can be applied
+// to inputs matching the metadata, and that their
+// classes are all labels.
+func (b *Bundle) verifyTrees(roots []*idtrees.Tree) error {
+	m := &b.Metadata
+	if m.Labels == nil {
+		return errors.New("tree model requires labels")
+	}
+	labels := map[idtrees.Class]bool{}
+	for _, label := range m.Labels {
+		labels[label] = true
+	}
+	features := map[idtrees.Attr]bool{}
+	if m.FeatureNames != nil {
+		for _, name := range m.FeatureNames {
+			features[name] = true
+		}
+	} else {
+		size := m.InputSize()
+		if size < 0 && m.Normalization != nil {
+			size = len(m.Normalization.Mean)
+		}
+		if size < 0 {
+			return errors.New("tree model requires an input shape or feature names")
+		}
+		for i := 0; i < size; i++ {
+			features[i] = true
+		}
+	}
+
+	checkAttr := func(attr idtrees.Attr) error {
+		switch attr.(type) {
+		case string, int:
+			if features[attr] {
+				return nil
+			}
+		}
+		return fmt.Errorf("tree attribute %v (%T) is not a feature", attr, attr)
+	}
+	var checkNode func(t *idtrees.Tree) error
+	checkNode = func(t *idtrees.Tree) error {
+		switch {
+		case t == nil:
+			return errors.New("tree has a missing branch")
+		case t.Classification != nil:
+			for class := range t.Classification {
+				if !labels[class] {
+					return fmt.Errorf("tree class %v (%T) is not a label", class, class)
+				}
+			}
+			return nil
+		case t.LinearSplit != nil:
+			split := t.LinearSplit
+			if len(split.Attrs) != len(split.Weights) {
+				return errors.New("linear split has mismatched attributes and weights")
+			}
+			for _, attr := range split.Attrs {
+				if err := checkAttr(attr); err != nil {
+					return err
+				}
+			}
+			if err := checkNode(split.LessEqual); err != nil {
+				return err
+			}
+			return checkNode(split.Greater)
+		case t.NumSplit != nil:
+			if _, ok := t.NumSplit.Threshold.(float64); !ok {
+				return fmt.Errorf("numeric split threshold has type %T, not float64",
+					t.NumSplit.Threshold)
+			}
+			if err := checkAttr(t.Attr); err != nil {
+				return err
+			}
+			if err := checkNode(t.NumSplit.LessEqual); err != nil {
+				return err
+			}
+			return checkNode(t.NumSplit.Greater)
+		default:
+			if err := checkAttr(t.Attr); err != nil {
+				return err
+			}
+			for _, subtree := range t.ValSplit {
+				if err := checkNode(subtree); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	for _, root := range roots {
+		if err := checkNode(root); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// treeRoots returns the trees in an idtrees model.
+func treeRoots(model interface{}) ([]*idtrees.Tree, bool) {
+	switch model := model.(type) {
+	case *idtrees.Tree:
+		return []*idtrees.Tree{model}, true
+	case idtrees.Forest:
+		return model, true
+	}
+	return nil, false
+}
+
+// A treeClassifier is an idtrees model.
+type treeClassifier interface {
+	Classify(s idtrees.AttrMap) map[idtrees.Class]float64
+}
+
+// vectorAttrMap presents a vector to an idtrees model.
+// Attributes are feature names if names is non-nil, or
+// int indices otherwise.
+type vectorAttrMap struct {
+	vec   linalg.Vector
+	names []string
+}
+
+func (v *vectorAttrMap) Attr(attr idtrees.Attr) idtrees.Val {
+	if v.names == nil {
+		return v.vec[attr.(int)]
+	}
+	name := attr.(string)
+	for i, x := range v.names {
+		if x == name {
+			return v.vec[i]
+		}
+	}
+	panic("unknown feature: " + name)
+}
@@ -0,0 +1,296 @@
+package bundle
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/unixpickle/num-analysis/linalg"
+	"github.com/unixpickle/serializer"
+	"github.com/unixpickle/weakai/idtrees"
+	"github.com/unixpickle/weakai/neuralnet"
+)
+
+func TestBundleSerialize(t *testing.T) {
+	b, err := NewBundle(testNetwork(), testMetadata())
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := serializer.SerializeWithType(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj, err := serializer.DeserializeWithType(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b1, ok := obj.(*Bundle)
+	if !ok {
+		t.Fatalf("unexpected type: %T", obj)
+	}
+	if !b1.Metadata.Created.Equal(b.Metadata.Created) {
+		t.Error("creation time mismatch")
+	}
+	b1.Metadata.Created = b.Metadata.Created
+	if !reflect.DeepEqual(b1.Metadata, b.Metadata) {
+		t.Errorf("expected metadata %v but got %v", b.Metadata, b1.Metadata)
+	}
+
+	in := linalg.Vector{1, 2, 3}
+	expected, err := b.Predict(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual, err := b1.Predict(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, x := range expected {
+		if math.Abs(x-actual[i]) > 1e-8 {
+			t.Fatalf("expected %v but got %v", expected, actual)
+		}
+	}
+}
+
+func TestBundleVerify(t *testing.T) {
+	badMeta := []func(m *Metadata){
+		func(m *Metadata) { m.InputShape = []int{4} },
+		func(m *Metadata) { m.FeatureNames = []string{"a", "b"} },
+		func(m *Metadata) { m.Normalization.Scale = linalg.Vector{1} },
+		func(m *Metadata) { m.InputShape = []int{3, 0} },
+		func(m *Metadata) {
+			m.InputShape = nil
+			m.Normalization.Mean = linalg.Vector{1, 2}
+			m.Normalization.Scale = linalg.Vector{1, 2}
+		},
+	}
+	for i, f := range badMeta {
+		meta := testMetadata()
+		f(&meta)
+		if _, err := NewBundle(testNetwork(), meta); err == nil {
+			t.Errorf("case %d: expected error", i)
+		}
+	}
+
+	b, err := NewBundle(testNetwork(), testMetadata())
+	if err != nil {
+		t.Fatal(err)
+	}
+	badInputs := []linalg.Vector{{1, 2}, {1, 2, 3, 4}, {1, math.NaN(), 3}}
+	for i, in := range badInputs {
+		if _, err := b.Predict(in); err == nil {
+			t.Errorf("input %d: expected error", i)
+		}
+	}
+
+	// Without a shape or feature names, inputs must still
+	// match the normalization.
+	b.Metadata.InputShape = nil
+	b.Metadata.FeatureNames = nil
+	for i, in := range badInputs[:2] {
+		if _, err := b.Predict(in); err == nil {
+			t.Errorf("unshaped input %d: expected error", i)
+		}
+	}
+	b.Metadata.InputShape = []int{3}
+
+	b.Metadata.Labels = []string{"one"}
+	if _, err := b.Predict(linalg.Vector{1, 2, 3}); err == nil {
+		t.Error("expected error for label mismatch")
+	}
+	b.Metadata.InputShape = []int{5}
+	data, err := b.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DeserializeBundle(data); err == nil {
+		t.Error("expected error deserializing inconsistent bundle")
+	}
+}
+
+func TestBundleNormalization(t *testing.T) {
+	samples := []linalg.Vector{{1, 5, 2}, {3, 5, 4}, {2, 5, 9}}
+	norm := NewNormalization(samples)
+	for i := range norm.Mean {
+		var mean, variance float64
+		for _, s := range samples {
+			mean += norm.Apply(s)[i] / 3
+		}
+		for _, s := range samples {
+			variance += math.Pow(norm.Apply(s)[i]-mean, 2) / 3
+		}
+		if math.Abs(mean) > 1e-8 {
+			t.Errorf("component %d: mean %f", i, mean)
+		}
+		if i != 1 && math.Abs(variance-1) > 1e-8 {
+			t.Errorf("component %d: variance %f", i, variance)
+		}
+	}
+
+	meta := testMetadata()
+	meta.Normalization = norm
+	b, err := NewBundle(neuralnet.Network{&neuralnet.Sigmoid{}}, meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := b.Predict(samples[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, x := range norm.Apply(samples[0]) {
+		if expected := 1 / (1 + math.Exp(-x)); math.Abs(out[i]-expected) > 1e-8 {
+			t.Errorf("expected %f but got %f", expected, out[i])
+		}
+	}
+	if label, err := b.Classify(samples[0]); err != nil {
+		t.Error(err)
+	} else if label != "b" {
+		t.Errorf("expected label b but got %s", label)
+	}
+}
+
+func testNetwork() neuralnet.Network {
+	net := neuralnet.Network{
+		&neuralnet.DenseLayer{InputCount: 3, OutputCount: 3},
+		&neuralnet.SoftmaxLayer{},
+	}
+	net.Randomize()
+	return net
+}
+
+func testMetadata() Metadata {
+	return Metadata{
+		InputShape:   []int{3},
+		FeatureNames: []string{"x", "y", "z"},
+		Labels:       []string{"a", "b", "c"},
+		Normalization: &Normalization{
+			Mean:  linalg.Vector{1, 2, 3},
+			Scale: linalg.Vector{1, 0.5, 2},
+		},
+		Metrics:    map[string]float64{"accuracy": 0.9},
+		Created:    time.Now(),
+		Provenance: map[string]string{"dataset": "test"},
+	}
+}
+
+func TestBundleForest(t *testing.T) {
+	var samples []idtrees.Sample
+	for i := 0; i < 40; i++ {
+		x, y := float64(i%8), float64(i/8)
+		class := "low"
+		if x+y > 5 {
+			class = "high"
+		}
+		samples = append(samples, &forestSample{x: x, y: y, class: class})
+	}
+	forest := idtrees.BuildForest(5, samples, []idtrees.Attr{"x", "y"}, 40, 2,
+		func(s []idtrees.Sample, a []idtrees.Attr) *idtrees.Tree {
+			return idtrees.ID3(s, a, 1)
+		})
+
+	meta := Metadata{
+		FeatureNames: []string{"x", "y"},
+		Labels:       []string{"low", "high"},
+	}
+	b, err := NewBundle(forest, meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := serializer.SerializeWithType(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj, err := serializer.DeserializeWithType(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b = obj.(*Bundle)
+
+	for _, s := range samples {
+		fs := s.(*forestSample)
+		in := linalg.Vector{fs.x, fs.y}
+		probs, err := b.Predict(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := forest.Classify(fs)
+		if probs[0] != expected["low"] || probs[1] != expected["high"] {
+			t.Fatalf("expected %v but got %v", expected, probs)
+		}
+		if label, err := b.Classify(in); err != nil {
+			t.Fatal(err)
+		} else if label != fs.class {
+			t.Errorf("input %v: expected %s but got %s", in, fs.class, label)
+		}
+	}
+	if _, err := b.Predict(linalg.Vector{1}); err == nil {
+		t.Error("expected error for bad input size")
+	}
+}
+
+func TestBundleTreeVerify(t *testing.T) {
+	split := func(attr idtrees.Attr, threshold interface{}, low, high idtrees.Class) *idtrees.Tree {
+		return &idtrees.Tree{
+			Attr: attr,
+			NumSplit: &idtrees.NumSplit{
+				Threshold: threshold,
+				LessEqual: &idtrees.Tree{Classification: map[idtrees.Class]float64{low: 1}},
+				Greater:   &idtrees.Tree{Classification: map[idtrees.Class]float64{high: 1}},
+			},
+		}
+	}
+	named := Metadata{FeatureNames: []string{"x", "y"}, Labels: []string{"a", "b"}}
+	indexed := Metadata{InputShape: []int{2}, Labels: []string{"a", "b"}}
+	unlabeled := Metadata{InputShape: []int{2}}
+	stringInts := Metadata{InputShape: []int{2}, Labels: []string{"a", "1"}}
+
+	good := split(1, 0.5, "a", "b")
+	b, err := NewBundle(good, indexed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if label, err := b.Classify(linalg.Vector{0, 1}); err != nil {
+		t.Fatal(err)
+	} else if label != "b" {
+		t.Errorf("expected label b but got %s", label)
+	}
+
+	bad := map[string]struct {
+		Model serializer.Serializer
+		Meta  Metadata
+	}{
+		"unknown name":      {split("z", 0.5, "a", "b"), named},
+		"index with names":  {split(0, 0.5, "a", "b"), named},
+		"index too large":   {split(2, 0.5, "a", "b"), indexed},
+		"negative index":    {split(-1, 0.5, "a", "b"), indexed},
+		"name without":      {split("x", 0.5, "a", "b"), indexed},
+		"unknown class":     {split(0, 0.5, "a", "c"), indexed},
+		"int class":         {split(0, 0.5, "a", 1), stringInts},
+		"no labels":         {split(0, 0.5, "a", "b"), unlabeled},
+		"int64 threshold":   {split(0, int64(1), "a", "b"), indexed},
+		"unknown size":      {split(0, 0.5, "a", "b"), Metadata{Labels: []string{"a", "b"}}},
+		"bad forest member": {idtrees.Forest{good, split("y", 0.5, "a", "b")}, indexed},
+	}
+	for name, c := range bad {
+		if _, err := NewBundle(c.Model, c.Meta); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+type forestSample struct {
+	x, y  float64
+	class string
+}
+
+func (f *forestSample) Attr(attr idtrees.Attr) idtrees.Val {
+	if attr == "x" {
+		return f.x
+	}
+	return f.y
+}
+
+func (f *forestSample) Class() idtrees.Class {
+	return f.class
+}
@@ -0,0 +1,184 @@
+package bundle
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/unixpickle/num-analysis/linalg"
+)
+
+// Metadata describes what a model expects and where it
+// came from.
+//
+// Every field is optional, but the fields which are
+// set must be consistent with each other.
+type Metadata struct {
+	// InputShape is the shape of the model's input.
+	// For example, a tensor input for a ConvLayer might
+	// have the shape {width, height, depth}.
+	// If InputShape is nil, the input size is not
+	// checked.
+	InputShape []int
+
+	// FeatureNames contains one name per input
+	// component.
+	FeatureNames []string
+
+	// Labels contains one name per output component,
+	// such as the class names of a classifier.
+	Labels []string
+
+	// Normalization, if non-nil, is applied to inputs
+	// before they are fed to the model.
+	Normalization *Normalization
+
+	// Metrics stores evaluation results, such as the
+	// accuracy on a validation set.
+	Metrics map[string]float64
+
+	// Created is the time at which the model was
+	// created.
+	Created time.Time
+
+	// Provenance stores free-form information about how
+	// the model was trained, such as the dataset or the
+	// hyper-parameters.
+	Provenance map[string]string
+}
+
+// InputSize returns the number of input components
+// specified by InputShape, or -1 if InputShape is nil.
+func (m *Metadata) InputSize() int {
+	if m.InputShape == nil {
+		return -1
+	}
+	size := 1
+	for _, x := range m.InputShape {
+		size *= x
+	}
+	return size
+}
+
+// Verify checks that the metadata is self-consistent.
+func (m *Metadata) Verify() error {
+	for _, x := range m.InputShape {
+		if x <= 0 {
+			return fmt.Errorf("invalid input shape: %v", m.InputShape)
+		}
+	}
+	inSize := m.InputSize()
+	if m.FeatureNames != nil && inSize >= 0 && len(m.FeatureNames) != inSize {
+		return fmt.Errorf("have %d feature names but input size is %d",
+			len(m.FeatureNames), inSize)
+	}
+	if n := m.Normalization; n != nil {
+		if len(n.Mean) != len(n.Scale) {
+			return errors.New("normalization mean and scale differ in length")
+		}
+		if inSize >= 0 && len(n.Mean) != inSize {
+			return fmt.Errorf("normalization size is %d but input size is %d",
+				len(n.Mean), inSize)
+		}
+		if m.FeatureNames != nil && len(n.Mean) != len(m.FeatureNames) {
+			return fmt.Errorf("normalization size is %d but have %d feature names",
+				len(n.Mean), len(m.FeatureNames))
+		}
+	}
+	return nil
+}
+
+// VerifyInput checks that an input vector matches the
+// schema described by the metadata.
+func (m *Metadata) VerifyInput(in linalg.Vector) error {
+	if size := m.InputSize(); size >= 0 && len(in) != size {
+		return fmt.Errorf("input size is %d but expected %d", len(in), size)
+	}
+	if m.FeatureNames != nil && len(in) != len(m.FeatureNames) {
+		return fmt.Errorf("input size is %d but expected %d", len(in),
+			len(m.FeatureNames))
+	}
+	if n := m.Normalization; n != nil && len(in) != len(n.Mean) {
+		return fmt.Errorf("input size is %d but normalization size is %d", len(in),
+			len(n.Mean))
+	}
+	for i, x := range in {
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			return fmt.Errorf("input component %s is %f", m.featureName(i), x)
+		}
+	}
+	return nil
+}
+
+// VerifyOutput checks that an output vector matches the
+// label vocabulary, if there is one.
+func (m *Metadata) VerifyOutput(out linalg.Vector) error {
+	if m.Labels != nil && len(out) != len(m.Labels) {
+		return fmt.Errorf("output size is %d but there are %d labels", len(out),
+			len(m.Labels))
+	}
+	return nil
+}
+
+func (m *Metadata) featureName(i int) string {
+	if i < len(m.FeatureNames) {
+		return fmt.Sprintf("%d (%s)", i, m.FeatureNames[i])
+	}
+	return fmt.Sprintf("%d", i)
+}
+
+// Normalization stores per-component normalization
+// constants.
+// A normalized input component is computed as
+// (x-Mean)*Scale.
+type Normalization struct {
+	Mean  linalg.Vector
+	Scale linalg.Vector
+}
+
+// NewNormalization computes normalization constants
+// which give each component of the samples a mean of 0
+// and a variance of 1.
+// Components with no variance are only shifted.
+func NewNormalization(samples []linalg.Vector) *Normalization {
+	if len(samples) == 0 {
+		panic("cannot normalize empty sample set")
+	}
+	size := len(samples[0])
+	res := &Normalization{
+		Mean:  make(linalg.Vector, size),
+		Scale: make(linalg.Vector, size),
+	}
+	for _, s := range samples {
+		res.Mean.Add(s)
+	}
+	res.Mean.Scale(1 / float64(len(samples)))
+	for _, s := range samples {
+		for i, x := range s {
+			res.Scale[i] += math.Pow(x-res.Mean[i], 2)
+		}
+	}
+	for i, v := range res.Scale {
+		stddev := math.Sqrt(v / float64(len(samples)))
+		if stddev == 0 {
+			res.Scale[i] = 1
+		} else {
+			res.Scale[i] = 1 / stddev
+		}
+	}
+	return res
+}
+
+// Apply returns a normalized copy of the input.
+// The input must be the same size as n.Mean.
+func (n *Normalization) Apply(in linalg.Vector) linalg.Vector {
+	if len(in) != len(n.Mean) {
+		panic("input size does not match normalization size")
+	}
+	res := make(linalg.Vector, len(in))
+	for i, x := range in {
+		res[i] = (x - n.Mean[i]) * n.Scale[i]
+	}
+	return res
+}
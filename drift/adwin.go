@@ -0,0 +1,188 @@
+package drift
+
+import "math"
+
+const (
+	defaultADWINDelta      = 0.002
+	defaultADWINMaxBuckets = 5
+
+	// adwinMinSubwindow is the minimum size of each side
+	// of a cut.
+	adwinMinSubwindow = 5
+)
+
+// ADWIN implements the ADaptive WINdowing algorithm
+// (ADWIN2) from Bifet and Gavalda (2007).
+//
+// ADWIN keeps a window of recent values, compressed
+// into buckets of exponentially increasing sizes.
+// Whenever two sub-windows have significantly different
+// means, the older sub-window is dropped and a drift is
+// signaled.
+// Thus, the window always reflects the current
+// distribution of the stream.
+//
+// The values passed to Add should be between 0 and 1.
+// A zero value for any field indicates that a default
+// should be used.
+type ADWIN struct {
+	// Delta is the confidence parameter.
+	// Smaller values result in fewer false alarms but
+	// slower detection.
+	Delta float64
+
+	// MaxBuckets is the number of buckets of each size
+	// to keep before merging buckets.
+	// Larger values make the window more precise at the
+	// expense of memory and time.
+	MaxBuckets int
+
+	// buckets is ordered from oldest to newest, and the
+	// sizes of the buckets never increase.
+	buckets  []adwinBucket
+	width    int
+	total    float64
+	variance float64
+}
+
+type adwinBucket struct {
+	count int
+	total float64
+
+	// variance is the sum of squared deviations from the
+	// bucket's mean.
+	variance float64
+}
+
+// Add records a value and returns the new state.
+func (a *ADWIN) Add(x float64) State {
+	a.insert(x)
+	a.compress()
+	if a.cut() {
+		return Drift
+	}
+	return Stable
+}
+
+// Width returns the number of values in the window.
+func (a *ADWIN) Width() int {
+	return a.width
+}
+
+// Mean returns the mean of the values in the window.
+func (a *ADWIN) Mean() float64 {
+	if a.width == 0 {
+		return 0
+	}
+	return a.total / float64(a.width)
+}
+
+func (a *ADWIN) insert(x float64) {
+	if a.width > 0 {
+		mean := a.Mean()
+		n := float64(a.width)
+		a.variance += n / (n + 1) * (x - mean) * (x - mean)
+	}
+	a.width++
+	a.total += x
+	a.buckets = append(a.buckets, adwinBucket{count: 1, total: x})
+}
+
+// compress merges the oldest two buckets of any size
+// which has too many buckets.
+func (a *ADWIN) compress() {
+	maxBuckets := a.MaxBuckets
+	if maxBuckets == 0 {
+		maxBuckets = defaultADWINMaxBuckets
+	}
+	for size := 1; ; size *= 2 {
+		first := -1
+		var num int
+		for i, b := range a.buckets {
+			if b.count == size {
+				if first < 0 {
+					first = i
+				}
+				num++
+			}
+		}
+		if num <= maxBuckets {
+			return
+		}
+		a.buckets[first] = mergeBuckets(a.buckets[first], a.buckets[first+1])
+		a.buckets = append(a.buckets[:first+1], a.buckets[first+2:]...)
+	}
+}
+
+// cut drops old buckets until no sub-windows have
+// significantly different means.
+// It returns true if anything was dropped.
+func (a *ADWIN) cut() bool {
+	delta := a.Delta
+	if delta == 0 {
+		delta = defaultADWINDelta
+	}
+	var res bool
+	for a.width > 2*adwinMinSubwindow && a.shouldCut(delta) {
+		oldest := a.buckets[0]
+		a.buckets = a.buckets[1:]
+		a.removeStats(oldest)
+		res = true
+	}
+	return res
+}
+
+func (a *ADWIN) shouldCut(delta float64) bool {
+	n := float64(a.width)
+	varianceW := a.variance / n
+	deltaPrime := delta / math.Log(n)
+	logTerm := math.Log(2 / deltaPrime)
+
+	var n0 int
+	var total0 float64
+	for _, b := range a.buckets[:len(a.buckets)-1] {
+		n0 += b.count
+		total0 += b.total
+		n1 := a.width - n0
+		if n0 < adwinMinSubwindow {
+			continue
+		}
+		if n1 < adwinMinSubwindow {
+			break
+		}
+		mean0 := total0 / float64(n0)
+		mean1 := (a.total - total0) / float64(n1)
+		m := 1 / (1/float64(n0) + 1/float64(n1))
+		epsilon := math.Sqrt(2/m*varianceW*logTerm) + 2/(3*m)*logTerm
+		if math.Abs(mean0-mean1) > epsilon {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *ADWIN) removeStats(b adwinBucket) {
+	n := float64(a.width)
+	nb := float64(b.count)
+	rest := n - nb
+	if rest == 0 {
+		a.width, a.total, a.variance = 0, 0, 0
+		return
+	}
+	restMean := (a.total - b.total) / rest
+	bucketMean := b.total / nb
+	a.variance -= b.variance + nb*rest/n*(bucketMean-restMean)*(bucketMean-restMean)
+	a.variance = math.Max(a.variance, 0)
+	a.width -= b.count
+	a.total -= b.total
+}
+
+func mergeBuckets(b1, b2 adwinBucket) adwinBucket {
+	n1, n2 := float64(b1.count), float64(b2.count)
+	mean1, mean2 := b1.total/n1, b2.total/n2
+	return adwinBucket{
+		count:    b1.count + b2.count,
+		total:    b1.total + b2.total,
+		variance: b1.variance + b2.variance + n1*n2/(n1+n2)*(mean1-mean2)*(mean1-mean2),
+	}
+}
@@ -0,0 +1,87 @@
+package drift
+
+import "math"
+
+const (
+	defaultDDMMinSamples   = 30
+	defaultDDMWarningLevel = 2
+	defaultDDMDriftLevel   = 3
+)
+
+// DDM implements the Drift Detection Method from Gama et
+// al. (2004).
+//
+// DDM tracks a model's error rate p and its standard
+// deviation s, remembering the point where p+s was
+// smallest.
+// It signals a warning or a drift when p+s grows by a
+// certain number of standard deviations beyond that
+// minimum.
+// After a drift, DDM starts over.
+//
+// The values passed to Add should be 1 for errors and 0
+// for correct predictions.
+// A zero value for any field indicates that a default
+// should be used.
+type DDM struct {
+	// MinSamples is the number of values to see before
+	// signaling any changes.
+	MinSamples int
+
+	// WarningLevel and DriftLevel are the number of
+	// standard deviations for a warning and a drift.
+	WarningLevel float64
+	DriftLevel   float64
+
+	count    int
+	errors   float64
+	minScore float64
+	minP     float64
+	minS     float64
+}
+
+// Add records a value and returns the new state.
+func (d *DDM) Add(x float64) State {
+	d.count++
+	d.errors += x
+	n := float64(d.count)
+	p := d.errors / n
+	s := math.Sqrt(p * (1 - p) / n)
+
+	minSamples := d.MinSamples
+	if minSamples == 0 {
+		minSamples = defaultDDMMinSamples
+	}
+	if d.count < minSamples {
+		return Stable
+	}
+	if d.count == minSamples || p+s <= d.minScore {
+		d.minScore = p + s
+		d.minP = p
+		d.minS = s
+	}
+
+	warningLevel, driftLevel := d.WarningLevel, d.DriftLevel
+	if warningLevel == 0 {
+		warningLevel = defaultDDMWarningLevel
+	}
+	if driftLevel == 0 {
+		driftLevel = defaultDDMDriftLevel
+	}
+	if p+s > d.minP+driftLevel*d.minS {
+		d.Reset()
+		return Drift
+	} else if p+s > d.minP+warningLevel*d.minS {
+		return Warning
+	}
+	return Stable
+}
+
+// Reset forgets all of the values seen so far.
+func (d *DDM) Reset() {
+	d.count = 0
+	d.errors = 0
+	d.minScore = 0
+	d.minP = 0
+	d.minS = 0
+}
@@ -0,0 +1,86 @@
+package drift
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestDetectors(t *testing.T) {
+	detectors := map[string]func() Detector{
+		// With fewer samples, DDM's estimate of the minimum
+		// error rate is too noisy for a reliable test.
+		"DDM":         func() Detector { return &DDM{MinSamples: 100} },
+		"ADWIN":       func() Detector { return &ADWIN{} },
+		"PageHinkley": func() Detector { return &PageHinkley{} },
+	}
+	for name, maker := range detectors {
+		r := rand.New(rand.NewSource(1337))
+		detector := maker()
+		for i := 0; i < 1000; i++ {
+			if detector.Add(bernoulli(r, 0.1)) == Drift {
+				t.Errorf("%s: false alarm at sample %d", name, i)
+				break
+			}
+		}
+		detected := -1
+		for i := 0; i < 1000; i++ {
+			if detector.Add(bernoulli(r, 0.5)) == Drift {
+				detected = i
+				break
+			}
+		}
+		if detected < 0 || detected > 300 {
+			t.Errorf("%s: detected drift after %d samples", name, detected)
+		}
+	}
+}
+
+func TestDDMWarning(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	d := &DDM{}
+	for i := 0; i < 1000; i++ {
+		d.Add(bernoulli(r, 0.1))
+	}
+	var sawWarning bool
+	for i := 0; i < 1000; i++ {
+		state := d.Add(bernoulli(r, 0.5))
+		if state == Warning {
+			sawWarning = true
+		} else if state == Drift {
+			break
+		}
+	}
+	if !sawWarning {
+		t.Error("expected a warning before the drift")
+	}
+}
+
+func TestADWINWindow(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	a := &ADWIN{}
+	for i := 0; i < 3000; i++ {
+		a.Add(bernoulli(r, 0.2))
+	}
+	if a.Width() != 3000 {
+		t.Errorf("expected width 3000 but got %d", a.Width())
+	}
+	if len(a.buckets) > 5*12 {
+		t.Errorf("too many buckets: %d", len(a.buckets))
+	}
+	for i := 0; i < 1000; i++ {
+		a.Add(bernoulli(r, 0.8))
+	}
+	if a.Width() > 1100 {
+		t.Errorf("window was not shrunk: width %d", a.Width())
+	}
+	if mean := a.Mean(); mean < 0.7 || mean > 0.9 {
+		t.Errorf("unexpected mean %f", mean)
+	}
+}
+
+func bernoulli(r *rand.Rand, p float64) float64 {
+	if r.Float64() < p {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,68 @@
+// Package drift implements monitors which detect when
+// the data seen by a deployed model drifts away from
+// the data it was trained on, or when the model's error
+// rate starts to rise.
+//
+// A Monitor compares a stream of recent vectors (model
+// inputs or model outputs) to a reference sample using
+// per-feature statistics and a multivariate kernel
+// test.
+// A Detector watches a stream of per-sample errors and
+// signals when the error rate changes.
+package drift
+
+import (
+	"fmt"
+
+	"github.com/unixpickle/num-analysis/linalg"
+	"github.com/unixpickle/sgd"
+	"github.com/unixpickle/weakai/neuralnet"
+)
+
+// A State indicates whether or not a Detector has found
+// a change in its input stream.
+type State int
+
+const (
+	// Stable indicates that no change has been found.
+	Stable State = iota
+
+	// Warning indicates that a change may be happening.
+	// Some applications start collecting new training
+	// data when a detector enters this state.
+	Warning
+
+	// Drift indicates that a change has been found.
+	Drift
+)
+
+// A Detector watches a stream of values, such as the
+// errors of a model's predictions, for changes in
+// the stream's distribution.
+type Detector interface {
+	// Add records a value and returns the detector's new
+	// state.
+	// For classifiers, the value is typically 1 for a
+	// misclassified sample and 0 for a correct one.
+	Add(x float64) State
+}
+
+// SampleInputs extracts vectors from a SampleSet so that
+// it can be used as the reference for a Monitor.
+//
+// Each sample must be a neuralnet.VectorSample, in which
+// case its input is used, or a linalg.Vector.
+func SampleInputs(s sgd.SampleSet) []linalg.Vector {
+	res := make([]linalg.Vector, s.Len())
+	for i := range res {
+		switch sample := s.GetSample(i).(type) {
+		case neuralnet.VectorSample:
+			res[i] = sample.Input
+		case linalg.Vector:
+			res[i] = sample
+		default:
+			panic(fmt.Sprintf("unsupported sample type: %T", sample))
+		}
+	}
+	return res
+}
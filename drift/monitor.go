@@ -0,0 +1,228 @@
+package drift
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+
+	"github.com/unixpickle/num-analysis/linalg"
+	"github.com/unixpickle/weakai/svm"
+)
+
+const (
+	defaultWindowSize   = 1000
+	defaultBins         = 10
+	defaultPSIThreshold = 0.2
+	defaultAlpha        = 0.01
+	defaultMMDSamples   = 200
+	defaultPermutations = 100
+)
+
+// MonitorConfig stores parameters for a Monitor.
+// A zero value for any field indicates that a default
+// should be used.
+type MonitorConfig struct {
+	// WindowSize is the number of recent vectors which
+	// are compared to the reference.
+	WindowSize int
+
+	// Bins is the number of bins used to compute the PSI
+	// of each feature.
+	Bins int
+
+	// PSIThreshold is the PSI above which a feature is
+	// considered to have drifted.
+	PSIThreshold float64
+
+	// Alpha is the significance level for the KS and MMD
+	// tests.
+	// The KS tests use a Bonferroni correction, since
+	// there is one test per feature.
+	Alpha float64
+
+	// Kernel is the kernel used for the MMD test.
+	// By default, an svm.RadialBasisKernel is used with a
+	// coefficient from MedianHeuristic on the reference.
+	Kernel svm.Kernel
+
+	// MMDSamples is the maximum number of reference and
+	// recent vectors used for the MMD test.
+	// Random subsets are used when there are more vectors
+	// than this.
+	MMDSamples int
+
+	// Permutations is the number of permutations for the
+	// MMD test.
+	Permutations int
+}
+
+// A Monitor compares a stream of vectors to reference
+// vectors, such as the inputs a model was trained on.
+// To monitor a model's outputs, use the model's outputs
+// on the reference inputs as the reference.
+//
+// It is safe to use a Monitor from multiple Goroutines.
+type Monitor struct {
+	config    MonitorConfig
+	reference []linalg.Vector
+
+	// sortedFeatures contains the sorted reference values
+	// for each feature, and edges contains the PSI bin
+	// edges for each feature.
+	sortedFeatures [][]float64
+	edges          [][]float64
+
+	lock   sync.Mutex
+	window []linalg.Vector
+	next   int
+	count  int
+}
+
+// NewMonitor creates a Monitor with the reference
+// vectors, which can be obtained from a SampleSet with
+// SampleInputs.
+//
+// The config may be nil to use the defaults.
+func NewMonitor(reference []linalg.Vector, c *MonitorConfig) *Monitor {
+	if len(reference) < 2 {
+		panic("need at least two reference vectors")
+	}
+	res := &Monitor{reference: reference}
+	if c != nil {
+		res.config = *c
+	}
+	if res.config.WindowSize == 0 {
+		res.config.WindowSize = defaultWindowSize
+	}
+	if res.config.Bins == 0 {
+		res.config.Bins = defaultBins
+	}
+	if res.config.PSIThreshold == 0 {
+		res.config.PSIThreshold = defaultPSIThreshold
+	}
+	if res.config.Alpha == 0 {
+		res.config.Alpha = defaultAlpha
+	}
+	if res.config.MMDSamples == 0 {
+		res.config.MMDSamples = defaultMMDSamples
+	}
+	if res.config.Permutations == 0 {
+		res.config.Permutations = defaultPermutations
+	}
+	if res.config.Kernel == nil {
+		subset := randomSubset(reference, res.config.MMDSamples, nil)
+		res.config.Kernel = svm.RadialBasisKernel(MedianHeuristic(subset))
+	}
+
+	for feature := range reference[0] {
+		values := make([]float64, len(reference))
+		for i, v := range reference {
+			values[i] = v[feature]
+		}
+		sort.Float64s(values)
+		res.sortedFeatures = append(res.sortedFeatures, values)
+		res.edges = append(res.edges, quantileEdges(values, res.config.Bins))
+	}
+	res.window = make([]linalg.Vector, res.config.WindowSize)
+	return res
+}
+
+// Add adds a vector to the window of recent vectors,
+// evicting the oldest vector if the window is full.
+func (m *Monitor) Add(v linalg.Vector) {
+	if len(v) != len(m.sortedFeatures) {
+		panic("vector size does not match reference")
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.window[m.next] = v
+	m.next = (m.next + 1) % len(m.window)
+	if m.count < len(m.window) {
+		m.count++
+	}
+}
+
+// FeatureReport describes the drift of one feature.
+type FeatureReport struct {
+	PSI         float64
+	KSStatistic float64
+	KSPValue    float64
+
+	// Drifted is true if the PSI exceeds the threshold or
+	// the KS test is significant.
+	Drifted bool
+}
+
+// A Report describes the drift of the recent vectors in
+// a Monitor.
+type Report struct {
+	// Count is the number of recent vectors.
+	Count int
+
+	Features []FeatureReport
+
+	MMD       float64
+	MMDPValue float64
+
+	// Drifted is true if any feature drifted or the MMD
+	// test is significant.
+	Drifted bool
+}
+
+// Report compares the recent vectors to the reference.
+// There must be at least two recent vectors.
+//
+// If r is nil, this uses the rand package's default
+// generator.
+func (m *Monitor) Report(r *rand.Rand) *Report {
+	m.lock.Lock()
+	recent := make([]linalg.Vector, m.count)
+	copy(recent, m.window[:m.count])
+	m.lock.Unlock()
+
+	if len(recent) < 2 {
+		panic("need at least two recent vectors")
+	}
+
+	res := &Report{Count: len(recent)}
+	ksAlpha := m.config.Alpha / float64(len(m.sortedFeatures))
+	for feature, refValues := range m.sortedFeatures {
+		values := make([]float64, len(recent))
+		for i, v := range recent {
+			values[i] = v[feature]
+		}
+		sort.Float64s(values)
+		report := FeatureReport{
+			PSI: psiFromEdges(m.edges[feature], refValues, values),
+		}
+		report.KSStatistic, report.KSPValue = ksSorted(refValues, values)
+		report.Drifted = report.PSI > m.config.PSIThreshold || report.KSPValue < ksAlpha
+		res.Drifted = res.Drifted || report.Drifted
+		res.Features = append(res.Features, report)
+	}
+
+	refSubset := randomSubset(m.reference, m.config.MMDSamples, r)
+	recentSubset := randomSubset(recent, m.config.MMDSamples, r)
+	res.MMD, res.MMDPValue = MMDTest(refSubset, recentSubset, m.config.Kernel,
+		m.config.Permutations, r)
+	res.Drifted = res.Drifted || res.MMDPValue < m.config.Alpha
+
+	return res
+}
+
+func randomSubset(vecs []linalg.Vector, max int, r *rand.Rand) []linalg.Vector {
+	if len(vecs) <= max {
+		return vecs
+	}
+	var perm []int
+	if r == nil {
+		perm = rand.Perm(len(vecs))
+	} else {
+		perm = r.Perm(len(vecs))
+	}
+	res := make([]linalg.Vector, max)
+	for i, j := range perm[:max] {
+		res[i] = vecs[j]
+	}
+	return res
+}
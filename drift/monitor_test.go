@@ -0,0 +1,50 @@
+package drift
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/unixpickle/num-analysis/linalg"
+	"github.com/unixpickle/weakai/neuralnet"
+)
+
+func TestMonitor(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	sample := func(shift float64) linalg.Vector {
+		return linalg.Vector{r.NormFloat64(), r.Float64() + shift, float64(r.Intn(3))}
+	}
+	var inputs, outputs []linalg.Vector
+	for i := 0; i < 1000; i++ {
+		inputs = append(inputs, sample(0))
+		outputs = append(outputs, linalg.Vector{1})
+	}
+	monitor := NewMonitor(SampleInputs(neuralnet.VectorSampleSet(inputs, outputs)),
+		&MonitorConfig{WindowSize: 300})
+
+	for i := 0; i < 500; i++ {
+		monitor.Add(sample(0))
+	}
+	report := monitor.Report(r)
+	if report.Count != 300 {
+		t.Errorf("expected count 300 but got %d", report.Count)
+	}
+	if report.Drifted {
+		t.Errorf("unexpected drift: %+v", report)
+	}
+
+	for i := 0; i < 300; i++ {
+		monitor.Add(sample(0.5))
+	}
+	report = monitor.Report(r)
+	if !report.Drifted {
+		t.Fatalf("expected drift: %+v", report)
+	}
+	for i, f := range report.Features {
+		if f.Drifted != (i == 1) {
+			t.Errorf("feature %d: unexpected drift status %v (%+v)", i, f.Drifted, f)
+		}
+	}
+	if report.MMDPValue > 0.01 {
+		t.Errorf("expected significant MMD but got p=%f", report.MMDPValue)
+	}
+}
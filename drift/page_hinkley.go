@@ -0,0 +1,74 @@
+package drift
+
+import "math"
+
+const (
+	defaultPageHinkleyDelta      = 0.005
+	defaultPageHinkleyThreshold  = 50
+	defaultPageHinkleyMinSamples = 30
+)
+
+// PageHinkley implements the Page-Hinkley test for an
+// increase in the mean of a stream, such as a model's
+// loss or error rate.
+//
+// It accumulates the deviations of the values from
+// their running mean (minus a tolerance Delta) and
+// signals a drift when the accumulated deviation rises
+// more than Threshold above its minimum.
+// PageHinkley never signals a warning.
+// After a drift, it starts over.
+//
+// A zero value for any field indicates that a default
+// should be used.
+type PageHinkley struct {
+	// Delta is the magnitude of changes which are
+	// tolerated.
+	Delta float64
+
+	// Threshold is the accumulated deviation which
+	// triggers a drift.
+	Threshold float64
+
+	// MinSamples is the number of values to see before
+	// signaling a drift.
+	MinSamples int
+
+	count  int
+	mean   float64
+	cumSum float64
+	minSum float64
+}
+
+// Add records a value and returns the new state.
+func (p *PageHinkley) Add(x float64) State {
+	delta, threshold, minSamples := p.Delta, p.Threshold, p.MinSamples
+	if delta == 0 {
+		delta = defaultPageHinkleyDelta
+	}
+	if threshold == 0 {
+		threshold = defaultPageHinkleyThreshold
+	}
+	if minSamples == 0 {
+		minSamples = defaultPageHinkleyMinSamples
+	}
+
+	p.count++
+	p.mean += (x - p.mean) / float64(p.count)
+	p.cumSum += x - p.mean - delta
+	p.minSum = math.Min(p.minSum, p.cumSum)
+
+	if p.count >= minSamples && p.cumSum-p.minSum > threshold {
+		p.Reset()
+		return Drift
+	}
+	return Stable
+}
+
+// Reset forgets all of the values seen so far.
+func (p *PageHinkley) Reset() {
+	p.count = 0
+	p.mean = 0
+	p.cumSum = 0
+	p.minSum = 0
+}
@@ -0,0 +1,247 @@
+package drift
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/unixpickle/num-analysis/linalg"
+	"github.com/unixpickle/weakai/svm"
+)
+
+// psiEpsilon is the smallest fraction of samples used
+// for a bin when computing the PSI, which prevents
+// empty bins from causing infinite results.
+const psiEpsilon = 1e-4
+
+// PSI computes the population stability index of a
+// feature, comparing the current values to the
+// reference values.
+//
+// The values are divided into bins using the quantiles
+// of the reference values.
+// As a rule of thumb, a PSI below 0.1 indicates no
+// significant change, while a PSI above 0.2 indicates a
+// significant change.
+//
+// Both samples must be non-empty.
+func PSI(reference, current []float64, bins int) float64 {
+	sorted := append([]float64{}, reference...)
+	sort.Float64s(sorted)
+	edges := quantileEdges(sorted, bins)
+	return psiFromEdges(edges, sorted, current)
+}
+
+// KSTest performs a two-sample Kolmogorov-Smirnov test.
+// It returns the largest difference between the
+// empirical CDFs of the two samples, along with an
+// asymptotic p-value for the hypothesis that both
+// samples come from the same distribution.
+//
+// Both samples must be non-empty.
+func KSTest(reference, current []float64) (statistic, pValue float64) {
+	sorted1 := append([]float64{}, reference...)
+	sorted2 := append([]float64{}, current...)
+	sort.Float64s(sorted1)
+	sort.Float64s(sorted2)
+	return ksSorted(sorted1, sorted2)
+}
+
+// MMD computes an unbiased estimate of the squared
+// maximum mean discrepancy between two samples under a
+// kernel, such as svm.RadialBasisKernel.
+// Each sample must contain at least two vectors.
+func MMD(reference, current []linalg.Vector, k svm.Kernel) float64 {
+	gram := poolGram(reference, current, k)
+	indices := make([]int, len(gram))
+	for i := range indices {
+		indices[i] = i
+	}
+	return mmdFromGram(gram, indices, len(reference))
+}
+
+// MMDTest computes the squared MMD like MMD and uses a
+// permutation test to compute a p-value for the
+// hypothesis that both samples come from the same
+// distribution.
+//
+// If r is nil, this uses the rand package's default
+// generator.
+func MMDTest(reference, current []linalg.Vector, k svm.Kernel, permutations int,
+	r *rand.Rand) (mmd, pValue float64) {
+	gram := poolGram(reference, current, k)
+	indices := make([]int, len(gram))
+	for i := range indices {
+		indices[i] = i
+	}
+	mmd = mmdFromGram(gram, indices, len(reference))
+
+	var exceeded int
+	for i := 0; i < permutations; i++ {
+		var perm []int
+		if r == nil {
+			perm = rand.Perm(len(gram))
+		} else {
+			perm = r.Perm(len(gram))
+		}
+		if mmdFromGram(gram, perm, len(reference)) >= mmd {
+			exceeded++
+		}
+	}
+	pValue = float64(exceeded+1) / float64(permutations+1)
+	return
+}
+
+// MedianHeuristic computes a coefficient for
+// svm.RadialBasisKernel from the median squared
+// distance between the samples.
+func MedianHeuristic(samples []linalg.Vector) float64 {
+	var distances []float64
+	for i, x := range samples {
+		for _, y := range samples[:i] {
+			var dist float64
+			for k, v := range x {
+				dist += (v - y[k]) * (v - y[k])
+			}
+			distances = append(distances, dist)
+		}
+	}
+	if len(distances) == 0 {
+		return 1
+	}
+	sort.Float64s(distances)
+	median := distances[len(distances)/2]
+	if median == 0 {
+		return 1
+	}
+	return 1 / median
+}
+
+// quantileEdges computes the boundaries between bins of
+// roughly equal size.
+// Duplicate boundaries are removed, so there may be
+// fewer than bins-1 edges.
+func quantileEdges(sorted []float64, bins int) []float64 {
+	if len(sorted) == 0 {
+		panic("reference sample is empty")
+	}
+	var edges []float64
+	for i := 1; i < bins; i++ {
+		edge := sorted[i*len(sorted)/bins]
+		if len(edges) == 0 || edge > edges[len(edges)-1] {
+			edges = append(edges, edge)
+		}
+	}
+	return edges
+}
+
+func psiFromEdges(edges, reference, current []float64) float64 {
+	refFracs := binFractions(edges, reference)
+	curFracs := binFractions(edges, current)
+	var res float64
+	for i, r := range refFracs {
+		c := curFracs[i]
+		res += (c - r) * math.Log(c/r)
+	}
+	return res
+}
+
+func binFractions(edges, values []float64) []float64 {
+	if len(values) == 0 {
+		panic("cannot bin an empty sample")
+	}
+	counts := make([]float64, len(edges)+1)
+	for _, x := range values {
+		counts[sort.Search(len(edges), func(i int) bool {
+			return edges[i] > x
+		})]++
+	}
+	for i, c := range counts {
+		counts[i] = math.Max(c/float64(len(values)), psiEpsilon)
+	}
+	return counts
+}
+
+func ksSorted(sorted1, sorted2 []float64) (statistic, pValue float64) {
+	if len(sorted1) == 0 || len(sorted2) == 0 {
+		panic("KS test needs non-empty samples")
+	}
+	n1, n2 := float64(len(sorted1)), float64(len(sorted2))
+	var i, j int
+	for i < len(sorted1) && j < len(sorted2) {
+		x := math.Min(sorted1[i], sorted2[j])
+		for i < len(sorted1) && sorted1[i] == x {
+			i++
+		}
+		for j < len(sorted2) && sorted2[j] == x {
+			j++
+		}
+		statistic = math.Max(statistic, math.Abs(float64(i)/n1-float64(j)/n2))
+	}
+	effective := math.Sqrt(n1 * n2 / (n1 + n2))
+	pValue = kolmogorovQ((effective + 0.12 + 0.11/effective) * statistic)
+	return
+}
+
+// kolmogorovQ computes the complementary CDF of the
+// Kolmogorov distribution.
+func kolmogorovQ(lambda float64) float64 {
+	if lambda < 1e-3 {
+		return 1
+	}
+	var sum float64
+	sign := 1.0
+	for j := 1; j <= 100; j++ {
+		term := sign * math.Exp(-2*float64(j*j)*lambda*lambda)
+		sum += term
+		if math.Abs(term) < 1e-12 {
+			break
+		}
+		sign = -sign
+	}
+	return math.Max(0, math.Min(1, 2*sum))
+}
+
+func poolGram(reference, current []linalg.Vector, k svm.Kernel) [][]float64 {
+	if len(reference) < 2 || len(current) < 2 {
+		panic("each sample needs at least two vectors")
+	}
+	pool := make([]svm.Sample, 0, len(reference)+len(current))
+	for _, v := range reference {
+		pool = append(pool, svm.Sample{V: v})
+	}
+	for _, v := range current {
+		pool = append(pool, svm.Sample{V: v})
+	}
+	gram := make([][]float64, len(pool))
+	for i := range gram {
+		gram[i] = make([]float64, len(pool))
+		for j := 0; j <= i; j++ {
+			gram[i][j] = k(pool[i], pool[j])
+			gram[j][i] = gram[i][j]
+		}
+	}
+	return gram
+}
+
+// mmdFromGram computes the unbiased squared MMD between
+// the first n1 pooled samples in the order and the rest.
+func mmdFromGram(gram [][]float64, order []int, n1 int) float64 {
+	first, second := order[:n1], order[n1:]
+	var sum1, sum2, cross float64
+	for i, a := range first {
+		for _, b := range first[:i] {
+			sum1 += gram[a][b]
+		}
+		for _, b := range second {
+			cross += gram[a][b]
+		}
+	}
+	for i, a := range second {
+		for _, b := range second[:i] {
+			sum2 += gram[a][b]
+		}
+	}
+	m, n := float64(len(first)), float64(len(second))
+	return 2*sum1/(m*(m-1)) + 2*sum2/(n*(n-1)) - 2*cross/(m*n)
+}
@@ -0,0 +1,103 @@
+package drift
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/unixpickle/num-analysis/linalg"
+	"github.com/unixpickle/weakai/svm"
+)
+
+func TestPSI(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	reference := make([]float64, 5000)
+	same := make([]float64, 5000)
+	shifted := make([]float64, 5000)
+	for i := range reference {
+		reference[i] = r.NormFloat64()
+		same[i] = r.NormFloat64()
+		shifted[i] = r.NormFloat64() + 1
+	}
+	if psi := PSI(reference, same, 10); psi > 0.02 {
+		t.Errorf("PSI of identical distributions is %f", psi)
+	}
+	if psi := PSI(reference, shifted, 10); psi < 0.5 {
+		t.Errorf("PSI of shifted distributions is %f", psi)
+	}
+	if psi := PSI([]float64{1, 1, 1, 1}, []float64{1, 1}, 10); psi != 0 {
+		t.Errorf("PSI of constant feature is %f", psi)
+	}
+}
+
+func TestEmptySamples(t *testing.T) {
+	funcs := map[string]func(){
+		"PSI reference":    func() { PSI(nil, []float64{1}, 10) },
+		"PSI current":      func() { PSI([]float64{1, 2}, nil, 10) },
+		"KSTest reference": func() { KSTest(nil, []float64{1}) },
+		"KSTest current":   func() { KSTest([]float64{1}, nil) },
+	}
+	for name, f := range funcs {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: expected panic", name)
+				}
+			}()
+			f()
+		}()
+	}
+}
+
+func TestKSTest(t *testing.T) {
+	stat, _ := KSTest([]float64{1, 2, 3, 4}, []float64{3, 4, 5, 6})
+	if math.Abs(stat-0.5) > 1e-8 {
+		t.Errorf("expected statistic 0.5 but got %f", stat)
+	}
+
+	r := rand.New(rand.NewSource(1337))
+	reference := make([]float64, 500)
+	same := make([]float64, 300)
+	shifted := make([]float64, 300)
+	for i := range reference {
+		reference[i] = r.NormFloat64()
+	}
+	for i := range same {
+		same[i] = r.NormFloat64()
+		shifted[i] = r.NormFloat64()*1.5 + 0.6
+	}
+	if _, p := KSTest(reference, same); p < 0.05 {
+		t.Errorf("identical distributions have p-value %f", p)
+	}
+	if _, p := KSTest(reference, shifted); p > 1e-3 {
+		t.Errorf("different distributions have p-value %f", p)
+	}
+}
+
+func TestMMDTest(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	makeSamples := func(count int, scale float64) []linalg.Vector {
+		res := make([]linalg.Vector, count)
+		for i := range res {
+			res[i] = linalg.Vector{r.NormFloat64(), r.NormFloat64() * scale}
+		}
+		return res
+	}
+	reference := makeSamples(100, 1)
+	kernel := svm.RadialBasisKernel(MedianHeuristic(reference))
+
+	mmd, p := MMDTest(reference, makeSamples(100, 1), kernel, 100, r)
+	if p < 0.05 {
+		t.Errorf("identical distributions have MMD %f (p=%f)", mmd, p)
+	}
+	if math.Abs(mmd-MMD(reference, makeSamples(100, 1), kernel)) > 0.05 {
+		t.Errorf("unexpectedly large MMD: %f", mmd)
+	}
+
+	// Only the variance of the second feature changes,
+	// which per-feature means would not catch.
+	mmd, p = MMDTest(reference, makeSamples(100, 3), kernel, 100, r)
+	if p > 0.02 {
+		t.Errorf("different distributions have MMD %f (p=%f)", mmd, p)
+	}
+}
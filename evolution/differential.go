@@ -0,0 +1,256 @@
+package evolution
+
+import (
+	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+const (
+	jdeAdaptProbability = 0.1
+	jdeMinF             = 0.1
+	jdeMaxF             = 1
+)
+
+// A DEStrategy determines how a DifferentialSolver
+// creates mutant vectors.
+// All strategies use binomial crossover.
+type DEStrategy int
+
+const (
+	// DERand1Bin mutates a random member using the
+	// difference of two other random members.
+	DERand1Bin DEStrategy = iota
+
+	// DEBest1Bin mutates the fittest member using the
+	// difference of two random members.
+	DEBest1Bin
+
+	// DECurrentToBest1Bin moves each member towards the
+	// fittest member and adds the difference of two
+	// random members.
+	DECurrentToBest1Bin
+)
+
+// A DEMember is a member of the population used by a
+// DifferentialSolver.
+type DEMember struct {
+	Vector  []float64
+	Fitness float64
+
+	// F and CR are the member's own mutation factor and
+	// crossover rate.
+	// They only differ between members when the solver
+	// is self-adaptive.
+	F  float64
+	CR float64
+}
+
+// A DifferentialSolver uses differential evolution to
+// maximize a function within a box.
+type DifferentialSolver struct {
+	// Fitness computes the fitness of a vector.
+	// The higher this value, the better the vector.
+	//
+	// Fitness is called concurrently from multiple
+	// Goroutines, so it must be thread-safe.
+	Fitness func(v []float64) float64
+
+	// Lower and Upper specify the bounds for each
+	// component of the vectors.
+	Lower []float64
+	Upper []float64
+
+	StepCount      int
+	PopulationSize int
+	Strategy       DEStrategy
+
+	// F is the mutation factor and CR is the crossover
+	// rate.
+	// If SelfAdaptive is set, these are only used as the
+	// initial values for every member.
+	F  float64
+	CR float64
+
+	// SelfAdaptive enables the jDE algorithm, in which
+	// each member evolves its own F and CR.
+	SelfAdaptive bool
+
+	// MaxGos specifies the maximum number of Goroutines
+	// to use for fitness evaluations.
+	// If MaxGos is 0, then GOMAXPROCS is used.
+	MaxGos int
+}
+
+// Solve runs differential evolution and returns the
+// final population, sorted from most fit to least fit.
+//
+// The start argument specifies initial vectors for the
+// population; it may be nil.
+// If it has fewer than PopulationSize vectors, the rest
+// of the population is sampled uniformly within the
+// bounds.
+func (d *DifferentialSolver) Solve(start [][]float64) []*DEMember {
+	if d.PopulationSize < 4 {
+		panic("population must have at least 4 members")
+	}
+	population := make([]*DEMember, d.PopulationSize)
+	for i := range population {
+		var vec []float64
+		if i < len(start) {
+			vec = append([]float64{}, start[i]...)
+		} else {
+			vec = d.randomVector()
+		}
+		population[i] = &DEMember{Vector: vec, F: d.F, CR: d.CR}
+	}
+	d.evaluate(population)
+
+	for i := 0; i < d.StepCount; i++ {
+		trials := make([]*DEMember, len(population))
+		best := fittestMember(population)
+		for j := range population {
+			trials[j] = d.trial(population, j, best)
+		}
+		d.evaluate(trials)
+		for j, trial := range trials {
+			if trial.Fitness >= population[j].Fitness {
+				population[j] = trial
+			}
+		}
+	}
+
+	sort.Sort(memberSorter(population))
+	return population
+}
+
+func (d *DifferentialSolver) randomVector() []float64 {
+	res := make([]float64, len(d.Lower))
+	for i, low := range d.Lower {
+		res[i] = low + rand.Float64()*(d.Upper[i]-low)
+	}
+	return res
+}
+
+// trial creates a trial vector for the given member.
+func (d *DifferentialSolver) trial(population []*DEMember, idx int, best *DEMember) *DEMember {
+	current := population[idx]
+	f, cr := current.F, current.CR
+	if d.SelfAdaptive {
+		if rand.Float64() < jdeAdaptProbability {
+			f = jdeMinF + rand.Float64()*(jdeMaxF-jdeMinF)
+		}
+		if rand.Float64() < jdeAdaptProbability {
+			cr = rand.Float64()
+		}
+	}
+
+	others := distinctIndices(len(population), idx, 3)
+	r1, r2, r3 := population[others[0]], population[others[1]], population[others[2]]
+
+	res := &DEMember{Vector: make([]float64, len(current.Vector)), F: f, CR: cr}
+	forced := rand.Intn(len(current.Vector))
+	for i, x := range current.Vector {
+		if i != forced && rand.Float64() >= cr {
+			res.Vector[i] = x
+			continue
+		}
+		var mutant float64
+		switch d.Strategy {
+		case DERand1Bin:
+			mutant = r1.Vector[i] + f*(r2.Vector[i]-r3.Vector[i])
+		case DEBest1Bin:
+			mutant = best.Vector[i] + f*(r1.Vector[i]-r2.Vector[i])
+		case DECurrentToBest1Bin:
+			mutant = x + f*(best.Vector[i]-x) + f*(r1.Vector[i]-r2.Vector[i])
+		default:
+			panic("unknown strategy")
+		}
+		res.Vector[i] = d.bound(i, mutant, x)
+	}
+	return res
+}
+
+// bound moves an out-of-bounds component halfway
+// between the parent's component and the bound.
+func (d *DifferentialSolver) bound(i int, x, parent float64) float64 {
+	if x < d.Lower[i] {
+		return (d.Lower[i] + parent) / 2
+	} else if x > d.Upper[i] {
+		return (d.Upper[i] + parent) / 2
+	}
+	return x
+}
+
+func (d *DifferentialSolver) evaluate(members []*DEMember) {
+	maxGos := d.MaxGos
+	if maxGos == 0 {
+		maxGos = runtime.GOMAXPROCS(0)
+	}
+
+	memberChan := make(chan *DEMember, len(members))
+	for _, m := range members {
+		memberChan <- m
+	}
+	close(memberChan)
+
+	var wg sync.WaitGroup
+	for i := 0; i < maxGos; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for m := range memberChan {
+				m.Fitness = d.Fitness(m.Vector)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// distinctIndices picks n distinct random indices in
+// [0, size), none of which is equal to exclude.
+func distinctIndices(size, exclude, n int) []int {
+	res := make([]int, 0, n)
+	for len(res) < n {
+		idx := rand.Intn(size)
+		if idx == exclude {
+			continue
+		}
+		var used bool
+		for _, x := range res {
+			if x == idx {
+				used = true
+				break
+			}
+		}
+		if !used {
+			res = append(res, idx)
+		}
+	}
+	return res
+}
+
+func fittestMember(population []*DEMember) *DEMember {
+	best := population[0]
+	for _, m := range population[1:] {
+		if m.Fitness > best.Fitness {
+			best = m
+		}
+	}
+	return best
+}
+
+type memberSorter []*DEMember
+
+func (m memberSorter) Len() int {
+	return len(m)
+}
+
+func (m memberSorter) Swap(i, j int) {
+	m[i], m[j] = m[j], m[i]
+}
+
+func (m memberSorter) Less(i, j int) bool {
+	return m[i].Fitness > m[j].Fitness
+}
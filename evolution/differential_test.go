@@ -0,0 +1,92 @@
+package evolution
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDifferentialSolverSphere(t *testing.T) {
+	strategies := map[string]DEStrategy{
+		"rand/1/bin":            DERand1Bin,
+		"best/1/bin":            DEBest1Bin,
+		"current-to-best/1/bin": DECurrentToBest1Bin,
+	}
+	for name, strategy := range strategies {
+		for _, adaptive := range []bool{false, true} {
+			solver := differentialTestSolver(sphereFitness, 5)
+			solver.Strategy = strategy
+			solver.SelfAdaptive = adaptive
+			res := solver.Solve(nil)
+			if len(res) != solver.PopulationSize {
+				t.Fatalf("%s: bad population size %d", name, len(res))
+			}
+			if res[0].Fitness < -1e-4 {
+				t.Errorf("%s (adaptive=%v): best fitness %f", name, adaptive, res[0].Fitness)
+			}
+			for i := 1; i < len(res); i++ {
+				if res[i].Fitness > res[i-1].Fitness {
+					t.Fatalf("%s: population is not sorted", name)
+				}
+			}
+		}
+	}
+}
+
+func TestDifferentialSolverRastrigin(t *testing.T) {
+	solver := differentialTestSolver(func(v []float64) float64 {
+		res := -10 * float64(len(v))
+		for _, x := range v {
+			res -= x*x - 10*math.Cos(2*math.Pi*x)
+		}
+		return res
+	}, 4)
+	solver.StepCount = 500
+	solver.SelfAdaptive = true
+	res := solver.Solve(nil)
+	if res[0].Fitness < -1e-3 {
+		t.Errorf("did not find global optimum: %v", res[0])
+	}
+}
+
+func TestDifferentialSolverBounds(t *testing.T) {
+	// The optimum lies outside of the box, so the best
+	// vector should end up on its boundary.
+	solver := differentialTestSolver(func(v []float64) float64 {
+		return v[0] + v[1]
+	}, 2)
+	start := [][]float64{{0.5, 0.5}}
+	res := solver.Solve(start)
+	for _, m := range res {
+		for i, x := range m.Vector {
+			if x < solver.Lower[i] || x > solver.Upper[i] {
+				t.Fatalf("vector out of bounds: %v", m.Vector)
+			}
+		}
+	}
+	if res[0].Fitness < 2*5.12-1e-3 {
+		t.Errorf("unexpected best fitness: %f", res[0].Fitness)
+	}
+}
+
+func differentialTestSolver(f func([]float64) float64, dim int) *DifferentialSolver {
+	res := &DifferentialSolver{
+		Fitness:        f,
+		StepCount:      300,
+		PopulationSize: 40,
+		F:              0.5,
+		CR:             0.9,
+	}
+	for i := 0; i < dim; i++ {
+		res.Lower = append(res.Lower, -5.12)
+		res.Upper = append(res.Upper, 5.12)
+	}
+	return res
+}
+
+func sphereFitness(v []float64) float64 {
+	var res float64
+	for _, x := range v {
+		res -= x * x
+	}
+	return res
+}
@@ -41,6 +41,9 @@ func BuildForest(n int, samples []Sample, attrs []Attr,
 
 // Classify uses f to compute the class probabilities
 // of the given sample.
+//
+// If the forest was trained on multi-label samples, the
+// result contains the probability of each label.
 func (f Forest) Classify(s AttrMap) map[Class]float64 {
 	res := map[Class]float64{}
 	for _, t := range f {
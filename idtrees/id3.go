@@ -101,7 +101,13 @@ func id3(samples []Sample, attrs []Attr, maxGos, maxDepth int, entropy float64)
 func createLeaf(samples []Sample) *Tree {
 	counts := map[Class]int{}
 	for _, s := range samples {
-		counts[s.Class()]++
+		if m, ok := s.(multiLabelSample); ok {
+			for _, label := range m.Labels() {
+				counts[label]++
+			}
+		} else {
+			counts[s.Class()]++
+		}
 	}
 	res := &Tree{Classification: map[Class]float64{}}
 	totalScaler := 1 / float64(len(samples))
@@ -264,18 +270,20 @@ func createNumericSplit(s sampleSorter, cutoffIdxs []int, cutoffs []Val) *potent
 	return best
 }
 
+// An entropyCounter computes the entropy of a set of
+// samples.
+// For multi-label samples, it computes the sum of the
+// binary entropies of every label.
 type entropyCounter struct {
 	classCounts map[Class]int
 	totalCount  int
+	multiLabel  bool
 }
 
 func newEntropyCounter(s []Sample) *entropyCounter {
-	res := &entropyCounter{
-		classCounts: map[Class]int{},
-		totalCount:  len(s),
-	}
+	res := &entropyCounter{classCounts: map[Class]int{}}
 	for _, sample := range s {
-		res.classCounts[sample.Class()]++
+		res.Add(sample)
 	}
 	return res
 }
@@ -289,18 +297,31 @@ func (e *entropyCounter) Entropy() float64 {
 		}
 		probability := float64(count) * countScaler
 		entropy -= probability * math.Log(probability)
+		if e.multiLabel && count < e.totalCount {
+			entropy -= (1 - probability) * math.Log(1-probability)
+		}
 	}
 	return entropy
 }
 
 func (e *entropyCounter) Add(s Sample) {
-	e.classCounts[s.Class()]++
-	e.totalCount++
+	e.addCount(s, 1)
 }
 
 func (e *entropyCounter) Remove(s Sample) {
-	e.classCounts[s.Class()]--
-	e.totalCount--
+	e.addCount(s, -1)
+}
+
+func (e *entropyCounter) addCount(s Sample, delta int) {
+	e.totalCount += delta
+	if m, ok := s.(multiLabelSample); ok {
+		e.multiLabel = true
+		for _, label := range m.Labels() {
+			e.classCounts[label] += delta
+		}
+	} else {
+		e.classCounts[s.Class()] += delta
+	}
 }
 
 func copySampleSlice(s []Sample) []Sample {
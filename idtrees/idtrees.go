@@ -45,6 +45,47 @@ type Sample interface {
 	Class() Class
 }
 
+// A MultiLabelSample is like a Sample, except that it
+// may belong to any number of classes at once.
+//
+// Use MultiLabel to train trees and forests on
+// MultiLabelSamples.
+type MultiLabelSample interface {
+	AttrMap
+
+	// Labels returns the classes of this sample.
+	// It should not contain duplicates.
+	Labels() []Class
+}
+
+// MultiLabel wraps MultiLabelSamples so that they can be
+// used to train Trees.
+//
+// When training on multi-label samples, the entropy of a
+// set of samples is the sum of the binary entropies of
+// the individual labels.
+// Each leaf's Classification maps labels to the fraction
+// of the leaf's samples which have them, so the
+// probabilities in a leaf need not sum to 1.
+//
+// The Class method of the wrapped samples always
+// returns nil.
+func MultiLabel(samples []MultiLabelSample) []Sample {
+	res := make([]Sample, len(samples))
+	for i, s := range samples {
+		res[i] = multiLabelSample{s}
+	}
+	return res
+}
+
+type multiLabelSample struct {
+	MultiLabelSample
+}
+
+func (m multiLabelSample) Class() Class {
+	return nil
+}
+
 type Tree struct {
 	// Classification is non-nil if this is a leaf,
 	// in which case it maps classes to their final
@@ -67,12 +108,25 @@ type Tree struct {
 
 	NumSplit *NumSplit
 	ValSplit ValSplit
+
+	// LinearSplit is non-nil if this branch splits on a
+	// linear combination of attributes, in which case
+	// Attr is unused.
+	LinearSplit *LinearSplit
 }
 
 // Classify follows the tree for the given sample and
 // returns the resulting leaf classification.
 func (t *Tree) Classify(s AttrMap) map[Class]float64 {
 	for t.Classification == nil {
+		if t.LinearSplit != nil {
+			if t.LinearSplit.Combination(s) > t.LinearSplit.Threshold {
+				t = t.LinearSplit.Greater
+			} else {
+				t = t.LinearSplit.LessEqual
+			}
+			continue
+		}
 		val := s.Attr(t.Attr)
 		if t.NumSplit != nil {
 			var greater bool
@@ -119,3 +173,45 @@ type NumSplit struct {
 // ValSplit stores the branches resulting from splitting
 // a tree by a comparable but non-numeric attribute.
 type ValSplit map[Val]*Tree
+
+// LinearSplit stores the two branches resulting from
+// splitting a tree based on a weighted sum of numeric
+// attributes.
+type LinearSplit struct {
+	// Attrs are the attributes used in the sum.
+	// The corresponding values must be int64s or
+	// float64s.
+	Attrs []Attr
+
+	// Weights contains one coefficient per attribute.
+	Weights []float64
+
+	// Threshold is the decision boundary.
+	// If a sample's weighted sum is greater than
+	// Threshold, then the Greater branch is taken.
+	// Otherwise, the LessEqual branch is.
+	Threshold float64
+
+	LessEqual *Tree
+	Greater   *Tree
+}
+
+// Combination computes the weighted sum of a sample's
+// attributes.
+func (l *LinearSplit) Combination(s AttrMap) float64 {
+	var sum float64
+	for i, attr := range l.Attrs {
+		sum += l.Weights[i] * numericValue(s.Attr(attr))
+	}
+	return sum
+}
+
+func numericValue(v Val) float64 {
+	switch v := v.(type) {
+	case float64:
+		return v
+	case int64:
+		return float64(v)
+	}
+	panic("attribute value is not numeric")
+}
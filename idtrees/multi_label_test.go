@@ -0,0 +1,104 @@
+package idtrees
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+type multiLabelTestSample struct {
+	attrs  map[Attr]Val
+	labels []Class
+}
+
+func (m *multiLabelTestSample) Attr(a Attr) Val {
+	return m.attrs[a]
+}
+
+func (m *multiLabelTestSample) Labels() []Class {
+	return m.labels
+}
+
+func TestMultiLabelID3(t *testing.T) {
+	var samples []MultiLabelSample
+	for _, x := range []int64{0, 1, 2, 3} {
+		for _, color := range []string{"red", "blue"} {
+			s := &multiLabelTestSample{attrs: map[Attr]Val{"x": x, "color": color}}
+			if x >= 2 {
+				s.labels = append(s.labels, "big")
+			}
+			if color == "red" {
+				s.labels = append(s.labels, "warm")
+			}
+			samples = append(samples, s)
+		}
+	}
+	tree := ID3(MultiLabel(samples), []Attr{"x", "color"}, 0)
+	for _, s := range samples {
+		probs := tree.Classify(s)
+		for _, label := range []Class{"big", "warm"} {
+			expected := 0.0
+			for _, l := range s.Labels() {
+				if l == label {
+					expected = 1
+				}
+			}
+			if probs[label] != expected {
+				t.Errorf("sample %v: label %v has probability %f", s, label, probs[label])
+			}
+		}
+	}
+}
+
+func TestMultiLabelLeaf(t *testing.T) {
+	samples := []MultiLabelSample{
+		&multiLabelTestSample{labels: []Class{"a", "b"}},
+		&multiLabelTestSample{labels: []Class{"a"}},
+		&multiLabelTestSample{labels: []Class{"a", "b", "c"}},
+		&multiLabelTestSample{labels: []Class{}},
+	}
+	tree := ID3(MultiLabel(samples), nil, 1)
+	expected := map[Class]float64{"a": 0.75, "b": 0.5, "c": 0.25}
+	if len(tree.Classification) != len(expected) {
+		t.Fatalf("expected %v but got %v", expected, tree.Classification)
+	}
+	for k, v := range expected {
+		if tree.Classification[k] != v {
+			t.Errorf("expected %v but got %v", expected, tree.Classification)
+			break
+		}
+	}
+
+	entropy := newEntropyCounter(MultiLabel(samples)).Entropy()
+	expectedEntropy := binaryEntropy(0.75) + binaryEntropy(0.5) + binaryEntropy(0.25)
+	if math.Abs(entropy-expectedEntropy) > 1e-8 {
+		t.Errorf("expected entropy %f but got %f", expectedEntropy, entropy)
+	}
+}
+
+func TestMultiLabelForest(t *testing.T) {
+	var samples []MultiLabelSample
+	for i := 0; i < 200; i++ {
+		x, y := rand.Float64(), rand.Float64()
+		s := &multiLabelTestSample{attrs: map[Attr]Val{"x": x, "y": y}}
+		if x > 0.5 {
+			s.labels = append(s.labels, "right")
+		}
+		if y > 0.5 {
+			s.labels = append(s.labels, "top")
+		}
+		samples = append(samples, s)
+	}
+	forest := BuildForest(20, MultiLabel(samples), []Attr{"x", "y"}, 100, 2,
+		func(s []Sample, a []Attr) *Tree {
+			return ID3(s, a, 1)
+		})
+	probs := forest.Classify(&multiLabelTestSample{attrs: map[Attr]Val{"x": 0.9, "y": 0.1}})
+	if probs["right"] < 0.9 || probs["top"] > 0.1 {
+		t.Errorf("unexpected probabilities: %v", probs)
+	}
+}
+
+func binaryEntropy(p float64) float64 {
+	return -p*math.Log(p) - (1-p)*math.Log(1-p)
+}
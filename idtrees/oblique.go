@@ -0,0 +1,248 @@
+package idtrees
+
+import (
+	"math"
+
+	"github.com/unixpickle/weakai/svm"
+)
+
+// A HyperplaneSolver finds a hyperplane which separates
+// the positive samples of a problem from the negative
+// ones.
+//
+// Both svm.SubgradientSolver and Perceptron implement
+// this interface.
+type HyperplaneSolver interface {
+	Solve(p *svm.Problem) *svm.LinearClassifier
+}
+
+// Oblique generates a Tree whose branches split on
+// linear combinations of numeric attributes.
+// All of the attributes must be int64s or float64s.
+//
+// At each branch, the solver is used to find a separating
+// hyperplane for every class (one-vs-rest), and the
+// hyperplane or axis-aligned split which minimizes the
+// entropy is used.
+// Since the split threshold is chosen by minimizing the
+// entropy, the solver's threshold is ignored.
+//
+// If maxDepth is negative, the depth of the tree is not
+// limited.
+// See LimitedID3 for more on how depth is counted.
+func Oblique(samples []Sample, attrs []Attr, solver HyperplaneSolver, maxDepth int) *Tree {
+	baseEntropy := newEntropyCounter(samples).Entropy()
+	return oblique(samples, attrs, solver, maxDepth, baseEntropy)
+}
+
+func oblique(samples []Sample, attrs []Attr, solver HyperplaneSolver, maxDepth int,
+	entropy float64) *Tree {
+	if entropy == 0 || maxDepth == 0 {
+		return createLeaf(samples)
+	}
+
+	split := bestLinearSplit(samples, attrs, solver)
+	if split == nil || split.Entropy >= entropy || split.numBranches() < 2 {
+		return createLeaf(samples)
+	}
+
+	return &Tree{
+		LinearSplit: &LinearSplit{
+			Attrs:     append([]Attr{}, attrs...),
+			Weights:   split.Weights,
+			Threshold: split.Threshold.(float64),
+			LessEqual: oblique(split.NumSplitSamples[0], attrs, solver, maxDepth-1,
+				split.NumSplitEntropies[0]),
+			Greater: oblique(split.NumSplitSamples[1], attrs, solver, maxDepth-1,
+				split.NumSplitEntropies[1]),
+		},
+	}
+}
+
+type linearPotentialSplit struct {
+	*potentialSplit
+	Weights []float64
+}
+
+func bestLinearSplit(samples []Sample, attrs []Attr, solver HyperplaneSolver) *linearPotentialSplit {
+	vecs := make([][]float64, len(samples))
+	for i, s := range samples {
+		vecs[i] = make([]float64, len(attrs))
+		for j, attr := range attrs {
+			vecs[i][j] = numericValue(s.Attr(attr))
+		}
+	}
+
+	var directions [][]float64
+	for i := range attrs {
+		axis := make([]float64, len(attrs))
+		axis[i] = 1
+		directions = append(directions, axis)
+	}
+	directions = append(directions, hyperplaneDirections(samples, vecs, solver)...)
+
+	var best *linearPotentialSplit
+	for _, dir := range directions {
+		split := projectedSplit(samples, vecs, dir)
+		if split == nil {
+			continue
+		}
+		if best == nil || split.Entropy < best.Entropy {
+			best = &linearPotentialSplit{potentialSplit: split, Weights: dir}
+		}
+	}
+	return best
+}
+
+// hyperplaneDirections uses a solver to find a normal
+// vector separating each class from the rest.
+//
+// The solver is given standardized features, which
+// makes regularized solvers insensitive to the scales
+// of the attributes.
+func hyperplaneDirections(samples []Sample, vecs [][]float64,
+	solver HyperplaneSolver) [][]float64 {
+	mean, stddev := featureStats(vecs)
+	standardized := make([]svm.Sample, len(vecs))
+	for i, vec := range vecs {
+		v := make([]float64, len(vec))
+		for j, x := range vec {
+			if stddev[j] != 0 {
+				v[j] = (x - mean[j]) / stddev[j]
+			}
+		}
+		standardized[i] = svm.Sample{V: v, UserInfo: i}
+	}
+
+	counter := newEntropyCounter(samples)
+	var res [][]float64
+	for _, class := range classOrder(samples) {
+		count := counter.classCounts[class]
+		if count == 0 || count == counter.totalCount {
+			// Multi-label samples may all share a label.
+			continue
+		}
+		problem := &svm.Problem{Kernel: svm.LinearKernel}
+		for i, s := range samples {
+			if hasClass(s, class) {
+				problem.Positives = append(problem.Positives, standardized[i])
+			} else {
+				problem.Negatives = append(problem.Negatives, standardized[i])
+			}
+		}
+		normal := solver.Solve(problem).HyperplaneNormal.V
+		dir := make([]float64, len(normal))
+		var nonZero bool
+		for j, x := range normal {
+			if stddev[j] != 0 {
+				dir[j] = x / stddev[j]
+				nonZero = nonZero || dir[j] != 0
+			}
+		}
+		if nonZero {
+			res = append(res, dir)
+		}
+		if len(counter.classCounts) == 2 && !counter.multiLabel {
+			// Both one-vs-rest problems are equivalent.
+			break
+		}
+	}
+	return res
+}
+
+// classOrder lists the classes of the samples in order
+// of first appearance, so that the directions do not
+// depend on map iteration order.
+func classOrder(samples []Sample) []Class {
+	seen := map[Class]bool{}
+	var res []Class
+	for _, s := range samples {
+		var classes []Class
+		if m, ok := s.(multiLabelSample); ok {
+			classes = m.Labels()
+		} else {
+			classes = []Class{s.Class()}
+		}
+		for _, class := range classes {
+			if !seen[class] {
+				seen[class] = true
+				res = append(res, class)
+			}
+		}
+	}
+	return res
+}
+
+// hasClass checks if a sample belongs to a class,
+// taking multi-label samples into account.
+func hasClass(s Sample, class Class) bool {
+	if m, ok := s.(multiLabelSample); ok {
+		for _, label := range m.Labels() {
+			if label == class {
+				return true
+			}
+		}
+		return false
+	}
+	return s.Class() == class
+}
+
+func featureStats(vecs [][]float64) (mean, stddev []float64) {
+	mean = make([]float64, len(vecs[0]))
+	stddev = make([]float64, len(vecs[0]))
+	scaler := 1 / float64(len(vecs))
+	for _, vec := range vecs {
+		for j, x := range vec {
+			mean[j] += x * scaler
+		}
+	}
+	for _, vec := range vecs {
+		for j, x := range vec {
+			stddev[j] += (x - mean[j]) * (x - mean[j]) * scaler
+		}
+	}
+	for j, x := range stddev {
+		stddev[j] = math.Sqrt(x)
+	}
+	return
+}
+
+// projectedSplit finds the best split of the samples'
+// projections onto a direction.
+func projectedSplit(samples []Sample, vecs [][]float64, dir []float64) *potentialSplit {
+	projected := make([]Sample, len(samples))
+	for i, s := range samples {
+		var dot float64
+		for j, x := range vecs[i] {
+			dot += x * dir[j]
+		}
+		projected[i] = projectedSample{Sample: s, Value: dot}
+	}
+	split := createFloatSplit(projected, projectionAttr{})
+	if split == nil {
+		return nil
+	}
+	for _, list := range split.NumSplitSamples {
+		for i, s := range list {
+			list[i] = s.(projectedSample).Sample
+		}
+	}
+	return split
+}
+
+type projectionAttr struct{}
+
+// A projectedSample wraps a Sample and exposes its
+// projection onto a direction as the projectionAttr
+// attribute.
+type projectedSample struct {
+	Sample
+	Value float64
+}
+
+func (p projectedSample) Attr(attr Attr) Val {
+	if _, ok := attr.(projectionAttr); ok {
+		return p.Value
+	}
+	return p.Sample.Attr(attr)
+}
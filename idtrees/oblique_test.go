@@ -0,0 +1,155 @@
+package idtrees
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/unixpickle/serializer"
+	"github.com/unixpickle/weakai/svm"
+)
+
+func TestObliqueRotated(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	train := rotatedSamples(r, 200)
+	test := rotatedSamples(r, 200)
+	attrs := []Attr{"x", "y"}
+
+	axisAligned := LimitedID3(train, attrs, 0, 1)
+	if acc := treeAccuracy(axisAligned, test); acc > 0.9 {
+		t.Fatalf("axis-aligned stump should struggle, but got accuracy %f", acc)
+	}
+
+	solvers := map[string]HyperplaneSolver{
+		"perceptron": &Perceptron{Epochs: 20, Rand: r},
+		"svm": &svm.SubgradientSolver{
+			Tradeoff: 1e-3,
+			Steps:    300,
+			StepSize: 1e-3,
+		},
+	}
+	for name, solver := range solvers {
+		tree := Oblique(train, attrs, solver, 1)
+		if tree.LinearSplit == nil {
+			t.Errorf("%s: expected a linear split", name)
+			continue
+		}
+		if acc := treeAccuracy(tree, test); acc < 0.95 {
+			t.Errorf("%s: accuracy %f is too low", name, acc)
+		}
+	}
+}
+
+func TestObliqueSeparable(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	samples := rotatedSamples(r, 100)
+	tree := Oblique(samples, []Attr{"x", "y"}, &Perceptron{Epochs: 10, Rand: r}, -1)
+	if acc := treeAccuracy(tree, samples); acc != 1 {
+		t.Errorf("expected perfect training accuracy but got %f", acc)
+	}
+}
+
+func TestPerceptronSeeded(t *testing.T) {
+	samples := rotatedSamples(rand.New(rand.NewSource(1337)), 50)
+	var trees []*Tree
+	for i := 0; i < 2; i++ {
+		solver := &Perceptron{Epochs: 1, Rand: rand.New(rand.NewSource(42))}
+		trees = append(trees, Oblique(samples, []Attr{"x", "y"}, solver, 1))
+	}
+	if !treesEqual(trees[0], trees[1]) || !treesEqual(trees[1], trees[0]) {
+		t.Error("perceptrons with the same seed should produce the same tree")
+	}
+}
+
+func TestTreeSerialize(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	samples := rotatedSamples(r, 100)
+	tree := &Tree{
+		Attr: "class",
+		ValSplit: ValSplit{
+			true: Oblique(samples, []Attr{"x", "y"}, &Perceptron{Epochs: 10, Rand: r}, 2),
+			false: &Tree{
+				Attr: "x",
+				NumSplit: &NumSplit{
+					Threshold: 0.5,
+					LessEqual: &Tree{Classification: map[Class]float64{}},
+					Greater:   &Tree{Classification: map[Class]float64{true: 1}},
+				},
+			},
+		},
+	}
+
+	data, err := tree.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DeserializeTree(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !treesEqual(tree, decoded) {
+		t.Errorf("expected:\n%s\ngot:\n%s", tree, decoded)
+	}
+}
+
+func TestForestSerialize(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	samples := rotatedSamples(r, 100)
+	forest := Forest{
+		Oblique(samples, []Attr{"x", "y"}, &Perceptron{Epochs: 10}, 2),
+		LimitedID3(samples, []Attr{"x", "y"}, 0, 2),
+	}
+
+	data, err := serializer.SerializeWithType(forest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj, err := serializer.DeserializeWithType(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, ok := obj.(Forest)
+	if !ok {
+		t.Fatalf("unexpected type: %T", obj)
+	}
+	if len(decoded) != len(forest) {
+		t.Fatalf("expected %d trees but got %d", len(forest), len(decoded))
+	}
+	for i, tree := range forest {
+		if !treesEqual(tree, decoded[i]) {
+			t.Errorf("tree %d: expected:\n%s\ngot:\n%s", i, tree, decoded[i])
+		}
+	}
+}
+
+// rotatedSamples generates samples whose classes are
+// separated by the line y = -x.
+func rotatedSamples(r *rand.Rand, count int) []Sample {
+	var res []Sample
+	for i := 0; i < count; i++ {
+		x, y := r.Float64()*2-1, r.Float64()*2-1
+		if x+y > 0 {
+			x, y = x+0.05, y+0.05
+		} else {
+			x, y = x-0.05, y-0.05
+		}
+		res = append(res, treeTestSample{"x": x, "y": y, "class": x+y > 0})
+	}
+	return res
+}
+
+func treeAccuracy(tree *Tree, samples []Sample) float64 {
+	var correct int
+	for _, s := range samples {
+		var best Class
+		var bestProb float64
+		for class, prob := range tree.Classify(s) {
+			if prob > bestProb {
+				best, bestProb = class, prob
+			}
+		}
+		if best == s.Class() {
+			correct++
+		}
+	}
+	return float64(correct) / float64(len(samples))
+}
@@ -0,0 +1,73 @@
+package idtrees
+
+import (
+	"math/rand"
+
+	"github.com/unixpickle/weakai/svm"
+)
+
+// A Perceptron is a HyperplaneSolver which trains an
+// averaged perceptron.
+//
+// It always uses the linear kernel, regardless of the
+// problem's Kernel.
+type Perceptron struct {
+	// Epochs is the number of passes to make over the
+	// samples.
+	Epochs int
+
+	// Rand is used to shuffle the samples each epoch.
+	// If it is nil, the rand package's default generator
+	// is used.
+	Rand *rand.Rand
+}
+
+// Solve trains the perceptron on the problem.
+func (p *Perceptron) Solve(prob *svm.Problem) *svm.LinearClassifier {
+	var samples []svm.Sample
+	var labels []float64
+	for _, s := range prob.Positives {
+		samples = append(samples, s)
+		labels = append(labels, 1)
+	}
+	for _, s := range prob.Negatives {
+		samples = append(samples, s)
+		labels = append(labels, -1)
+	}
+
+	dim := len(samples[0].V)
+	weights := make([]float64, dim)
+	sumWeights := make([]float64, dim)
+	var bias, sumBias float64
+	for epoch := 0; epoch < p.Epochs; epoch++ {
+		var perm []int
+		if p.Rand != nil {
+			perm = p.Rand.Perm(len(samples))
+		} else {
+			perm = rand.Perm(len(samples))
+		}
+		for _, i := range perm {
+			v := samples[i].V
+			output := bias
+			for j, x := range v {
+				output += weights[j] * x
+			}
+			if output*labels[i] <= 0 {
+				for j, x := range v {
+					weights[j] += labels[i] * x
+				}
+				bias += labels[i]
+			}
+			for j, w := range weights {
+				sumWeights[j] += w
+			}
+			sumBias += bias
+		}
+	}
+
+	return &svm.LinearClassifier{
+		HyperplaneNormal: svm.Sample{V: sumWeights},
+		Threshold:        sumBias,
+		Kernel:           svm.LinearKernel,
+	}
+}
@@ -0,0 +1,103 @@
+package idtrees
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+
+	"github.com/unixpickle/serializer"
+)
+
+func init() {
+	var t Tree
+	serializer.RegisterTypedDeserializer(t.SerializerType(), DeserializeTree)
+	var f Forest
+	serializer.RegisterTypedDeserializer(f.SerializerType(), DeserializeForest)
+}
+
+// DeserializeTree deserializes a Tree.
+//
+// Attributes, values, and classes are encoded with the
+// encoding/gob package, so any types besides built-in
+// ones must be registered with gob.Register.
+func DeserializeTree(d []byte) (*Tree, error) {
+	var res Tree
+	if err := gob.NewDecoder(bytes.NewReader(d)).Decode(&res); err != nil {
+		return nil, err
+	}
+	res.restoreLeaves()
+	return &res, nil
+}
+
+// Serialize serializes the tree.
+// See DeserializeTree for restrictions on the types of
+// attributes, values, and classes.
+func (t *Tree) Serialize() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(t); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SerializerType returns the unique ID used to serialize
+// a Tree with the serializer package.
+func (t *Tree) SerializerType() string {
+	return "github.com/unixpickle/weakai/idtrees.Tree"
+}
+
+// DeserializeForest deserializes a Forest.
+// See DeserializeTree for restrictions on the types of
+// attributes, values, and classes.
+func DeserializeForest(d []byte) (Forest, error) {
+	list, err := serializer.DeserializeSlice(d)
+	if err != nil {
+		return nil, err
+	}
+	res := make(Forest, len(list))
+	for i, x := range list {
+		tree, ok := x.(*Tree)
+		if !ok {
+			return nil, fmt.Errorf("expected *Tree but got %T", x)
+		}
+		res[i] = tree
+	}
+	return res, nil
+}
+
+// Serialize serializes the forest.
+// See DeserializeTree for restrictions on the types of
+// attributes, values, and classes.
+func (f Forest) Serialize() ([]byte, error) {
+	list := make([]serializer.Serializer, len(f))
+	for i, t := range f {
+		list[i] = t
+	}
+	return serializer.SerializeSlice(list)
+}
+
+// SerializerType returns the unique ID used to serialize
+// a Forest with the serializer package.
+func (f Forest) SerializerType() string {
+	return "github.com/unixpickle/weakai/idtrees.Forest"
+}
+
+// restoreLeaves gives empty classifications back to
+// unreachable leaves, since gob does not transmit empty
+// maps.
+func (t *Tree) restoreLeaves() {
+	switch {
+	case t.NumSplit != nil:
+		t.NumSplit.LessEqual.restoreLeaves()
+		t.NumSplit.Greater.restoreLeaves()
+	case t.LinearSplit != nil:
+		t.LinearSplit.LessEqual.restoreLeaves()
+		t.LinearSplit.Greater.restoreLeaves()
+	case t.ValSplit != nil:
+		for _, subtree := range t.ValSplit {
+			subtree.restoreLeaves()
+		}
+	case t.Classification == nil:
+		t.Classification = map[Class]float64{}
+	}
+}
@@ -15,7 +15,11 @@ func (t *Tree) String() string {
 
 	var buf bytes.Buffer
 
-	buf.WriteString(fmt.Sprintf("%v", t.Attr))
+	if t.LinearSplit != nil {
+		buf.WriteString(linearString(t.LinearSplit))
+	} else {
+		buf.WriteString(fmt.Sprintf("%v", t.Attr))
+	}
 	buf.WriteRune('\n')
 
 	split := t.ValSplit
@@ -27,6 +31,13 @@ func (t *Tree) String() string {
 			lessKey:    t.NumSplit.LessEqual,
 			greaterKey: t.NumSplit.Greater,
 		}
+	} else if t.LinearSplit != nil {
+		lessKey := fmt.Sprintf("<= %v", t.LinearSplit.Threshold)
+		greaterKey := fmt.Sprintf("> %v", t.LinearSplit.Threshold)
+		split = ValSplit{
+			lessKey:    t.LinearSplit.LessEqual,
+			greaterKey: t.LinearSplit.Greater,
+		}
 	}
 
 	isFirst := true
@@ -56,3 +67,16 @@ func classificationString(m map[Class]float64) string {
 	}
 	return strings.Join(parts, " ")
 }
+
+func linearString(l *LinearSplit) string {
+	var parts []string
+	for i, attr := range l.Attrs {
+		if l.Weights[i] != 0 {
+			parts = append(parts, fmt.Sprintf("%v*%v", l.Weights[i], attr))
+		}
+	}
+	if len(parts) == 0 {
+		return "0"
+	}
+	return strings.Join(parts, " + ")
+}
@@ -46,12 +46,33 @@ func treesEqual(t1, t2 *Tree) bool {
 			}
 		}
 		return true
+	} else if t2.Classification != nil {
+		return false
 	}
 
 	if t1.Attr != t2.Attr {
 		return false
 	}
 
+	if t1.LinearSplit != nil {
+		if t2.LinearSplit == nil ||
+			t1.LinearSplit.Threshold != t2.LinearSplit.Threshold ||
+			len(t1.LinearSplit.Weights) != len(t2.LinearSplit.Weights) ||
+			len(t1.LinearSplit.Attrs) != len(t2.LinearSplit.Attrs) {
+			return false
+		}
+		for i, w := range t1.LinearSplit.Weights {
+			if w != t2.LinearSplit.Weights[i] ||
+				t1.LinearSplit.Attrs[i] != t2.LinearSplit.Attrs[i] {
+				return false
+			}
+		}
+		return treesEqual(t1.LinearSplit.Greater, t2.LinearSplit.Greater) &&
+			treesEqual(t1.LinearSplit.LessEqual, t2.LinearSplit.LessEqual)
+	} else if t2.LinearSplit != nil {
+		return false
+	}
+
 	if t1.NumSplit != nil {
 		if t2.NumSplit == nil {
 			return false
@@ -61,6 +82,8 @@ func treesEqual(t1, t2 *Tree) bool {
 		}
 		return treesEqual(t1.NumSplit.Greater, t2.NumSplit.Greater) &&
 			treesEqual(t1.NumSplit.LessEqual, t2.NumSplit.LessEqual)
+	} else if t2.NumSplit != nil {
+		return false
 	}
 
 	if len(t1.ValSplit) != len(t2.ValSplit) {
@@ -0,0 +1,51 @@
+// Package kernapprox implements explicit feature maps
+// which approximate kernel functions.
+//
+// A feature map z approximates a kernel k if
+// z(x)*z(y) is close to k(x, y).
+// Using such a map, a linear model trained on the
+// features behaves like a kernel machine, but it can be
+// trained and evaluated without computing kernels
+// between pairs of samples.
+//
+// RandomFourier approximates the radial basis kernel
+// with random Fourier features, while Nystrom
+// approximates any kernel using a set of landmarks.
+// Both maps can be used as fixed neuralnet.Layers, or
+// to transform svm.Samples for use with a linear kernel.
+package kernapprox
+
+import (
+	"github.com/unixpickle/num-analysis/linalg"
+	"github.com/unixpickle/weakai/svm"
+)
+
+// A FeatureMap maps input vectors to feature vectors.
+type FeatureMap interface {
+	Map(in linalg.Vector) linalg.Vector
+}
+
+// TransformSamples maps every sample through a
+// FeatureMap.
+// The UserInfo of each sample is preserved.
+func TransformSamples(f FeatureMap, s []svm.Sample) []svm.Sample {
+	res := make([]svm.Sample, len(s))
+	for i, sample := range s {
+		res[i] = svm.Sample{
+			V:        f.Map(sample.V),
+			UserInfo: sample.UserInfo,
+		}
+	}
+	return res
+}
+
+// TransformProblem maps the samples in a Problem through
+// a FeatureMap, producing a new Problem which uses
+// svm.LinearKernel.
+func TransformProblem(f FeatureMap, p *svm.Problem) *svm.Problem {
+	return &svm.Problem{
+		Positives: TransformSamples(f, p.Positives),
+		Negatives: TransformSamples(f, p.Negatives),
+		Kernel:    svm.LinearKernel,
+	}
+}
@@ -0,0 +1,147 @@
+package kernapprox
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+
+	"github.com/unixpickle/autofunc"
+	"github.com/unixpickle/num-analysis/linalg"
+	"github.com/unixpickle/serializer"
+)
+
+func init() {
+	var r RandomFourier
+	serializer.RegisterTypedDeserializer(r.SerializerType(), DeserializeRandomFourier)
+}
+
+// RandomFourier is a random Fourier feature map, as
+// described in Rahimi and Recht (2007).
+// It approximates RBFKernel (or, equivalently,
+// svm.RadialBasisKernel).
+//
+// The features are computed as sqrt(2/D)*cos(Wx+b),
+// where D is the number of features.
+//
+// A RandomFourier is a fixed neuralnet.Layer, meaning
+// that it has no learnable parameters.
+type RandomFourier struct {
+	// Weights has one row per feature.
+	Weights *linalg.Matrix
+
+	// Offsets has one phase offset per feature.
+	Offsets linalg.Vector
+}
+
+// DeserializeRandomFourier deserializes a RandomFourier.
+func DeserializeRandomFourier(d []byte) (*RandomFourier, error) {
+	var rows, cols serializer.Int
+	var weights, offsets *autofunc.Variable
+	if err := serializer.DeserializeAny(d, &rows, &cols, &weights, &offsets); err != nil {
+		return nil, err
+	}
+	if len(weights.Vector) != int(rows)*int(cols) || len(offsets.Vector) != int(rows) {
+		return nil, errors.New("invalid RandomFourier dimensions")
+	}
+	return &RandomFourier{
+		Weights: &linalg.Matrix{
+			Rows: int(rows),
+			Cols: int(cols),
+			Data: weights.Vector,
+		},
+		Offsets: offsets.Vector,
+	}, nil
+}
+
+// NewRandomFourier creates a RandomFourier which
+// approximates an RBFKernel with the given coefficient.
+//
+// The inSize argument specifies the input dimension,
+// and numFeatures specifies the output dimension.
+// More features give a better approximation.
+//
+// If r is nil, this uses the rand package's default
+// generator.
+func NewRandomFourier(inSize, numFeatures int, coeff float64, r *rand.Rand) *RandomFourier {
+	res := &RandomFourier{
+		Weights: linalg.NewMatrix(numFeatures, inSize),
+		Offsets: make(linalg.Vector, numFeatures),
+	}
+	stddev := math.Sqrt(2 * coeff)
+	for i := range res.Weights.Data {
+		if r != nil {
+			res.Weights.Data[i] = r.NormFloat64() * stddev
+		} else {
+			res.Weights.Data[i] = rand.NormFloat64() * stddev
+		}
+	}
+	for i := range res.Offsets {
+		if r != nil {
+			res.Offsets[i] = r.Float64() * 2 * math.Pi
+		} else {
+			res.Offsets[i] = rand.Float64() * 2 * math.Pi
+		}
+	}
+	return res
+}
+
+// Map computes the features for an input vector.
+func (r *RandomFourier) Map(in linalg.Vector) linalg.Vector {
+	return r.Apply(&autofunc.Variable{Vector: in}).Output()
+}
+
+// Apply applies the feature map to an input.
+func (r *RandomFourier) Apply(in autofunc.Result) autofunc.Result {
+	lin := r.linTran().Apply(in)
+	phased := autofunc.Add(lin, &autofunc.Variable{Vector: r.phases()})
+	return autofunc.Scale(autofunc.Sin{}.Apply(phased), r.outScale())
+}
+
+// ApplyR applies the feature map to an input.
+func (r *RandomFourier) ApplyR(rv autofunc.RVector, in autofunc.RResult) autofunc.RResult {
+	lin := r.linTran().ApplyR(rv, in)
+	phased := autofunc.AddR(lin, constRVariable(r.phases()))
+	return autofunc.ScaleR(autofunc.Sin{}.ApplyR(rv, phased), r.outScale())
+}
+
+// SerializerType returns the unique ID used to serialize
+// a RandomFourier with the serializer package.
+func (r *RandomFourier) SerializerType() string {
+	return "github.com/unixpickle/weakai/kernapprox.RandomFourier"
+}
+
+// Serialize serializes the feature map.
+func (r *RandomFourier) Serialize() ([]byte, error) {
+	return serializer.SerializeAny(
+		serializer.Int(r.Weights.Rows),
+		serializer.Int(r.Weights.Cols),
+		&autofunc.Variable{Vector: r.Weights.Data},
+		&autofunc.Variable{Vector: r.Offsets},
+	)
+}
+
+// linTran returns a function which multiplies by the
+// weight matrix.
+// The weights are wrapped in a fresh variable so that
+// they are treated as constants.
+func (r *RandomFourier) linTran() *autofunc.LinTran {
+	return &autofunc.LinTran{
+		Data: &autofunc.Variable{Vector: r.Weights.Data},
+		Rows: r.Weights.Rows,
+		Cols: r.Weights.Cols,
+	}
+}
+
+// phases returns the offsets shifted by pi/2, since
+// cos(x) = sin(x+pi/2).
+func (r *RandomFourier) phases() linalg.Vector {
+	res := make(linalg.Vector, len(r.Offsets))
+	for i, x := range r.Offsets {
+		res[i] = x + math.Pi/2
+	}
+	return res
+}
+
+func (r *RandomFourier) outScale() float64 {
+	return math.Sqrt(2 / float64(len(r.Offsets)))
+}
@@ -0,0 +1,72 @@
+package kernapprox
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/unixpickle/autofunc"
+	"github.com/unixpickle/autofunc/functest"
+	"github.com/unixpickle/num-analysis/linalg"
+	"github.com/unixpickle/serializer"
+)
+
+func TestRandomFourierApprox(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	kernel := &RBFKernel{Coeff: 0.5}
+	f := NewRandomFourier(3, 5000, kernel.Coeff, r)
+	for i := 0; i < 10; i++ {
+		x := randomVector(r, 3)
+		y := randomVector(r, 3)
+		expected := kernel.Eval(x, y)
+		actual := f.Map(x).Dot(f.Map(y))
+		if math.Abs(actual-expected) > 0.05 {
+			t.Errorf("sample %d: expected %f but got %f", i, expected, actual)
+		}
+	}
+}
+
+func TestRandomFourierGradients(t *testing.T) {
+	f := NewRandomFourier(3, 4, 0.5, nil)
+	in := &autofunc.Variable{Vector: randomVector(nil, 3)}
+	rv := autofunc.RVector{in: randomVector(nil, 3)}
+	checker := &functest.RFuncChecker{
+		F:     f,
+		Vars:  []*autofunc.Variable{in},
+		Input: in,
+		RV:    rv,
+	}
+	checker.FullCheck(t)
+}
+
+func TestRandomFourierSerialize(t *testing.T) {
+	f := NewRandomFourier(3, 4, 0.5, nil)
+	data, err := serializer.SerializeWithType(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj, err := serializer.DeserializeWithType(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f1, ok := obj.(*RandomFourier)
+	if !ok {
+		t.Fatalf("unexpected type: %T", obj)
+	}
+	if !reflect.DeepEqual(f1, f) {
+		t.Error("deserialized feature map does not match original")
+	}
+}
+
+func randomVector(r *rand.Rand, size int) linalg.Vector {
+	res := make(linalg.Vector, size)
+	for i := range res {
+		if r != nil {
+			res[i] = r.NormFloat64()
+		} else {
+			res[i] = rand.NormFloat64()
+		}
+	}
+	return res
+}
@@ -0,0 +1,230 @@
+package kernapprox
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/unixpickle/autofunc"
+	"github.com/unixpickle/num-analysis/linalg"
+	"github.com/unixpickle/serializer"
+	"github.com/unixpickle/weakai/svm"
+)
+
+func init() {
+	var r RBFKernel
+	serializer.RegisterTypedDeserializer(r.SerializerType(), DeserializeRBFKernel)
+	var p PolyKernel
+	serializer.RegisterTypedDeserializer(p.SerializerType(), DeserializePolyKernel)
+	var l LinearKernel
+	serializer.RegisterTypedDeserializer(l.SerializerType(), DeserializeLinearKernel)
+}
+
+// A Kernel is a kernel function which can be evaluated
+// on plain vectors.
+//
+// Kernels are used by Nystrom to compute kernel values
+// between inputs and landmarks.
+type Kernel interface {
+	serializer.Serializer
+
+	// Eval computes the kernel between two vectors.
+	Eval(x, y linalg.Vector) float64
+}
+
+// A DifferentiableKernel is a Kernel which can also be
+// applied to an autofunc.Result.
+//
+// A Nystrom can only be used as a neuralnet.Layer if
+// its kernel is differentiable.
+type DifferentiableKernel interface {
+	Kernel
+
+	// Apply computes the kernel between a Result and a
+	// constant vector, producing a Result with one
+	// component.
+	Apply(x autofunc.Result, y linalg.Vector) autofunc.Result
+
+	// ApplyR is like Apply, but with R-operator support.
+	ApplyR(rv autofunc.RVector, x autofunc.RResult, y linalg.Vector) autofunc.RResult
+}
+
+// SVMKernel converts a Kernel into an svm.Kernel.
+func SVMKernel(k Kernel) svm.Kernel {
+	return func(s1, s2 svm.Sample) float64 {
+		return k.Eval(s1.V, s2.V)
+	}
+}
+
+// RBFKernel is equivalent to svm.RadialBasisKernel,
+// computing exp(-Coeff*||x-y||^2).
+type RBFKernel struct {
+	Coeff float64
+}
+
+// DeserializeRBFKernel deserializes an RBFKernel.
+func DeserializeRBFKernel(d []byte) (*RBFKernel, error) {
+	var res RBFKernel
+	if err := json.Unmarshal(d, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// Eval evaluates the kernel.
+func (r *RBFKernel) Eval(x, y linalg.Vector) float64 {
+	return svm.RadialBasisKernel(r.Coeff)(svm.Sample{V: x}, svm.Sample{V: y})
+}
+
+// Apply applies the kernel to a Result.
+func (r *RBFKernel) Apply(x autofunc.Result, y linalg.Vector) autofunc.Result {
+	negY := &autofunc.Variable{Vector: y.Copy().Scale(-1)}
+	sqDist := autofunc.SquaredNorm{}.Apply(autofunc.Add(x, negY))
+	return autofunc.Exp{}.Apply(autofunc.Scale(sqDist, -r.Coeff))
+}
+
+// ApplyR applies the kernel to an RResult.
+func (r *RBFKernel) ApplyR(rv autofunc.RVector, x autofunc.RResult,
+	y linalg.Vector) autofunc.RResult {
+	negY := constRVariable(y.Copy().Scale(-1))
+	sqDist := autofunc.SquaredNorm{}.ApplyR(rv, autofunc.AddR(x, negY))
+	return autofunc.Exp{}.ApplyR(rv, autofunc.ScaleR(sqDist, -r.Coeff))
+}
+
+// SerializerType returns the unique ID used to serialize
+// an RBFKernel with the serializer package.
+func (r *RBFKernel) SerializerType() string {
+	return "github.com/unixpickle/weakai/kernapprox.RBFKernel"
+}
+
+// Serialize serializes the kernel.
+func (r *RBFKernel) Serialize() ([]byte, error) {
+	return json.Marshal(r)
+}
+
+// PolyKernel computes (x*y + Bias)^Degree.
+// Unlike svm.PolynomialKernel, it is limited to
+// non-negative integer degrees.
+type PolyKernel struct {
+	Bias   float64
+	Degree int
+}
+
+// DeserializePolyKernel deserializes a PolyKernel.
+func DeserializePolyKernel(d []byte) (*PolyKernel, error) {
+	var res PolyKernel
+	if err := json.Unmarshal(d, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// Eval evaluates the kernel.
+func (p *PolyKernel) Eval(x, y linalg.Vector) float64 {
+	k := svm.PolynomialKernel(p.Bias, float64(p.Degree))
+	return k(svm.Sample{V: x}, svm.Sample{V: y})
+}
+
+// Apply applies the kernel to a Result.
+func (p *PolyKernel) Apply(x autofunc.Result, y linalg.Vector) autofunc.Result {
+	dot := autofunc.SumAll(autofunc.Mul(x, &autofunc.Variable{Vector: y}))
+	return autofunc.Pool(autofunc.AddScaler(dot, p.Bias), func(base autofunc.Result) autofunc.Result {
+		var res autofunc.Result = &autofunc.Variable{Vector: []float64{1}}
+		for i := 0; i < p.Degree; i++ {
+			res = autofunc.Mul(res, base)
+		}
+		return res
+	})
+}
+
+// ApplyR applies the kernel to an RResult.
+func (p *PolyKernel) ApplyR(rv autofunc.RVector, x autofunc.RResult,
+	y linalg.Vector) autofunc.RResult {
+	dot := autofunc.SumAllR(autofunc.MulR(x, constRVariable(y)))
+	return autofunc.PoolR(autofunc.AddScalerR(dot, p.Bias),
+		func(base autofunc.RResult) autofunc.RResult {
+			var res autofunc.RResult = constRVariable([]float64{1})
+			for i := 0; i < p.Degree; i++ {
+				res = autofunc.MulR(res, base)
+			}
+			return res
+		})
+}
+
+// SerializerType returns the unique ID used to serialize
+// a PolyKernel with the serializer package.
+func (p *PolyKernel) SerializerType() string {
+	return "github.com/unixpickle/weakai/kernapprox.PolyKernel"
+}
+
+// Serialize serializes the kernel.
+func (p *PolyKernel) Serialize() ([]byte, error) {
+	return json.Marshal(p)
+}
+
+// LinearKernel computes the dot product of its inputs.
+type LinearKernel struct{}
+
+// DeserializeLinearKernel deserializes a LinearKernel.
+func DeserializeLinearKernel(d []byte) (LinearKernel, error) {
+	return LinearKernel{}, nil
+}
+
+// Eval evaluates the kernel.
+func (_ LinearKernel) Eval(x, y linalg.Vector) float64 {
+	return svm.LinearKernel(svm.Sample{V: x}, svm.Sample{V: y})
+}
+
+// Apply applies the kernel to a Result.
+func (_ LinearKernel) Apply(x autofunc.Result, y linalg.Vector) autofunc.Result {
+	return autofunc.SumAll(autofunc.Mul(x, &autofunc.Variable{Vector: y}))
+}
+
+// ApplyR applies the kernel to an RResult.
+func (_ LinearKernel) ApplyR(rv autofunc.RVector, x autofunc.RResult,
+	y linalg.Vector) autofunc.RResult {
+	return autofunc.SumAllR(autofunc.MulR(x, constRVariable(y)))
+}
+
+// SerializerType returns the unique ID used to serialize
+// a LinearKernel with the serializer package.
+func (_ LinearKernel) SerializerType() string {
+	return "github.com/unixpickle/weakai/kernapprox.LinearKernel"
+}
+
+// Serialize serializes the kernel.
+func (_ LinearKernel) Serialize() ([]byte, error) {
+	return []byte{}, nil
+}
+
+// FuncKernel wraps an arbitrary svm.Kernel.
+//
+// Since the kernel is an opaque function, a FuncKernel
+// cannot be serialized or differentiated.
+// Thus, a Nystrom with a FuncKernel can be used as a
+// FeatureMap, but not as a neuralnet.Layer.
+type FuncKernel struct {
+	K svm.Kernel
+}
+
+// Eval evaluates the kernel.
+func (f *FuncKernel) Eval(x, y linalg.Vector) float64 {
+	return f.K(svm.Sample{V: x}, svm.Sample{V: y})
+}
+
+// SerializerType returns a unique ID for FuncKernel.
+func (f *FuncKernel) SerializerType() string {
+	return "github.com/unixpickle/weakai/kernapprox.FuncKernel"
+}
+
+// Serialize always fails, since svm.Kernels cannot be
+// serialized.
+func (f *FuncKernel) Serialize() ([]byte, error) {
+	return nil, errors.New("cannot serialize FuncKernel")
+}
+
+func constRVariable(v linalg.Vector) *autofunc.RVariable {
+	return &autofunc.RVariable{
+		Variable:   &autofunc.Variable{Vector: v},
+		ROutputVec: make(linalg.Vector, len(v)),
+	}
+}
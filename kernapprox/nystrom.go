@@ -0,0 +1,280 @@
+package kernapprox
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+
+	"github.com/unixpickle/autofunc"
+	"github.com/unixpickle/num-analysis/linalg"
+	"github.com/unixpickle/serializer"
+)
+
+// eigenThreshold is the relative size below which
+// eigenvalues of the landmark kernel matrix are
+// discarded.
+const eigenThreshold = 1e-10
+
+func init() {
+	var n Nystrom
+	serializer.RegisterTypedDeserializer(n.SerializerType(), DeserializeNystrom)
+}
+
+// Nystrom is a feature map which approximates an
+// arbitrary kernel using the Nyström method, as
+// described in Williams and Seeger (2001).
+//
+// The features for an input x are computed as P*k(x),
+// where k(x) is the vector of kernel values between x
+// and each landmark, and P is the inverse square root
+// of the kernel matrix of the landmarks.
+// As a result, the approximation is exact when both
+// arguments are landmarks.
+//
+// A Nystrom is a fixed neuralnet.Layer, meaning that it
+// has no learnable parameters.
+// It can only be used as a layer if its Kernel is a
+// DifferentiableKernel, and it can only be serialized if
+// its Kernel can be.
+type Nystrom struct {
+	Kernel    Kernel
+	Landmarks []linalg.Vector
+
+	// Projection has one column per landmark and one
+	// row per output feature.
+	// There may be fewer features than landmarks if the
+	// kernel matrix of the landmarks is singular.
+	Projection *linalg.Matrix
+}
+
+// DeserializeNystrom deserializes a Nystrom.
+func DeserializeNystrom(d []byte) (*Nystrom, error) {
+	var kernel Kernel
+	var numLandmarks, rank serializer.Int
+	var landmarks, projection *autofunc.Variable
+	err := serializer.DeserializeAny(d, &kernel, &numLandmarks, &rank, &landmarks,
+		&projection)
+	if err != nil {
+		return nil, err
+	}
+	if numLandmarks <= 0 || len(landmarks.Vector)%int(numLandmarks) != 0 ||
+		len(projection.Vector) != int(rank)*int(numLandmarks) {
+		return nil, errors.New("invalid Nystrom dimensions")
+	}
+	res := &Nystrom{
+		Kernel: kernel,
+		Projection: &linalg.Matrix{
+			Rows: int(rank),
+			Cols: int(numLandmarks),
+			Data: projection.Vector,
+		},
+	}
+	inSize := len(landmarks.Vector) / int(numLandmarks)
+	for i := 0; i < int(numLandmarks); i++ {
+		res.Landmarks = append(res.Landmarks, landmarks.Vector[i*inSize:(i+1)*inSize])
+	}
+	return res, nil
+}
+
+// NewNystrom creates a Nystrom feature map by randomly
+// choosing numLandmarks distinct landmarks from a set of
+// samples.
+// If there are fewer samples than numLandmarks, every
+// sample is used as a landmark.
+//
+// If r is nil, this uses the rand package's default
+// generator.
+func NewNystrom(k Kernel, samples []linalg.Vector, numLandmarks int,
+	r *rand.Rand) *Nystrom {
+	var perm []int
+	if r != nil {
+		perm = r.Perm(len(samples))
+	} else {
+		perm = rand.Perm(len(samples))
+	}
+	if numLandmarks < len(perm) {
+		perm = perm[:numLandmarks]
+	}
+	landmarks := make([]linalg.Vector, len(perm))
+	for i, j := range perm {
+		landmarks[i] = samples[j].Copy()
+	}
+	return NewNystromLandmarks(k, landmarks)
+}
+
+// NewNystromLandmarks creates a Nystrom feature map with
+// the given landmarks.
+func NewNystromLandmarks(k Kernel, landmarks []linalg.Vector) *Nystrom {
+	if len(landmarks) == 0 {
+		panic("at least one landmark is required")
+	}
+	kernelMat := linalg.NewMatrix(len(landmarks), len(landmarks))
+	for i, x := range landmarks {
+		for j, y := range landmarks[:i+1] {
+			val := k.Eval(x, y)
+			kernelMat.Set(i, j, val)
+			kernelMat.Set(j, i, val)
+		}
+	}
+	values, vectors := symmetricEigen(kernelMat)
+
+	var maxValue float64
+	for _, v := range values {
+		maxValue = math.Max(maxValue, v)
+	}
+	var rows []int
+	for i, v := range values {
+		if v > maxValue*eigenThreshold {
+			rows = append(rows, i)
+		}
+	}
+
+	projection := linalg.NewMatrix(len(rows), len(landmarks))
+	for i, idx := range rows {
+		scale := 1 / math.Sqrt(values[idx])
+		for j := range landmarks {
+			projection.Set(i, j, vectors.Get(j, idx)*scale)
+		}
+	}
+	return &Nystrom{
+		Kernel:     k,
+		Landmarks:  landmarks,
+		Projection: projection,
+	}
+}
+
+// Map computes the features for an input vector.
+func (n *Nystrom) Map(in linalg.Vector) linalg.Vector {
+	kernelVec := make(linalg.Vector, len(n.Landmarks))
+	for i, l := range n.Landmarks {
+		kernelVec[i] = n.Kernel.Eval(in, l)
+	}
+	return n.linTran().Apply(&autofunc.Variable{Vector: kernelVec}).Output()
+}
+
+// Apply applies the feature map to an input.
+// It panics if the kernel is not differentiable.
+func (n *Nystrom) Apply(in autofunc.Result) autofunc.Result {
+	kernel := n.diffKernel()
+	return autofunc.Pool(in, func(in autofunc.Result) autofunc.Result {
+		kernelVals := make([]autofunc.Result, len(n.Landmarks))
+		for i, l := range n.Landmarks {
+			kernelVals[i] = kernel.Apply(in, l)
+		}
+		return n.linTran().Apply(autofunc.Concat(kernelVals...))
+	})
+}
+
+// ApplyR applies the feature map to an input.
+// It panics if the kernel is not differentiable.
+func (n *Nystrom) ApplyR(rv autofunc.RVector, in autofunc.RResult) autofunc.RResult {
+	kernel := n.diffKernel()
+	return autofunc.PoolR(in, func(in autofunc.RResult) autofunc.RResult {
+		kernelVals := make([]autofunc.RResult, len(n.Landmarks))
+		for i, l := range n.Landmarks {
+			kernelVals[i] = kernel.ApplyR(rv, in, l)
+		}
+		return n.linTran().ApplyR(rv, autofunc.ConcatR(kernelVals...))
+	})
+}
+
+// SerializerType returns the unique ID used to serialize
+// a Nystrom with the serializer package.
+func (n *Nystrom) SerializerType() string {
+	return "github.com/unixpickle/weakai/kernapprox.Nystrom"
+}
+
+// Serialize serializes the feature map.
+// This fails if the kernel cannot be serialized.
+func (n *Nystrom) Serialize() ([]byte, error) {
+	var landmarks linalg.Vector
+	for _, l := range n.Landmarks {
+		landmarks = append(landmarks, l...)
+	}
+	return serializer.SerializeAny(
+		n.Kernel,
+		serializer.Int(len(n.Landmarks)),
+		serializer.Int(n.Projection.Rows),
+		&autofunc.Variable{Vector: landmarks},
+		&autofunc.Variable{Vector: n.Projection.Data},
+	)
+}
+
+func (n *Nystrom) diffKernel() DifferentiableKernel {
+	kernel, ok := n.Kernel.(DifferentiableKernel)
+	if !ok {
+		panic("kernel is not differentiable")
+	}
+	return kernel
+}
+
+func (n *Nystrom) linTran() *autofunc.LinTran {
+	return &autofunc.LinTran{
+		Data: &autofunc.Variable{Vector: n.Projection.Data},
+		Rows: n.Projection.Rows,
+		Cols: n.Projection.Cols,
+	}
+}
+
+// symmetricEigen computes the eigenvalues and
+// eigenvectors of a symmetric matrix using the cyclic
+// Jacobi method.
+// The eigenvectors are the columns of the resulting
+// matrix.
+func symmetricEigen(m *linalg.Matrix) (linalg.Vector, *linalg.Matrix) {
+	n := m.Rows
+	a := m.Copy()
+	vecs := linalg.NewMatrix(n, n)
+	for i := 0; i < n; i++ {
+		vecs.Set(i, i, 1)
+	}
+
+	for sweep := 0; sweep < 100; sweep++ {
+		var offDiag, diag float64
+		for i := 0; i < n; i++ {
+			diag += a.Get(i, i) * a.Get(i, i)
+			for j := i + 1; j < n; j++ {
+				offDiag += a.Get(i, j) * a.Get(i, j)
+			}
+		}
+		if offDiag <= 1e-30*diag || offDiag == 0 {
+			break
+		}
+		for p := 0; p < n; p++ {
+			for q := p + 1; q < n; q++ {
+				apq := a.Get(p, q)
+				if apq == 0 {
+					continue
+				}
+				theta := (a.Get(q, q) - a.Get(p, p)) / (2 * apq)
+				t := 1 / (math.Abs(theta) + math.Sqrt(theta*theta+1))
+				if theta < 0 {
+					t = -t
+				}
+				c := 1 / math.Sqrt(t*t+1)
+				s := t * c
+				for k := 0; k < n; k++ {
+					akp, akq := a.Get(k, p), a.Get(k, q)
+					a.Set(k, p, c*akp-s*akq)
+					a.Set(k, q, s*akp+c*akq)
+				}
+				for k := 0; k < n; k++ {
+					apk, aqk := a.Get(p, k), a.Get(q, k)
+					a.Set(p, k, c*apk-s*aqk)
+					a.Set(q, k, s*apk+c*aqk)
+				}
+				for k := 0; k < n; k++ {
+					vkp, vkq := vecs.Get(k, p), vecs.Get(k, q)
+					vecs.Set(k, p, c*vkp-s*vkq)
+					vecs.Set(k, q, s*vkp+c*vkq)
+				}
+			}
+		}
+	}
+
+	values := make(linalg.Vector, n)
+	for i := range values {
+		values[i] = a.Get(i, i)
+	}
+	return values, vecs
+}
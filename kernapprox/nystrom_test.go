@@ -0,0 +1,161 @@
+package kernapprox
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/unixpickle/autofunc"
+	"github.com/unixpickle/autofunc/functest"
+	"github.com/unixpickle/num-analysis/linalg"
+	"github.com/unixpickle/serializer"
+	"github.com/unixpickle/weakai/svm"
+)
+
+func TestNystromLandmarks(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	kernels := []Kernel{
+		&RBFKernel{Coeff: 0.3},
+		&PolyKernel{Bias: 1, Degree: 2},
+		&FuncKernel{K: svm.RadialBasisKernel(0.3)},
+	}
+	var samples []linalg.Vector
+	for i := 0; i < 20; i++ {
+		samples = append(samples, randomVector(r, 3))
+	}
+	for i, kernel := range kernels {
+		n := NewNystrom(kernel, samples, 8, r)
+		if len(n.Landmarks) != 8 {
+			t.Errorf("kernel %d: expected 8 landmarks but got %d", i, len(n.Landmarks))
+		}
+		features := make([]linalg.Vector, len(n.Landmarks))
+		for j, l := range n.Landmarks {
+			features[j] = n.Map(l)
+		}
+		for j, x := range n.Landmarks {
+			for k, y := range n.Landmarks {
+				expected := kernel.Eval(x, y)
+				actual := features[j].Dot(features[k])
+				if math.Abs(actual-expected) > 1e-6 {
+					t.Errorf("kernel %d: landmarks %d,%d: expected %f but got %f",
+						i, j, k, expected, actual)
+				}
+			}
+		}
+	}
+}
+
+func TestNystromLowRank(t *testing.T) {
+	var samples []linalg.Vector
+	for i := 0; i < 10; i++ {
+		samples = append(samples, randomVector(nil, 2))
+	}
+	n := NewNystrom(LinearKernel{}, samples, 10, nil)
+	if n.Projection.Rows != 2 {
+		t.Errorf("expected 2 features but got %d", n.Projection.Rows)
+	}
+	x, y := randomVector(nil, 2), randomVector(nil, 2)
+	if actual, expected := n.Map(x).Dot(n.Map(y)), x.Dot(y); math.Abs(actual-expected) > 1e-6 {
+		t.Errorf("expected %f but got %f", expected, actual)
+	}
+}
+
+func TestNystromGradients(t *testing.T) {
+	var samples []linalg.Vector
+	for i := 0; i < 5; i++ {
+		samples = append(samples, randomVector(nil, 3))
+	}
+	kernels := []Kernel{&RBFKernel{Coeff: 0.3}, &PolyKernel{Bias: 1, Degree: 3}}
+	for _, kernel := range kernels {
+		n := NewNystrom(kernel, samples, 4, nil)
+		in := &autofunc.Variable{Vector: randomVector(nil, 3)}
+		rv := autofunc.RVector{in: randomVector(nil, 3)}
+		checker := &functest.RFuncChecker{
+			F:     n,
+			Vars:  []*autofunc.Variable{in},
+			Input: in,
+			RV:    rv,
+		}
+		checker.FullCheck(t)
+	}
+}
+
+func TestNystromFuncKernel(t *testing.T) {
+	var samples []linalg.Vector
+	for i := 0; i < 5; i++ {
+		samples = append(samples, randomVector(nil, 3))
+	}
+	n := NewNystrom(&FuncKernel{K: svm.RadialBasisKernel(0.3)}, samples, 4, nil)
+	if len(n.Map(samples[0])) != n.Projection.Rows {
+		t.Error("unexpected feature count")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic applying FuncKernel")
+		}
+	}()
+	n.Apply(&autofunc.Variable{Vector: samples[0]})
+}
+
+func TestNystromSerialize(t *testing.T) {
+	var samples []linalg.Vector
+	for i := 0; i < 5; i++ {
+		samples = append(samples, randomVector(nil, 3))
+	}
+	n := NewNystrom(&RBFKernel{Coeff: 0.3}, samples, 4, nil)
+	data, err := serializer.SerializeWithType(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj, err := serializer.DeserializeWithType(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n1, ok := obj.(*Nystrom)
+	if !ok {
+		t.Fatalf("unexpected type: %T", obj)
+	}
+	if !reflect.DeepEqual(n1, n) {
+		t.Error("deserialized feature map does not match original")
+	}
+
+	n.Kernel = &FuncKernel{K: svm.LinearKernel}
+	if _, err := n.Serialize(); err == nil {
+		t.Error("expected error serializing FuncKernel")
+	}
+}
+
+func TestTransformProblem(t *testing.T) {
+	r := rand.New(rand.NewSource(42))
+	problem := &svm.Problem{Kernel: svm.RadialBasisKernel(1)}
+	var samples []linalg.Vector
+	for i := 0; i < 60; i++ {
+		v := linalg.Vector{r.Float64()*4 - 2, r.Float64()*4 - 2}
+		samples = append(samples, v)
+		s := svm.Sample{V: v, UserInfo: i}
+		if v.Dot(v) < 1 {
+			problem.Positives = append(problem.Positives, s)
+		} else {
+			problem.Negatives = append(problem.Negatives, s)
+		}
+	}
+
+	f := NewNystrom(&RBFKernel{Coeff: 1}, samples, 30, r)
+	transformed := TransformProblem(f, problem)
+	if len(transformed.Positives) != len(problem.Positives) ||
+		len(transformed.Negatives) != len(problem.Negatives) {
+		t.Fatal("sample counts changed")
+	}
+	for i, s := range transformed.Positives {
+		if s.UserInfo != problem.Positives[i].UserInfo {
+			t.Fatal("UserInfo not preserved")
+		}
+	}
+
+	x, y := transformed.Positives[0], transformed.Negatives[0]
+	expected := problem.Kernel(problem.Positives[0], problem.Negatives[0])
+	if actual := transformed.Kernel(x, y); math.Abs(actual-expected) > 0.05 {
+		t.Errorf("expected kernel %f but got %f", expected, actual)
+	}
+}
@@ -0,0 +1,58 @@
+package lime
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/unixpickle/num-analysis/linalg"
+)
+
+const defaultBinaryKernelWidth = 0.25
+
+// sampleMasks creates binary perturbations for features
+// which can be switched on and off.
+//
+// The first mask keeps every feature.
+// Each other mask turns off a uniformly random number of
+// features.
+//
+// The distances are cosine distances between each mask
+// and the first mask.
+func (e *Explainer) sampleMasks(numFeatures int, r *rand.Rand) ([]linalg.Vector,
+	[]float64) {
+	if numFeatures == 0 {
+		panic("no features to explain")
+	}
+	masks := make([]linalg.Vector, e.numSamples())
+	distances := make([]float64, len(masks))
+	for i := range masks {
+		masks[i] = make(linalg.Vector, numFeatures)
+		for j := range masks[i] {
+			masks[i][j] = 1
+		}
+		if i == 0 {
+			continue
+		}
+		numOff := 1 + randIntn(r, numFeatures)
+		for _, j := range randPerm(r, numFeatures)[:numOff] {
+			masks[i][j] = 0
+		}
+		numOn := numFeatures - numOff
+		distances[i] = 1 - math.Sqrt(float64(numOn)/float64(numFeatures))
+	}
+	return masks, distances
+}
+
+func randIntn(r *rand.Rand, n int) int {
+	if r == nil {
+		return rand.Intn(n)
+	}
+	return r.Intn(n)
+}
+
+func randPerm(r *rand.Rand, n int) []int {
+	if r == nil {
+		return rand.Perm(n)
+	}
+	return r.Perm(n)
+}
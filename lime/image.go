@@ -0,0 +1,86 @@
+package lime
+
+import (
+	"math/rand"
+
+	"github.com/unixpickle/num-analysis/linalg"
+	"github.com/unixpickle/tensor"
+)
+
+// GridSegments divides an image into square superpixels
+// with the given side length.
+// The result contains one superpixel ID per pixel, in
+// row-major order.
+func GridSegments(width, height, cellSize int) []int {
+	cols := (width + cellSize - 1) / cellSize
+	res := make([]int, 0, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			res = append(res, (y/cellSize)*cols+x/cellSize)
+		}
+	}
+	return res
+}
+
+// ExplainImage explains a model's prediction for an
+// image which is divided into superpixels.
+//
+// The segments argument assigns a superpixel ID to each
+// pixel, in row-major order.
+// IDs should range from 0 to one less than the number of
+// superpixels, like those from GridSegments.
+//
+// Perturbed inputs are created by hiding superpixels,
+// replacing each hidden superpixel with its mean color.
+// The model receives the Data of perturbed images.
+//
+// The weights in the resulting explanation indicate how
+// much each superpixel contributes to the prediction.
+// The default kernel width is 0.25.
+//
+// If r is nil, this uses the rand package's default
+// generator.
+func (e *Explainer) ExplainImage(m Model, img *tensor.Float64, segments []int,
+	r *rand.Rand) *Explanation {
+	if len(segments) != img.Width*img.Height {
+		panic("segment count must match pixel count")
+	}
+	var numSegments int
+	for _, s := range segments {
+		if s+1 > numSegments {
+			numSegments = s + 1
+		}
+	}
+
+	means := make([]linalg.Vector, numSegments)
+	counts := make([]float64, numSegments)
+	for i := range means {
+		means[i] = make(linalg.Vector, img.Depth)
+	}
+	for pixel, s := range segments {
+		means[s].Add(img.Data[pixel*img.Depth : (pixel+1)*img.Depth])
+		counts[s]++
+	}
+	for i, count := range counts {
+		if count > 0 {
+			means[i].Scale(1 / count)
+		}
+	}
+
+	masks, distances := e.sampleMasks(numSegments, r)
+	return e.explain(masks, distances, defaultBinaryKernelWidth,
+		func(indices []int) []float64 {
+			inputs := make([]linalg.Vector, len(indices))
+			for i, idx := range indices {
+				mask := masks[idx]
+				data := linalg.Vector(img.Data).Copy()
+				for pixel, s := range segments {
+					if mask[s] == 0 {
+						copy(data[pixel*img.Depth:], means[s])
+					}
+				}
+				inputs[i] = data
+			}
+			return m.Predict(inputs)
+		})
+}
@@ -0,0 +1,214 @@
+// Package lime implements Local Interpretable
+// Model-agnostic Explanations (LIME), as described in
+// Ribeiro, Singh, and Guestrin (2016).
+//
+// LIME explains a single prediction of a black-box
+// model by perturbing the input, querying the model on
+// the perturbed inputs, and fitting a sparse linear
+// model which approximates the black-box model near the
+// original input.
+// Since it only needs the model's outputs, LIME works
+// for models without gradients, such as forests,
+// boosted ensembles, and kernel SVMs.
+package lime
+
+import (
+	"math"
+	"sort"
+
+	"github.com/unixpickle/num-analysis/linalg"
+)
+
+const (
+	defaultNumSamples     = 1000
+	defaultNumFeatures    = 10
+	defaultRegularization = 1
+	defaultBatchSize      = 100
+)
+
+// A Model computes a score for each vector in a batch
+// of inputs, such as the probability of a class.
+type Model interface {
+	Predict(inputs []linalg.Vector) []float64
+}
+
+// ModelFunc is a Model which calls a function.
+type ModelFunc func(inputs []linalg.Vector) []float64
+
+// Predict calls f.
+func (f ModelFunc) Predict(inputs []linalg.Vector) []float64 {
+	return f(inputs)
+}
+
+// A TextModel computes a score for each document in a
+// batch of tokenized documents.
+type TextModel interface {
+	PredictText(docs [][]string) []float64
+}
+
+// TextModelFunc is a TextModel which calls a function.
+type TextModelFunc func(docs [][]string) []float64
+
+// PredictText calls f.
+func (f TextModelFunc) PredictText(docs [][]string) []float64 {
+	return f(docs)
+}
+
+// A Contribution describes how much one interpretable
+// feature contributes to a prediction.
+type Contribution struct {
+	// Feature is the index of the feature.
+	// For tabular data, this is an index in the input
+	// vector.
+	// For images, it is a superpixel ID.
+	// For text, it is an index in the list of distinct
+	// tokens.
+	Feature int
+
+	// Name is the token for text features, and is empty
+	// for other kinds of features.
+	Name string
+
+	// Weight is the feature's coefficient in the linear
+	// surrogate model.
+	Weight float64
+}
+
+// An Explanation is a sparse linear model which
+// approximates a model near an input.
+type Explanation struct {
+	// Contributions contains the features used by the
+	// surrogate, sorted by decreasing absolute weight.
+	Contributions []Contribution
+
+	// Intercept is the surrogate's bias term.
+	Intercept float64
+
+	// Score is the weighted coefficient of determination
+	// of the surrogate on the perturbed samples.
+	// It indicates how faithful the explanation is.
+	Score float64
+
+	// ModelPrediction is the model's output for the
+	// original input.
+	ModelPrediction float64
+
+	// LocalPrediction is the surrogate's output for the
+	// original input.
+	LocalPrediction float64
+}
+
+// An Explainer produces LIME explanations.
+//
+// A zero value for any field indicates that a default
+// should be used.
+type Explainer struct {
+	// NumSamples is the number of perturbed inputs,
+	// including the original input.
+	NumSamples int
+
+	// NumFeatures is the maximum number of features in
+	// an explanation.
+	NumFeatures int
+
+	// KernelWidth controls how quickly the importance of
+	// a perturbed sample decays with its distance from
+	// the original input.
+	// The default depends on the kind of input.
+	KernelWidth float64
+
+	// Regularization is the ridge penalty used when
+	// fitting the surrogate.
+	Regularization float64
+
+	// BatchSize is the maximum number of inputs to pass
+	// to the model at once.
+	BatchSize int
+}
+
+// explain queries a model on perturbed samples and fits
+// a surrogate to the results.
+//
+// The interpretable representation of each sample is
+// given by interp, where the first sample must be the
+// original input.
+// The predict function evaluates the model on the
+// samples at the given indices.
+func (e *Explainer) explain(interp []linalg.Vector, distances []float64,
+	kernelWidth float64, predict func(indices []int) []float64) *Explanation {
+	batchSize := e.BatchSize
+	if batchSize == 0 {
+		batchSize = defaultBatchSize
+	}
+	preds := make([]float64, 0, len(interp))
+	for i := 0; i < len(interp); i += batchSize {
+		var indices []int
+		for j := i; j < i+batchSize && j < len(interp); j++ {
+			indices = append(indices, j)
+		}
+		preds = append(preds, predict(indices)...)
+	}
+
+	if e.KernelWidth != 0 {
+		kernelWidth = e.KernelWidth
+	}
+	weights := make([]float64, len(distances))
+	for i, d := range distances {
+		weights[i] = math.Sqrt(math.Exp(-d * d / (kernelWidth * kernelWidth)))
+	}
+
+	allFeatures := make([]int, len(interp[0]))
+	for i := range allFeatures {
+		allFeatures[i] = i
+	}
+	features := allFeatures
+	if len(allFeatures) > e.numFeatures() {
+		coeffs, _ := e.fitSurrogate(interp, preds, weights, allFeatures)
+		features = topFeatures(coeffs, e.numFeatures())
+	}
+	coeffs, intercept := e.fitSurrogate(interp, preds, weights, features)
+
+	res := &Explanation{
+		Intercept:       intercept,
+		ModelPrediction: preds[0],
+		LocalPrediction: intercept,
+	}
+	for i, feature := range features {
+		res.Contributions = append(res.Contributions, Contribution{
+			Feature: feature,
+			Weight:  coeffs[i],
+		})
+		res.LocalPrediction += coeffs[i] * interp[0][feature]
+	}
+	sort.Sort(contributionSorter(res.Contributions))
+	res.Score = weightedR2(interp, preds, weights, features, coeffs, intercept)
+	return res
+}
+
+func (e *Explainer) numSamples() int {
+	if e.NumSamples == 0 {
+		return defaultNumSamples
+	}
+	return e.NumSamples
+}
+
+func (e *Explainer) numFeatures() int {
+	if e.NumFeatures == 0 {
+		return defaultNumFeatures
+	}
+	return e.NumFeatures
+}
+
+type contributionSorter []Contribution
+
+func (c contributionSorter) Len() int {
+	return len(c)
+}
+
+func (c contributionSorter) Less(i, j int) bool {
+	return math.Abs(c[i].Weight) > math.Abs(c[j].Weight)
+}
+
+func (c contributionSorter) Swap(i, j int) {
+	c[i], c[j] = c[j], c[i]
+}
@@ -0,0 +1,122 @@
+package lime
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/unixpickle/num-analysis/linalg"
+	"github.com/unixpickle/tensor"
+)
+
+func TestExplainTabular(t *testing.T) {
+	model := ModelFunc(func(inputs []linalg.Vector) []float64 {
+		res := make([]float64, len(inputs))
+		for i, x := range inputs {
+			res[i] = 3*x[0] - 2*x[2] + 1
+		}
+		return res
+	})
+	e := &Explainer{NumFeatures: 2, Regularization: 1e-5}
+	input := linalg.Vector{1, 2, 3, 4}
+	stddevs := linalg.Vector{1, 1, 0.5, 0}
+	exp := e.ExplainTabular(model, input, stddevs, rand.New(rand.NewSource(1337)))
+
+	if len(exp.Contributions) != 2 {
+		t.Fatalf("expected 2 contributions but got %d", len(exp.Contributions))
+	}
+	expected := []Contribution{{Feature: 0, Weight: 3}, {Feature: 2, Weight: -1}}
+	for i, c := range expected {
+		actual := exp.Contributions[i]
+		if actual.Feature != c.Feature || math.Abs(actual.Weight-c.Weight) > 1e-3 {
+			t.Errorf("contribution %d: expected %v but got %v", i, c, actual)
+		}
+	}
+	if math.Abs(exp.ModelPrediction-(-2)) > 1e-8 {
+		t.Errorf("expected model prediction -2 but got %f", exp.ModelPrediction)
+	}
+	if math.Abs(exp.LocalPrediction-exp.ModelPrediction) > 1e-3 {
+		t.Errorf("expected local prediction %f but got %f", exp.ModelPrediction,
+			exp.LocalPrediction)
+	}
+	if math.Abs(exp.Score-1) > 1e-6 {
+		t.Errorf("expected score 1 but got %f", exp.Score)
+	}
+}
+
+func TestExplainImage(t *testing.T) {
+	img := tensor.NewFloat64(4, 4, 2)
+	img.Set(3, 3, 0, 1)
+	img.Set(0, 0, 1, 1)
+	segments := GridSegments(4, 4, 2)
+
+	// The model detects a bright pixel in the red channel
+	// of the bottom-right superpixel.
+	model := ModelFunc(func(inputs []linalg.Vector) []float64 {
+		res := make([]float64, len(inputs))
+		for i, x := range inputs {
+			for j := 0; j < len(x); j += 2 {
+				res[i] = math.Max(res[i], x[j])
+			}
+		}
+		return res
+	})
+	e := &Explainer{NumSamples: 500, NumFeatures: 2}
+	exp := e.ExplainImage(model, img, segments, rand.New(rand.NewSource(1337)))
+
+	top := exp.Contributions[0]
+	if top.Feature != 3 {
+		t.Errorf("expected superpixel 3 but got %d", top.Feature)
+	}
+	if top.Weight < 0.5 || top.Weight > 0.8 {
+		t.Errorf("unexpected weight: %f", top.Weight)
+	}
+	if math.Abs(exp.Contributions[1].Weight) > 0.05 {
+		t.Errorf("irrelevant superpixel has weight %f", exp.Contributions[1].Weight)
+	}
+}
+
+func TestExplainText(t *testing.T) {
+	tokens := []string{"this", "movie", "was", "great", "and", "great", "fun"}
+	var queries int
+	model := TextModelFunc(func(docs [][]string) []float64 {
+		if len(docs) > 30 {
+			t.Fatalf("batch of size %d", len(docs))
+		}
+		res := make([]float64, len(docs))
+		for i, doc := range docs {
+			queries++
+			for _, token := range doc {
+				if token == "great" {
+					res[i]++
+				}
+			}
+		}
+		return res
+	})
+	e := &Explainer{NumSamples: 300, BatchSize: 30, NumFeatures: 3}
+	exp := e.ExplainText(model, tokens, rand.New(rand.NewSource(1337)))
+	if queries != 300 {
+		t.Errorf("expected 300 queries but got %d", queries)
+	}
+	if len(exp.Contributions) != 3 {
+		t.Fatalf("expected 3 contributions but got %d", len(exp.Contributions))
+	}
+	top := exp.Contributions[0]
+	if top.Name != "great" || top.Feature != 3 {
+		t.Errorf("unexpected top contribution: %v", top)
+	}
+	if math.Abs(top.Weight-2) > 0.1 {
+		t.Errorf("expected weight near 2 but got %f", top.Weight)
+	}
+}
+
+func TestGridSegments(t *testing.T) {
+	actual := GridSegments(3, 3, 2)
+	expected := []int{0, 0, 1, 0, 0, 1, 2, 2, 3}
+	for i, x := range expected {
+		if actual[i] != x {
+			t.Fatalf("expected %v but got %v", expected, actual)
+		}
+	}
+}
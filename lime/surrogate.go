@@ -0,0 +1,146 @@
+package lime
+
+import (
+	"math"
+	"sort"
+
+	"github.com/unixpickle/num-analysis/linalg"
+)
+
+// fitSurrogate fits a weighted ridge regression to the
+// given features of the samples.
+// The intercept is not regularized.
+func (e *Explainer) fitSurrogate(samples []linalg.Vector, targets, weights []float64,
+	features []int) (coeffs []float64, intercept float64) {
+	reg := e.Regularization
+	if reg == 0 {
+		reg = defaultRegularization
+	}
+
+	var totalWeight, targetMean float64
+	featureMeans := make([]float64, len(features))
+	for i, sample := range samples {
+		totalWeight += weights[i]
+		targetMean += weights[i] * targets[i]
+		for j, feature := range features {
+			featureMeans[j] += weights[i] * sample[feature]
+		}
+	}
+	targetMean /= totalWeight
+	for j := range featureMeans {
+		featureMeans[j] /= totalWeight
+	}
+
+	n := len(features)
+	gram := linalg.NewMatrix(n, n)
+	rhs := make(linalg.Vector, n)
+	centered := make([]float64, n)
+	for i, sample := range samples {
+		for j, feature := range features {
+			centered[j] = sample[feature] - featureMeans[j]
+		}
+		w := weights[i]
+		for j, x := range centered {
+			rhs[j] += w * x * (targets[i] - targetMean)
+			for k, y := range centered[:j+1] {
+				gram.Data[j*n+k] += w * x * y
+			}
+		}
+	}
+	for j := 0; j < n; j++ {
+		gram.Set(j, j, gram.Get(j, j)+reg)
+		for k := 0; k < j; k++ {
+			gram.Set(k, j, gram.Get(j, k))
+		}
+	}
+
+	coeffs = solvePositiveDefinite(gram, rhs)
+	intercept = targetMean
+	for j, c := range coeffs {
+		intercept -= c * featureMeans[j]
+	}
+	return
+}
+
+// topFeatures returns the indices of the count largest
+// coefficients by absolute value, in ascending order.
+func topFeatures(coeffs []float64, count int) []int {
+	sorted := make([]Contribution, len(coeffs))
+	for i, c := range coeffs {
+		sorted[i] = Contribution{Feature: i, Weight: c}
+	}
+	sort.Stable(contributionSorter(sorted))
+	res := make([]int, count)
+	for i, c := range sorted[:count] {
+		res[i] = c.Feature
+	}
+	sort.Ints(res)
+	return res
+}
+
+// weightedR2 computes the weighted coefficient of
+// determination of a linear model.
+func weightedR2(samples []linalg.Vector, targets, weights []float64, features []int,
+	coeffs []float64, intercept float64) float64 {
+	var totalWeight, targetMean float64
+	for i, w := range weights {
+		totalWeight += w
+		targetMean += w * targets[i]
+	}
+	targetMean /= totalWeight
+
+	var residual, total float64
+	for i, sample := range samples {
+		pred := intercept
+		for j, feature := range features {
+			pred += coeffs[j] * sample[feature]
+		}
+		residual += weights[i] * (targets[i] - pred) * (targets[i] - pred)
+		total += weights[i] * (targets[i] - targetMean) * (targets[i] - targetMean)
+	}
+	if total == 0 {
+		if residual == 0 {
+			return 1
+		}
+		return 0
+	}
+	return 1 - residual/total
+}
+
+// solvePositiveDefinite solves m*x = b for a positive
+// definite matrix m using a Cholesky decomposition.
+func solvePositiveDefinite(m *linalg.Matrix, b linalg.Vector) linalg.Vector {
+	n := m.Rows
+	l := linalg.NewMatrix(n, n)
+	for i := 0; i < n; i++ {
+		for j := 0; j <= i; j++ {
+			sum := m.Get(i, j)
+			for k := 0; k < j; k++ {
+				sum -= l.Get(i, k) * l.Get(j, k)
+			}
+			if i == j {
+				l.Set(i, i, math.Sqrt(math.Max(sum, 1e-300)))
+			} else {
+				l.Set(i, j, sum/l.Get(j, j))
+			}
+		}
+	}
+
+	y := make(linalg.Vector, n)
+	for i := 0; i < n; i++ {
+		sum := b[i]
+		for k := 0; k < i; k++ {
+			sum -= l.Get(i, k) * y[k]
+		}
+		y[i] = sum / l.Get(i, i)
+	}
+	x := make(linalg.Vector, n)
+	for i := n - 1; i >= 0; i-- {
+		sum := y[i]
+		for k := i + 1; k < n; k++ {
+			sum -= l.Get(k, i) * x[k]
+		}
+		x[i] = sum / l.Get(i, i)
+	}
+	return x
+}
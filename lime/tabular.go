@@ -0,0 +1,85 @@
+package lime
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/unixpickle/num-analysis/linalg"
+)
+
+// FeatureStddevs computes the standard deviation of each
+// feature in a data set, for use with ExplainTabular.
+func FeatureStddevs(data []linalg.Vector) linalg.Vector {
+	mean := make(linalg.Vector, len(data[0]))
+	for _, x := range data {
+		mean.Add(x)
+	}
+	mean.Scale(1 / float64(len(data)))
+
+	res := make(linalg.Vector, len(mean))
+	for _, x := range data {
+		for i, val := range x {
+			res[i] += (val - mean[i]) * (val - mean[i])
+		}
+	}
+	for i, v := range res {
+		res[i] = math.Sqrt(v / float64(len(data)))
+	}
+	return res
+}
+
+// ExplainTabular explains a model's prediction for a
+// vector of numeric features.
+//
+// Each perturbed input is created by adding Gaussian
+// noise to every feature, where the noise for a feature
+// is scaled by the feature's entry in stddevs.
+// Features with a standard deviation of 0 are never
+// perturbed.
+//
+// The weights in the resulting explanation indicate how
+// much the prediction changes when a feature increases
+// by one standard deviation.
+// The default kernel width is 0.75*sqrt(n), where n is
+// the number of features.
+//
+// If r is nil, this uses the rand package's default
+// generator.
+func (e *Explainer) ExplainTabular(m Model, input, stddevs linalg.Vector,
+	r *rand.Rand) *Explanation {
+	if len(input) != len(stddevs) {
+		panic("input size must match stddevs size")
+	}
+	interp := make([]linalg.Vector, e.numSamples())
+	distances := make([]float64, len(interp))
+	interp[0] = make(linalg.Vector, len(input))
+	for i := 1; i < len(interp); i++ {
+		z := make(linalg.Vector, len(input))
+		for j, s := range stddevs {
+			if s != 0 {
+				z[j] = normFloat64(r)
+			}
+		}
+		interp[i] = z
+		distances[i] = math.Sqrt(z.Dot(z))
+	}
+
+	kernelWidth := 0.75 * math.Sqrt(float64(len(input)))
+	return e.explain(interp, distances, kernelWidth, func(indices []int) []float64 {
+		inputs := make([]linalg.Vector, len(indices))
+		for i, idx := range indices {
+			inputs[i] = input.Copy()
+			for j, z := range interp[idx] {
+				inputs[i][j] += z * stddevs[j]
+			}
+		}
+		return m.Predict(inputs)
+	})
+}
+
+func normFloat64(r *rand.Rand) float64 {
+	if r == nil {
+		return rand.NormFloat64()
+	}
+	return r.NormFloat64()
+}
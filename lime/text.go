@@ -0,0 +1,52 @@
+package lime
+
+import "math/rand"
+
+// ExplainText explains a model's prediction for a
+// tokenized document.
+//
+// The interpretable features are the distinct tokens of
+// the document, in order of first appearance.
+// Perturbed documents are created by removing every
+// occurrence of some of the tokens.
+//
+// The weights in the resulting explanation indicate how
+// much each token contributes to the prediction.
+// The default kernel width is 0.25.
+//
+// If r is nil, this uses the rand package's default
+// generator.
+func (e *Explainer) ExplainText(m TextModel, tokens []string, r *rand.Rand) *Explanation {
+	var vocab []string
+	tokenFeatures := make([]int, len(tokens))
+	vocabIndices := map[string]int{}
+	for i, token := range tokens {
+		idx, ok := vocabIndices[token]
+		if !ok {
+			idx = len(vocab)
+			vocabIndices[token] = idx
+			vocab = append(vocab, token)
+		}
+		tokenFeatures[i] = idx
+	}
+
+	masks, distances := e.sampleMasks(len(vocab), r)
+	res := e.explain(masks, distances, defaultBinaryKernelWidth,
+		func(indices []int) []float64 {
+			docs := make([][]string, len(indices))
+			for i, idx := range indices {
+				doc := []string{}
+				for j, token := range tokens {
+					if masks[idx][tokenFeatures[j]] != 0 {
+						doc = append(doc, token)
+					}
+				}
+				docs[i] = doc
+			}
+			return m.PredictText(docs)
+		})
+	for i, c := range res.Contributions {
+		res.Contributions[i].Name = vocab[c.Feature]
+	}
+	return res
+}
@@ -0,0 +1,160 @@
+package multilabel
+
+import (
+	"math/rand"
+
+	"github.com/unixpickle/num-analysis/linalg"
+)
+
+// A BinaryClassifier computes the probability that a
+// label applies to a feature vector.
+type BinaryClassifier interface {
+	Probability(features linalg.Vector) float64
+}
+
+// A BinaryTrainer trains a BinaryClassifier to predict a
+// single label.
+type BinaryTrainer func(features []linalg.Vector, labels []bool) BinaryClassifier
+
+// A Chain is a classifier chain, which predicts labels
+// one at a time and feeds each prediction into the
+// classifiers for the remaining labels.
+// This allows a Chain to model correlations between
+// labels, unlike independent binary classifiers.
+type Chain struct {
+	// Order lists the labels in the order in which they
+	// are predicted.
+	Order []int
+
+	// Classifiers contains one classifier per label.
+	// The classifier at index i predicts label Order[i],
+	// and its input is the feature vector followed by a
+	// 1 or 0 for each of the labels Order[:i].
+	Classifiers []BinaryClassifier
+}
+
+// TrainChain trains a Chain on labeled samples.
+// Each classifier is trained using the actual values of
+// the labels which come before it in the chain.
+//
+// If order is nil, the labels are predicted in order of
+// their indices.
+func TrainChain(features []linalg.Vector, labels [][]bool, order []int,
+	t BinaryTrainer) *Chain {
+	if len(labels) == 0 {
+		panic("cannot train on zero samples")
+	}
+	if order == nil {
+		order = make([]int, len(labels[0]))
+		for i := range order {
+			order[i] = i
+		}
+	}
+	res := &Chain{Order: append([]int{}, order...)}
+
+	inputs := make([]linalg.Vector, len(features))
+	for i, f := range features {
+		inputs[i] = append(linalg.Vector{}, f...)
+	}
+	for _, label := range order {
+		// Trainers may keep their arguments, so every
+		// classifier gets its own slices.
+		targets := make([]bool, len(labels))
+		for i, sampleLabels := range labels {
+			targets[i] = sampleLabels[label]
+		}
+		res.Classifiers = append(res.Classifiers, t(inputs, targets))
+		nextInputs := make([]linalg.Vector, len(inputs))
+		for i, target := range targets {
+			nextInputs[i] = append(append(linalg.Vector{}, inputs[i]...), boolFeature(target))
+		}
+		inputs = nextInputs
+	}
+	return res
+}
+
+// Probabilities computes the probability of each label,
+// indexed by label.
+// Each probability is conditioned on the chain's own
+// predictions for the labels before it.
+func (c *Chain) Probabilities(features linalg.Vector) []float64 {
+	res := make([]float64, len(c.Order))
+	input := append(make(linalg.Vector, 0, len(features)+len(c.Order)), features...)
+	for i, label := range c.Order {
+		prob := c.Classifiers[i].Probability(input)
+		res[label] = prob
+		input = append(input, boolFeature(prob >= 0.5))
+	}
+	return res
+}
+
+// Classify predicts which labels apply to the features.
+func (c *Chain) Classify(features linalg.Vector) []bool {
+	return thresholdProbs(c.Probabilities(features))
+}
+
+// A ChainEnsemble combines several Chains with different
+// label orders by averaging their probabilities.
+// This reduces the effect of a poorly chosen order.
+type ChainEnsemble []*Chain
+
+// TrainChainEnsemble trains n Chains, each with a random
+// label order.
+//
+// If r is nil, this uses the rand package's default
+// generator.
+func TrainChainEnsemble(n int, features []linalg.Vector, labels [][]bool,
+	t BinaryTrainer, r *rand.Rand) ChainEnsemble {
+	if len(labels) == 0 {
+		panic("cannot train on zero samples")
+	}
+	perm := rand.Perm
+	if r != nil {
+		perm = r.Perm
+	}
+	res := make(ChainEnsemble, n)
+	for i := range res {
+		res[i] = TrainChain(features, labels, perm(len(labels[0])), t)
+	}
+	return res
+}
+
+// Probabilities computes the average of the chains'
+// probabilities for each label.
+func (c ChainEnsemble) Probabilities(features linalg.Vector) []float64 {
+	var res []float64
+	for _, chain := range c {
+		probs := chain.Probabilities(features)
+		if res == nil {
+			res = probs
+		} else {
+			for i, p := range probs {
+				res[i] += p
+			}
+		}
+	}
+	for i := range res {
+		res[i] /= float64(len(c))
+	}
+	return res
+}
+
+// Classify predicts which labels apply to the features.
+func (c ChainEnsemble) Classify(features linalg.Vector) []bool {
+	return thresholdProbs(c.Probabilities(features))
+}
+
+func boolFeature(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func thresholdProbs(probs []float64) []bool {
+	res := make([]bool, len(probs))
+	for i, p := range probs {
+		res[i] = p >= 0.5
+	}
+	return res
+}
@@ -0,0 +1,131 @@
+package multilabel
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/unixpickle/num-analysis/linalg"
+)
+
+type logisticClassifier struct {
+	Weights linalg.Vector
+	Bias    float64
+}
+
+func (l *logisticClassifier) Probability(features linalg.Vector) float64 {
+	return 1 / (1 + math.Exp(-(l.Weights.Dot(features) + l.Bias)))
+}
+
+func trainLogistic(features []linalg.Vector, labels []bool) BinaryClassifier {
+	res := &logisticClassifier{Weights: make(linalg.Vector, len(features[0]))}
+	for iter := 0; iter < 500; iter++ {
+		grad := make(linalg.Vector, len(res.Weights))
+		var biasGrad float64
+		for i, f := range features {
+			diff := res.Probability(f) - boolFeature(labels[i])
+			grad.Add(f.Copy().Scale(diff))
+			biasGrad += diff
+		}
+		scale := -1 / float64(len(features))
+		res.Weights.Add(grad.Scale(scale))
+		res.Bias += biasGrad * scale
+	}
+	return res
+}
+
+// chainTestData generates samples where the last label
+// is the AND of the first two.
+// The AND is not linearly separable in the features, but
+// it is in terms of the other labels.
+func chainTestData(n int) ([]linalg.Vector, [][]bool) {
+	var features []linalg.Vector
+	var labels [][]bool
+	for i := 0; i < n; i++ {
+		f := linalg.Vector{rand.NormFloat64(), rand.NormFloat64()}
+		l0, l1 := f[0] > 0, f[1] > 0
+		features = append(features, f.Scale(5))
+		labels = append(labels, []bool{l0, l1, l0 && l1})
+	}
+	return features, labels
+}
+
+func TestChain(t *testing.T) {
+	features, labels := chainTestData(300)
+	chain := TrainChain(features, labels, nil, trainLogistic)
+
+	testFeatures, testLabels := chainTestData(300)
+	var predicted [][]bool
+	for _, f := range testFeatures {
+		predicted = append(predicted, chain.Classify(f))
+	}
+	if acc := SubsetAccuracy(predicted, testLabels); acc < 0.9 {
+		t.Errorf("subset accuracy too low: %f", acc)
+	}
+
+	// The last label should only be predictable well when
+	// it comes after the labels it depends on.
+	reversed := TrainChain(features, labels, []int{2, 1, 0}, trainLogistic)
+	var chainCorrect, reversedCorrect int
+	for i, f := range testFeatures {
+		if chain.Classify(f)[2] == testLabels[i][2] {
+			chainCorrect++
+		}
+		if reversed.Classify(f)[2] == testLabels[i][2] {
+			reversedCorrect++
+		}
+	}
+	if chainCorrect <= reversedCorrect {
+		t.Errorf("chain got %d correct but reversed chain got %d", chainCorrect,
+			reversedCorrect)
+	}
+}
+
+func TestChainEnsemble(t *testing.T) {
+	features, labels := chainTestData(300)
+	ensemble := TrainChainEnsemble(5, features, labels, trainLogistic, rand.New(rand.NewSource(1)))
+	testFeatures, testLabels := chainTestData(300)
+	var predicted [][]bool
+	for _, f := range testFeatures {
+		probs := ensemble.Probabilities(f)
+		if len(probs) != 3 {
+			t.Fatalf("expected 3 probabilities but got %d", len(probs))
+		}
+		predicted = append(predicted, ensemble.Classify(f))
+	}
+	if loss := HammingLoss(predicted, testLabels); loss > 0.1 {
+		t.Errorf("Hamming loss too high: %f", loss)
+	}
+}
+
+type retainedArgs struct {
+	Features []linalg.Vector
+	Labels   []bool
+}
+
+func (r *retainedArgs) Probability(features linalg.Vector) float64 {
+	return 0.5
+}
+
+func TestChainRetainedArgs(t *testing.T) {
+	features, labels := chainTestData(10)
+	var retained []*retainedArgs
+	chain := TrainChain(features, labels, []int{2, 0, 1},
+		func(f []linalg.Vector, l []bool) BinaryClassifier {
+			res := &retainedArgs{Features: f, Labels: l}
+			retained = append(retained, res)
+			return res
+		})
+	for i, r := range retained {
+		label := chain.Order[i]
+		for j, l := range r.Labels {
+			if l != labels[j][label] {
+				t.Errorf("classifier %d: sample %d has wrong label", i, j)
+			}
+			if len(r.Features[j]) != len(features[j])+i {
+				t.Errorf("classifier %d: sample %d has %d features", i, j,
+					len(r.Features[j]))
+			}
+		}
+	}
+}
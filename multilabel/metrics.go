@@ -0,0 +1,116 @@
+// Package multilabel implements tools for classification
+// problems where each sample may have several labels.
+//
+// Throughout this package, the labels of a sample are
+// represented as a []bool with one entry per label.
+package multilabel
+
+// HammingLoss computes the fraction of labels which are
+// predicted incorrectly, averaged over all samples.
+func HammingLoss(predicted, actual [][]bool) float64 {
+	checkShapes(predicted, actual)
+	var wrong, total int
+	for i, pred := range predicted {
+		for j, p := range pred {
+			if p != actual[i][j] {
+				wrong++
+			}
+			total++
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(wrong) / float64(total)
+}
+
+// SubsetAccuracy computes the fraction of samples for
+// which every label is predicted correctly.
+func SubsetAccuracy(predicted, actual [][]bool) float64 {
+	checkShapes(predicted, actual)
+	if len(predicted) == 0 {
+		return 1
+	}
+	var correct int
+SampleLoop:
+	for i, pred := range predicted {
+		for j, p := range pred {
+			if p != actual[i][j] {
+				continue SampleLoop
+			}
+		}
+		correct++
+	}
+	return float64(correct) / float64(len(predicted))
+}
+
+// MicroF1 computes the F1 score of all the labels
+// together, as if they were one big binary problem.
+//
+// If no labels are predicted or present, the score is 1.
+func MicroF1(predicted, actual [][]bool) float64 {
+	checkShapes(predicted, actual)
+	var counts confusion
+	for i, pred := range predicted {
+		for j, p := range pred {
+			counts.Add(p, actual[i][j])
+		}
+	}
+	return counts.F1()
+}
+
+// MacroF1 computes the F1 score of each label and
+// averages the results.
+//
+// A label which is never predicted or present has an F1
+// score of 1.
+func MacroF1(predicted, actual [][]bool) float64 {
+	checkShapes(predicted, actual)
+	if len(predicted) == 0 || len(predicted[0]) == 0 {
+		return 1
+	}
+	numLabels := len(predicted[0])
+	var sum float64
+	for label := 0; label < numLabels; label++ {
+		var counts confusion
+		for i, pred := range predicted {
+			counts.Add(pred[label], actual[i][label])
+		}
+		sum += counts.F1()
+	}
+	return sum / float64(numLabels)
+}
+
+type confusion struct {
+	TruePos  int
+	FalsePos int
+	FalseNeg int
+}
+
+func (c *confusion) Add(predicted, actual bool) {
+	if predicted && actual {
+		c.TruePos++
+	} else if predicted {
+		c.FalsePos++
+	} else if actual {
+		c.FalseNeg++
+	}
+}
+
+func (c *confusion) F1() float64 {
+	if c.TruePos+c.FalsePos+c.FalseNeg == 0 {
+		return 1
+	}
+	return 2 * float64(c.TruePos) / float64(2*c.TruePos+c.FalsePos+c.FalseNeg)
+}
+
+func checkShapes(predicted, actual [][]bool) {
+	if len(predicted) != len(actual) {
+		panic("predicted and actual sample counts differ")
+	}
+	for i, pred := range predicted {
+		if len(pred) != len(actual[i]) || len(pred) != len(predicted[0]) {
+			panic("inconsistent label counts")
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package multilabel
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	actual := [][]bool{
+		{true, false, true},
+		{false, false, true},
+		{true, true, false},
+		{false, false, false},
+	}
+	predicted := [][]bool{
+		{true, false, true},
+		{true, false, true},
+		{true, false, false},
+		{false, false, false},
+	}
+	if loss := HammingLoss(predicted, actual); math.Abs(loss-2.0/12) > 1e-8 {
+		t.Errorf("expected Hamming loss %f but got %f", 2.0/12, loss)
+	}
+	if acc := SubsetAccuracy(predicted, actual); acc != 0.5 {
+		t.Errorf("expected subset accuracy 0.5 but got %f", acc)
+	}
+
+	// 4 true positives, 1 false positive, 1 false negative.
+	if f1 := MicroF1(predicted, actual); math.Abs(f1-8.0/10) > 1e-8 {
+		t.Errorf("expected micro F1 %f but got %f", 8.0/10, f1)
+	}
+
+	// Per-label F1 scores: 4/5, 0, 1.
+	if f1 := MacroF1(predicted, actual); math.Abs(f1-(0.8+0+1)/3) > 1e-8 {
+		t.Errorf("expected macro F1 %f but got %f", 1.8/3, f1)
+	}
+}
+
+func TestMetricsEmpty(t *testing.T) {
+	none := [][]bool{{false, false}}
+	if f1 := MicroF1(none, none); f1 != 1 {
+		t.Errorf("expected micro F1 1 but got %f", f1)
+	}
+	if f1 := MacroF1(none, none); f1 != 1 {
+		t.Errorf("expected macro F1 1 but got %f", f1)
+	}
+}
@@ -0,0 +1,314 @@
+package neuralnet
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/unixpickle/num-analysis/linalg"
+)
+
+// GenerateGo generates a self-contained Go source file
+// which evaluates a Network.
+//
+// The file declares a function with the given name and
+// the signature func([]float32) []float32, along with
+// unexported helpers and variables for the weights.
+// The generated code only depends on the standard
+// library, so it can be used without this package.
+//
+// The network may contain DenseLayers, ConvLayers,
+// MaxPoolingLayers, SoftmaxLayers, LogSoftmaxLayers,
+// and the activation layers (Sigmoid, ReLU,
+// HyperbolicTangent, and Sin).
+// The inputSize argument specifies the size of the
+// network's input vectors.
+func GenerateGo(n Network, inputSize int, packageName, funcName string) ([]byte, error) {
+	if !token.IsIdentifier(packageName) {
+		return nil, fmt.Errorf("invalid package name: %s", packageName)
+	}
+	if !token.IsIdentifier(funcName) {
+		return nil, fmt.Errorf("invalid function name: %s", funcName)
+	}
+	g := &codeGenerator{
+		prefix:  lowerFirst(funcName),
+		helpers: map[string]bool{},
+		size:    inputSize,
+	}
+	if err := g.addLayers(n); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintln(&buf, "// Code generated by neuralnet.GenerateGo. DO NOT EDIT.")
+	fmt.Fprintln(&buf)
+	fmt.Fprintf(&buf, "package %s\n\n", packageName)
+	if g.helpers["Sigmoid"] || g.helpers["HyperbolicTangent"] || g.helpers["Sin"] ||
+		g.helpers["Softmax"] || g.helpers["LogSoftmax"] {
+		fmt.Fprintln(&buf, `import "math"`)
+		fmt.Fprintln(&buf)
+	}
+
+	fmt.Fprintf(&buf, "// %s evaluates a neural network on an input of size %d.\n",
+		funcName, inputSize)
+	fmt.Fprintf(&buf, "// The result has size %d.\n", g.size)
+	fmt.Fprintf(&buf, "func %s(input []float32) []float32 {\n", funcName)
+	fmt.Fprintf(&buf, "if len(input) != %d {\n", inputSize)
+	fmt.Fprintf(&buf, "panic(\"expected input of size %d\")\n}\n", inputSize)
+	fmt.Fprintln(&buf, "x := append([]float32{}, input...)")
+	buf.Write(g.body.Bytes())
+	fmt.Fprintln(&buf, "return x\n}")
+
+	for _, name := range generatedHelperOrder {
+		if g.helpers[name] {
+			code := strings.Replace(generatedHelpers[name], "PREFIX", g.prefix, -1)
+			fmt.Fprintf(&buf, "\n%s", code)
+		}
+	}
+	buf.Write(g.vars.Bytes())
+
+	return format.Source(buf.Bytes())
+}
+
+type codeGenerator struct {
+	prefix  string
+	helpers map[string]bool
+	size    int
+	varIdx  int
+
+	body bytes.Buffer
+	vars bytes.Buffer
+}
+
+func (c *codeGenerator) addLayers(n Network) error {
+	for i, layer := range n {
+		if err := c.addLayer(layer); err != nil {
+			return fmt.Errorf("layer %d: %s", i, err)
+		}
+	}
+	return nil
+}
+
+func (c *codeGenerator) addLayer(layer Layer) error {
+	switch layer := layer.(type) {
+	case Network:
+		return c.addLayers(layer)
+	case Sigmoid, *Sigmoid:
+		c.call("Sigmoid", "x")
+	case ReLU, *ReLU:
+		c.call("ReLU", "x")
+	case HyperbolicTangent, *HyperbolicTangent:
+		c.call("HyperbolicTangent", "x")
+	case Sin, *Sin:
+		c.call("Sin", "x")
+	case *SoftmaxLayer:
+		temp := layer.Temperature
+		if temp == 0 {
+			temp = 1
+		}
+		c.call("Softmax", "x", formatFloat32(temp))
+	case *LogSoftmaxLayer:
+		c.call("LogSoftmax", "x")
+	case *DenseLayer:
+		if layer.InputCount != c.size {
+			return fmt.Errorf("expected input size %d but got %d", layer.InputCount, c.size)
+		}
+		weights := c.addVar("Weights", layer.Weights.Data.Vector)
+		biases := c.addVar("Biases", layer.Biases.Var.Vector)
+		c.assign("Dense", "x", weights, biases)
+		c.size = layer.OutputCount
+	case *ConvLayer:
+		inSize := layer.InputWidth * layer.InputHeight * layer.InputDepth
+		if inSize != c.size {
+			return fmt.Errorf("expected input size %d but got %d", inSize, c.size)
+		}
+		filters := c.addVar("Filters", layer.FilterVar.Vector)
+		biases := c.addVar("Biases", layer.Biases.Vector)
+		c.assign("Conv", "x", strconv.Itoa(layer.InputWidth),
+			strconv.Itoa(layer.InputHeight), strconv.Itoa(layer.InputDepth),
+			strconv.Itoa(layer.FilterWidth), strconv.Itoa(layer.FilterHeight),
+			strconv.Itoa(layer.Stride), filters, biases)
+		c.size = layer.OutputWidth() * layer.OutputHeight() * layer.OutputDepth()
+	case *MaxPoolingLayer:
+		inSize := layer.InputWidth * layer.InputHeight * layer.InputDepth
+		if inSize != c.size {
+			return fmt.Errorf("expected input size %d but got %d", inSize, c.size)
+		}
+		c.assign("MaxPool", "x", strconv.Itoa(layer.InputWidth),
+			strconv.Itoa(layer.InputHeight), strconv.Itoa(layer.InputDepth),
+			strconv.Itoa(layer.XSpan), strconv.Itoa(layer.YSpan))
+		c.size = layer.OutputWidth() * layer.OutputHeight() * layer.InputDepth
+	default:
+		return fmt.Errorf("cannot generate code for layer of type %T", layer)
+	}
+	return nil
+}
+
+func (c *codeGenerator) call(helper string, args ...string) {
+	c.helpers[helper] = true
+	fmt.Fprintf(&c.body, "%s%s(%s)\n", c.prefix, helper, strings.Join(args, ", "))
+}
+
+func (c *codeGenerator) assign(helper string, args ...string) {
+	c.helpers[helper] = true
+	fmt.Fprintf(&c.body, "x = %s%s(%s)\n", c.prefix, helper, strings.Join(args, ", "))
+}
+
+func (c *codeGenerator) addVar(kind string, data linalg.Vector) string {
+	name := fmt.Sprintf("%s%s%d", c.prefix, kind, c.varIdx)
+	c.varIdx++
+	fmt.Fprintf(&c.vars, "\nvar %s = []float32{", name)
+	for i, x := range data {
+		if i%8 == 0 {
+			c.vars.WriteString("\n")
+		}
+		fmt.Fprintf(&c.vars, "%s, ", formatFloat32(x))
+	}
+	c.vars.WriteString("\n}\n")
+	return name
+}
+
+func formatFloat32(x float64) string {
+	return strconv.FormatFloat(float64(float32(x)), 'g', -1, 32)
+}
+
+func lowerFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToLower(r)) + s[size:]
+}
+
+var generatedHelperOrder = []string{"Dense", "Conv", "MaxPool", "Sigmoid", "ReLU",
+	"HyperbolicTangent", "Sin", "Softmax", "LogSoftmax"}
+
+// generatedHelpers contains the source of the helpers
+// used by generated code.
+// PREFIX is replaced by a prefix for each helper name.
+var generatedHelpers = map[string]string{
+	"Dense": `func PREFIXDense(in, weights, biases []float32) []float32 {
+	out := make([]float32, len(biases))
+	for i, bias := range biases {
+		row := weights[i*len(in) : (i+1)*len(in)]
+		sum := bias
+		for j, x := range in {
+			sum += row[j] * x
+		}
+		out[i] = sum
+	}
+	return out
+}
+`,
+	"Conv": `func PREFIXConv(in []float32, width, height, depth, filterWidth, filterHeight,
+	stride int, filters, biases []float32) []float32 {
+	outWidth := 1 + (width-filterWidth)/stride
+	outHeight := 1 + (height-filterHeight)/stride
+	filterSize := filterWidth * filterHeight * depth
+	rowSize := filterWidth * depth
+	out := make([]float32, 0, outWidth*outHeight*len(biases))
+	for y := 0; y < outHeight; y++ {
+		for x := 0; x < outWidth; x++ {
+			for f, bias := range biases {
+				filter := filters[f*filterSize : (f+1)*filterSize]
+				sum := bias
+				for fy := 0; fy < filterHeight; fy++ {
+					start := ((y*stride+fy)*width + x*stride) * depth
+					row := in[start : start+rowSize]
+					for i, w := range filter[fy*rowSize : (fy+1)*rowSize] {
+						sum += w * row[i]
+					}
+				}
+				out = append(out, sum)
+			}
+		}
+	}
+	return out
+}
+`,
+	"MaxPool": `func PREFIXMaxPool(in []float32, width, height, depth, xSpan, ySpan int) []float32 {
+	outWidth := (width + xSpan - 1) / xSpan
+	outHeight := (height + ySpan - 1) / ySpan
+	out := make([]float32, 0, outWidth*outHeight*depth)
+	for y := 0; y < outHeight; y++ {
+		for x := 0; x < outWidth; x++ {
+			for z := 0; z < depth; z++ {
+				max := in[(y*ySpan*width+x*xSpan)*depth+z]
+				for poolY := y * ySpan; poolY < (y+1)*ySpan && poolY < height; poolY++ {
+					for poolX := x * xSpan; poolX < (x+1)*xSpan && poolX < width; poolX++ {
+						if val := in[(poolY*width+poolX)*depth+z]; val > max {
+							max = val
+						}
+					}
+				}
+				out = append(out, max)
+			}
+		}
+	}
+	return out
+}
+`,
+	"Sigmoid": `func PREFIXSigmoid(v []float32) {
+	for i, x := range v {
+		v[i] = float32(1 / (1 + math.Exp(-float64(x))))
+	}
+}
+`,
+	"ReLU": `func PREFIXReLU(v []float32) {
+	for i, x := range v {
+		if x < 0 {
+			v[i] = 0
+		}
+	}
+}
+`,
+	"HyperbolicTangent": `func PREFIXHyperbolicTangent(v []float32) {
+	for i, x := range v {
+		v[i] = float32(math.Tanh(float64(x)))
+	}
+}
+`,
+	"Sin": `func PREFIXSin(v []float32) {
+	for i, x := range v {
+		v[i] = float32(math.Sin(float64(x)))
+	}
+}
+`,
+	"Softmax": `func PREFIXSoftmax(v []float32, temperature float32) {
+	max := v[0] / temperature
+	for _, x := range v {
+		if x/temperature > max {
+			max = x / temperature
+		}
+	}
+	var sum float64
+	for i, x := range v {
+		exp := math.Exp(float64(x/temperature - max))
+		v[i] = float32(exp)
+		sum += exp
+	}
+	for i, x := range v {
+		v[i] = float32(float64(x) / sum)
+	}
+}
+`,
+	"LogSoftmax": `func PREFIXLogSoftmax(v []float32) {
+	max := v[0]
+	for _, x := range v {
+		if x > max {
+			max = x
+		}
+	}
+	var sum float64
+	for _, x := range v {
+		sum += math.Exp(float64(x - max))
+	}
+	logSum := float32(math.Log(sum)) + max
+	for i, x := range v {
+		v[i] = x - logSum
+	}
+}
+`,
+}
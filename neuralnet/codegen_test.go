@@ -0,0 +1,160 @@
+package neuralnet
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/unixpickle/autofunc"
+	"github.com/unixpickle/num-analysis/linalg"
+)
+
+const codegenTestMain = `package main
+
+import (
+	"encoding/json"
+	"os"
+)
+
+func main() {
+	var inputs [][]float32
+	if err := json.NewDecoder(os.Stdin).Decode(&inputs); err != nil {
+		panic(err)
+	}
+	var outputs [][]float32
+	for _, in := range inputs {
+		outputs = append(outputs, Predict(in))
+	}
+	json.NewEncoder(os.Stdout).Encode(outputs)
+}
+`
+
+func TestGenerateGoDense(t *testing.T) {
+	net := Network{
+		NewDenseLayer(5, 4),
+		&Sigmoid{},
+		NewDenseLayer(4, 6),
+		HyperbolicTangent{},
+		NewDenseLayer(6, 3),
+		&SoftmaxLayer{},
+	}
+	testGeneratedGo(t, net, 5)
+}
+
+func TestGenerateGoConv(t *testing.T) {
+	conv := &ConvLayer{
+		FilterCount:  3,
+		FilterWidth:  2,
+		FilterHeight: 3,
+		Stride:       2,
+		InputWidth:   7,
+		InputHeight:  8,
+		InputDepth:   2,
+	}
+	conv.Randomize()
+	net := Network{
+		conv,
+		&ReLU{},
+		&MaxPoolingLayer{
+			XSpan:       2,
+			YSpan:       2,
+			InputWidth:  conv.OutputWidth(),
+			InputHeight: conv.OutputHeight(),
+			InputDepth:  conv.OutputDepth(),
+		},
+		Network{NewDenseLayer(2*2*3, 4), Sin{}},
+		&LogSoftmaxLayer{},
+	}
+	testGeneratedGo(t, net, 7*8*2)
+}
+
+func TestGenerateGoErrors(t *testing.T) {
+	if _, err := GenerateGo(Network{NewDenseLayer(3, 2)}, 4, "main", "Predict"); err == nil {
+		t.Error("expected error for mismatched size")
+	}
+	if _, err := GenerateGo(Network{&BorderLayer{}}, 0, "main", "Predict"); err == nil {
+		t.Error("expected error for unsupported layer")
+	}
+	if _, err := GenerateGo(Network{}, 3, "main", "not valid"); err == nil {
+		t.Error("expected error for invalid name")
+	}
+}
+
+func testGeneratedGo(t *testing.T, net Network, inSize int) {
+	goPath, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not available")
+	}
+
+	code, err := GenerateGo(net, inSize, "main", "Predict")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "codegen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"go.mod":   "module codegen\n",
+		"model.go": string(code),
+		"main.go":  codegenTestMain,
+	}
+	for name, contents := range files {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var inputs [][]float32
+	var expected []linalg.Vector
+	for i := 0; i < 3; i++ {
+		in := compiledTestVec(inSize)
+		in32 := make([]float32, len(in))
+		for j, x := range in {
+			in32[j] = float32(x)
+			in[j] = float64(in32[j])
+		}
+		inputs = append(inputs, in32)
+		expected = append(expected, net.Apply(&autofunc.Variable{Vector: in}).Output())
+	}
+	inputData, _ := json.Marshal(inputs)
+
+	cmd := exec.Command(goPath, "run", ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=", "GO111MODULE=on")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		stdin.Write(inputData)
+		stdin.Close()
+	}()
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("failed to run generated code: %s", err)
+	}
+	var actual [][]float32
+	if err := json.Unmarshal(output, &actual); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, exp := range expected {
+		if len(actual[i]) != len(exp) {
+			t.Fatalf("expected %d outputs but got %d", len(exp), len(actual[i]))
+		}
+		for j, x := range exp {
+			if math.Abs(float64(actual[i][j])-x) > 1e-4 {
+				t.Errorf("sample %d: expected %v but got %v", i, exp, actual[i])
+				break
+			}
+		}
+	}
+}
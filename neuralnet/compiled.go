@@ -0,0 +1,503 @@
+package neuralnet
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/gonum/blas"
+	"github.com/gonum/blas/blas64"
+	"github.com/unixpickle/num-analysis/linalg"
+)
+
+// A CompiledNetwork is an inference-only form of a
+// Network which evaluates inputs without building
+// autofunc graphs.
+//
+// A CompiledNetwork never allocates memory while it is
+// being evaluated.
+// Instead, every buffer is stored in a Workspace, and
+// each Goroutine which uses the network should create
+// its own Workspace.
+//
+// Dense and convolutional layers are fused with the
+// activation functions that follow them.
+// Dropout and noise layers always behave as they would
+// outside of training.
+type CompiledNetwork struct {
+	inputSize int
+	batchSize int
+	ops       []compiledOp
+}
+
+// Compile creates a CompiledNetwork for inputs of the
+// given size which can evaluate batches of up to
+// batchSize inputs.
+//
+// The parameters of the network are copied, so later
+// changes to the network will not affect the result.
+//
+// An error is returned if the network contains a layer
+// that cannot be compiled, or if the layers' input and
+// output sizes do not line up.
+func Compile(n Network, inputSize, batchSize int) (*CompiledNetwork, error) {
+	if batchSize < 1 {
+		return nil, errors.New("batch size must be positive")
+	}
+	res := &CompiledNetwork{inputSize: inputSize, batchSize: batchSize}
+	size := inputSize
+	if err := res.compileLayers(n, &size); err != nil {
+		return nil, err
+	}
+	if len(res.ops) == 0 {
+		res.ops = append(res.ops, &elemOp{size: size})
+	}
+	return res, nil
+}
+
+// InputSize returns the size of each input vector.
+func (c *CompiledNetwork) InputSize() int {
+	return c.inputSize
+}
+
+// OutputSize returns the size of each output vector.
+func (c *CompiledNetwork) OutputSize() int {
+	return c.ops[len(c.ops)-1].outputSize()
+}
+
+// BatchSize returns the maximum number of inputs in a
+// batch.
+func (c *CompiledNetwork) BatchSize() int {
+	return c.batchSize
+}
+
+// NewWorkspace allocates a Workspace for the network.
+func (c *CompiledNetwork) NewWorkspace() *Workspace {
+	res := &Workspace{
+		network: c,
+		buffers: make([]linalg.Vector, len(c.ops)),
+	}
+	for i, op := range c.ops {
+		res.buffers[i] = make(linalg.Vector, c.batchSize*op.outputSize())
+	}
+	return res
+}
+
+func (c *CompiledNetwork) compileLayers(n Network, size *int) error {
+	for i, layer := range n {
+		if err := c.compileLayer(layer, size); err != nil {
+			return fmt.Errorf("layer %d: %s", i, err)
+		}
+	}
+	return nil
+}
+
+func (c *CompiledNetwork) compileLayer(layer Layer, size *int) error {
+	if act := activationFunc(layer); act != nil {
+		c.addActivation(act, *size)
+		return nil
+	}
+
+	switch layer := layer.(type) {
+	case Network:
+		return c.compileLayers(layer, size)
+	case *DenseLayer:
+		if layer.InputCount != *size {
+			return fmt.Errorf("expected input size %d but got %d", layer.InputCount, *size)
+		}
+		c.ops = append(c.ops, &denseOp{
+			inSize:  layer.InputCount,
+			outSize: layer.OutputCount,
+			weights: layer.Weights.Data.Vector.Copy(),
+			biases:  layer.Biases.Var.Vector.Copy(),
+		})
+		*size = layer.OutputCount
+	case *ConvLayer:
+		inSize := layer.InputWidth * layer.InputHeight * layer.InputDepth
+		if inSize != *size {
+			return fmt.Errorf("expected input size %d but got %d", inSize, *size)
+		}
+		conv := *layer
+		op := &convOp{
+			layer:   &conv,
+			filters: layer.FilterVar.Vector.Copy(),
+			biases:  layer.Biases.Vector.Copy(),
+		}
+		c.ops = append(c.ops, op)
+		*size = op.outputSize()
+	case *MaxPoolingLayer:
+		inSize := layer.InputWidth * layer.InputHeight * layer.InputDepth
+		if inSize != *size {
+			return fmt.Errorf("expected input size %d but got %d", inSize, *size)
+		}
+		pool := *layer
+		op := &poolOp{layer: &pool}
+		c.ops = append(c.ops, op)
+		*size = op.outputSize()
+	case *BorderLayer:
+		inSize := layer.InputWidth * layer.InputHeight * layer.InputDepth
+		if inSize != *size {
+			return fmt.Errorf("expected input size %d but got %d", inSize, *size)
+		}
+		border := *layer
+		op := &borderOp{layer: &border}
+		c.ops = append(c.ops, op)
+		*size = op.outputSize()
+	case *VecRescaleLayer:
+		if len(layer.Biases) != *size || len(layer.Scales) != *size {
+			return fmt.Errorf("expected input size %d but got %d", len(layer.Biases), *size)
+		}
+		c.ops = append(c.ops, &vecRescaleOp{
+			biases: layer.Biases.Copy(),
+			scales: layer.Scales.Copy(),
+		})
+	case *SoftmaxLayer:
+		temp := layer.Temperature
+		if temp == 0 {
+			temp = 1
+		}
+		c.ops = append(c.ops, &softmaxOp{size: *size, temperature: temp})
+	case *LogSoftmaxLayer:
+		c.ops = append(c.ops, &softmaxOp{size: *size, temperature: 1, log: true})
+	case *GaussNoiseLayer:
+		// Noise is only added during training.
+	default:
+		return fmt.Errorf("cannot compile layer of type %T", layer)
+	}
+	return nil
+}
+
+// addActivation fuses an element-wise function into the
+// last op, if possible.
+func (c *CompiledNetwork) addActivation(f func(float64) float64, size int) {
+	if len(c.ops) > 0 {
+		switch op := c.ops[len(c.ops)-1].(type) {
+		case *denseOp:
+			if op.activation == nil {
+				op.activation = f
+				return
+			}
+		case *convOp:
+			if op.activation == nil {
+				op.activation = f
+				return
+			}
+		}
+	}
+	c.ops = append(c.ops, &elemOp{size: size, activation: f})
+}
+
+// A Workspace stores the buffers used to evaluate a
+// CompiledNetwork.
+// A Workspace should not be used by more than one
+// Goroutine at once.
+type Workspace struct {
+	network *CompiledNetwork
+	buffers []linalg.Vector
+}
+
+// Apply evaluates the network on a single input.
+//
+// The result is owned by the Workspace and is only
+// valid until the Workspace is used again.
+func (w *Workspace) Apply(in linalg.Vector) linalg.Vector {
+	return w.Batch(in, 1)
+}
+
+// Batch evaluates the network on n inputs, packed one
+// after another in a single vector.
+// The outputs are packed in the same way.
+//
+// The result is owned by the Workspace and is only
+// valid until the Workspace is used again.
+func (w *Workspace) Batch(in linalg.Vector, n int) linalg.Vector {
+	if n > w.network.batchSize {
+		panic("batch size exceeds compiled batch size")
+	}
+	if len(in) != n*w.network.inputSize {
+		panic("invalid input size")
+	}
+	for i, op := range w.network.ops {
+		out := w.buffers[i][:n*op.outputSize()]
+		op.apply(in, out, n)
+		in = out
+	}
+	return in
+}
+
+// A compiledOp is a single step of a CompiledNetwork.
+type compiledOp interface {
+	outputSize() int
+
+	// apply evaluates the op on a batch of n inputs,
+	// writing the outputs to out.
+	apply(in, out linalg.Vector, n int)
+}
+
+type denseOp struct {
+	inSize     int
+	outSize    int
+	weights    linalg.Vector
+	biases     linalg.Vector
+	activation func(float64) float64
+}
+
+func (d *denseOp) outputSize() int {
+	return d.outSize
+}
+
+func (d *denseOp) apply(in, out linalg.Vector, n int) {
+	inMat := blas64.General{
+		Rows:   n,
+		Cols:   d.inSize,
+		Stride: d.inSize,
+		Data:   in,
+	}
+	weightMat := blas64.General{
+		Rows:   d.outSize,
+		Cols:   d.inSize,
+		Stride: d.inSize,
+		Data:   d.weights,
+	}
+	outMat := blas64.General{
+		Rows:   n,
+		Cols:   d.outSize,
+		Stride: d.outSize,
+		Data:   out,
+	}
+	blas64.Gemm(blas.NoTrans, blas.Trans, 1, inMat, weightMat, 0, outMat)
+	for i := range out {
+		x := out[i] + d.biases[i%d.outSize]
+		if d.activation != nil {
+			x = d.activation(x)
+		}
+		out[i] = x
+	}
+}
+
+type convOp struct {
+	layer      *ConvLayer
+	filters    linalg.Vector
+	biases     linalg.Vector
+	activation func(float64) float64
+}
+
+func (c *convOp) outputSize() int {
+	return c.layer.OutputWidth() * c.layer.OutputHeight() * c.layer.OutputDepth()
+}
+
+func (c *convOp) apply(in, out linalg.Vector, n int) {
+	l := c.layer
+	inSize := l.InputWidth * l.InputHeight * l.InputDepth
+	outSize := c.outputSize()
+	outWidth, outHeight := l.OutputWidth(), l.OutputHeight()
+	filterSize := l.FilterWidth * l.FilterHeight * l.InputDepth
+	rowSize := l.FilterWidth * l.InputDepth
+
+	for i := 0; i < n; i++ {
+		image := in[i*inSize : (i+1)*inSize]
+		outImage := out[i*outSize : (i+1)*outSize]
+		outIdx := 0
+		for y := 0; y < outHeight; y++ {
+			for x := 0; x < outWidth; x++ {
+				for f := 0; f < l.FilterCount; f++ {
+					filter := c.filters[f*filterSize : (f+1)*filterSize]
+					sum := c.biases[f]
+					for fy := 0; fy < l.FilterHeight; fy++ {
+						rowStart := ((y*l.Stride+fy)*l.InputWidth + x*l.Stride) * l.InputDepth
+						imageRow := image[rowStart : rowStart+rowSize]
+						filterRow := filter[fy*rowSize : (fy+1)*rowSize]
+						for j, w := range filterRow {
+							sum += w * imageRow[j]
+						}
+					}
+					if c.activation != nil {
+						sum = c.activation(sum)
+					}
+					outImage[outIdx] = sum
+					outIdx++
+				}
+			}
+		}
+	}
+}
+
+type poolOp struct {
+	layer *MaxPoolingLayer
+}
+
+func (p *poolOp) outputSize() int {
+	return p.layer.OutputWidth() * p.layer.OutputHeight() * p.layer.InputDepth
+}
+
+func (p *poolOp) apply(in, out linalg.Vector, n int) {
+	l := p.layer
+	inSize := l.InputWidth * l.InputHeight * l.InputDepth
+	outSize := p.outputSize()
+	outWidth, outHeight := l.OutputWidth(), l.OutputHeight()
+
+	for i := 0; i < n; i++ {
+		image := in[i*inSize : (i+1)*inSize]
+		outImage := out[i*outSize : (i+1)*outSize]
+		for y := 0; y < outHeight; y++ {
+			maxY := minInt((y+1)*l.YSpan, l.InputHeight)
+			for x := 0; x < outWidth; x++ {
+				maxX := minInt((x+1)*l.XSpan, l.InputWidth)
+				for z := 0; z < l.InputDepth; z++ {
+					max := math.Inf(-1)
+					for poolY := y * l.YSpan; poolY < maxY; poolY++ {
+						for poolX := x * l.XSpan; poolX < maxX; poolX++ {
+							val := image[(poolY*l.InputWidth+poolX)*l.InputDepth+z]
+							if val > max {
+								max = val
+							}
+						}
+					}
+					outImage[(y*outWidth+x)*l.InputDepth+z] = max
+				}
+			}
+		}
+	}
+}
+
+type borderOp struct {
+	layer *BorderLayer
+}
+
+func (b *borderOp) outputSize() int {
+	l := b.layer
+	return (l.InputWidth + l.LeftBorder + l.RightBorder) *
+		(l.InputHeight + l.TopBorder + l.BottomBorder) * l.InputDepth
+}
+
+func (b *borderOp) apply(in, out linalg.Vector, n int) {
+	l := b.layer
+	inSize := l.InputWidth * l.InputHeight * l.InputDepth
+	outSize := b.outputSize()
+	outWidth := l.InputWidth + l.LeftBorder + l.RightBorder
+	rowSize := l.InputWidth * l.InputDepth
+
+	for i := range out {
+		out[i] = 0
+	}
+	for i := 0; i < n; i++ {
+		image := in[i*inSize : (i+1)*inSize]
+		outImage := out[i*outSize : (i+1)*outSize]
+		for y := 0; y < l.InputHeight; y++ {
+			outStart := ((y+l.TopBorder)*outWidth + l.LeftBorder) * l.InputDepth
+			copy(outImage[outStart:outStart+rowSize], image[y*rowSize:(y+1)*rowSize])
+		}
+	}
+}
+
+// An elemOp applies a function to every component of
+// its input.
+// If the function is nil, the input is copied.
+type elemOp struct {
+	size       int
+	activation func(float64) float64
+}
+
+func (e *elemOp) outputSize() int {
+	return e.size
+}
+
+func (e *elemOp) apply(in, out linalg.Vector, n int) {
+	if e.activation == nil {
+		copy(out, in)
+		return
+	}
+	for i, x := range in {
+		out[i] = e.activation(x)
+	}
+}
+
+type vecRescaleOp struct {
+	biases linalg.Vector
+	scales linalg.Vector
+}
+
+func (v *vecRescaleOp) outputSize() int {
+	return len(v.biases)
+}
+
+func (v *vecRescaleOp) apply(in, out linalg.Vector, n int) {
+	size := len(v.biases)
+	for i, x := range in {
+		out[i] = (x + v.biases[i%size]) * v.scales[i%size]
+	}
+}
+
+type softmaxOp struct {
+	size        int
+	temperature float64
+	log         bool
+}
+
+func (s *softmaxOp) outputSize() int {
+	return s.size
+}
+
+func (s *softmaxOp) apply(in, out linalg.Vector, n int) {
+	for i := 0; i < n; i++ {
+		inVec := in[i*s.size : (i+1)*s.size]
+		outVec := out[i*s.size : (i+1)*s.size]
+		max := math.Inf(-1)
+		for _, x := range inVec {
+			max = math.Max(max, x/s.temperature)
+		}
+		var sum float64
+		for j, x := range inVec {
+			outVec[j] = x/s.temperature - max
+			sum += math.Exp(outVec[j])
+		}
+		if s.log {
+			logSum := math.Log(sum)
+			for j := range outVec {
+				outVec[j] -= logSum
+			}
+		} else {
+			for j, x := range outVec {
+				outVec[j] = math.Exp(x) / sum
+			}
+		}
+	}
+}
+
+// activationFunc returns the element-wise function
+// computed by a layer, or nil if the layer is not
+// element-wise.
+func activationFunc(layer Layer) func(float64) float64 {
+	switch layer := layer.(type) {
+	case Sigmoid, *Sigmoid:
+		return func(x float64) float64 {
+			return 1 / (1 + math.Exp(-x))
+		}
+	case ReLU, *ReLU:
+		return func(x float64) float64 {
+			return math.Max(x, 0)
+		}
+	case HyperbolicTangent, *HyperbolicTangent:
+		return math.Tanh
+	case Sin, *Sin:
+		return math.Sin
+	case *RescaleLayer:
+		bias, scale := layer.Bias, layer.Scale
+		return func(x float64) float64 {
+			return (x + bias) * scale
+		}
+	case *DropoutLayer:
+		keep := layer.KeepProbability
+		return func(x float64) float64 {
+			return x * keep
+		}
+	}
+	return nil
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,162 @@
+package neuralnet
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/unixpickle/autofunc"
+	"github.com/unixpickle/num-analysis/linalg"
+)
+
+func TestCompiledDense(t *testing.T) {
+	net := Network{
+		&RescaleLayer{Bias: 0.5, Scale: 2},
+		NewDenseLayer(5, 4),
+		&Sigmoid{},
+		NewDenseLayer(4, 6),
+		HyperbolicTangent{},
+		&DropoutLayer{KeepProbability: 0.5},
+		NewDenseLayer(6, 3),
+		&ReLU{},
+		&GaussNoiseLayer{Stddev: 1},
+		&SoftmaxLayer{Temperature: 2},
+	}
+	testCompiledNetwork(t, net, 5)
+}
+
+func TestCompiledConv(t *testing.T) {
+	conv := &ConvLayer{
+		FilterCount:  3,
+		FilterWidth:  2,
+		FilterHeight: 3,
+		Stride:       2,
+		InputWidth:   7,
+		InputHeight:  8,
+		InputDepth:   2,
+	}
+	conv.Randomize()
+	net := Network{
+		&BorderLayer{
+			InputWidth:   5,
+			InputHeight:  6,
+			InputDepth:   2,
+			LeftBorder:   1,
+			RightBorder:  1,
+			TopBorder:    2,
+			BottomBorder: 0,
+		},
+		conv,
+		Sin{},
+		&MaxPoolingLayer{
+			XSpan:       2,
+			YSpan:       2,
+			InputWidth:  conv.OutputWidth(),
+			InputHeight: conv.OutputHeight(),
+			InputDepth:  conv.OutputDepth(),
+		},
+		&VecRescaleLayer{
+			Biases: compiledTestVec(2 * 2 * 3),
+			Scales: compiledTestVec(2 * 2 * 3),
+		},
+		NewDenseLayer(2*2*3, 4),
+		&LogSoftmaxLayer{},
+	}
+	testCompiledNetwork(t, net, 5*6*2)
+}
+
+func TestCompiledErrors(t *testing.T) {
+	if _, err := Compile(Network{NewDenseLayer(3, 2)}, 4, 1); err == nil {
+		t.Error("expected error for mismatched size")
+	}
+	net := Network{&ResidualLayer{Network: Network{NewDenseLayer(3, 3)}}}
+	if _, err := Compile(net, 3, 1); err == nil {
+		t.Error("expected error for unsupported layer")
+	}
+}
+
+func TestCompiledAllocs(t *testing.T) {
+	net := Network{NewDenseLayer(10, 10), &Sigmoid{}, NewDenseLayer(10, 2),
+		&SoftmaxLayer{}}
+	compiled, err := Compile(net, 10, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws := compiled.NewWorkspace()
+	in := compiledTestVec(30)
+	allocs := testing.AllocsPerRun(100, func() {
+		ws.Batch(in, 3)
+	})
+	if allocs != 0 {
+		t.Errorf("expected 0 allocations but got %f", allocs)
+	}
+}
+
+func TestCompiledConcurrent(t *testing.T) {
+	net := Network{NewDenseLayer(4, 8), &ReLU{}, NewDenseLayer(8, 2)}
+	compiled, err := Compile(net, 4, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ws := compiled.NewWorkspace()
+			for j := 0; j < 100; j++ {
+				in := compiledTestVec(4)
+				expected := net.Apply(&autofunc.Variable{Vector: in}).Output()
+				if !compiledVecsClose(ws.Apply(in), expected) {
+					t.Error("bad output")
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func testCompiledNetwork(t *testing.T, net Network, inSize int) {
+	const batchSize = 3
+	compiled, err := Compile(net, inSize, batchSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws := compiled.NewWorkspace()
+
+	in := compiledTestVec(inSize)
+	expected := net.Apply(&autofunc.Variable{Vector: in}).Output()
+	if actual := ws.Apply(in); !compiledVecsClose(actual, expected) {
+		t.Errorf("expected %v but got %v", expected, actual)
+	}
+
+	for n := 1; n <= batchSize; n++ {
+		in := compiledTestVec(inSize * n)
+		expected := net.BatchLearner().Batch(&autofunc.Variable{Vector: in}, n).Output()
+		if actual := ws.Batch(in, n); !compiledVecsClose(actual, expected) {
+			t.Errorf("batch %d: expected %v but got %v", n, expected, actual)
+		}
+	}
+}
+
+func compiledTestVec(size int) linalg.Vector {
+	res := make(linalg.Vector, size)
+	for i := range res {
+		res[i] = rand.NormFloat64()
+	}
+	return res
+}
+
+func compiledVecsClose(v1, v2 linalg.Vector) bool {
+	if len(v1) != len(v2) {
+		return false
+	}
+	for i, x := range v1 {
+		if math.Abs(x-v2[i]) > 1e-8 {
+			return false
+		}
+	}
+	return true
+}
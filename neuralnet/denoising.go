@@ -0,0 +1,228 @@
+package neuralnet
+
+import (
+	"github.com/unixpickle/autofunc"
+	"github.com/unixpickle/num-analysis/linalg"
+	"github.com/unixpickle/sgd"
+)
+
+// A DenoisingTrainer performs greedy layer-wise
+// pre-training on a Network by training each DenseLayer
+// as a denoising autoencoder.
+//
+// This is the continuous analog of pre-training a DBN
+// with rbm.Trainer.
+type DenoisingTrainer struct {
+	// Corruption is applied to the inputs of each
+	// autoencoder during training, but not to the
+	// targets.
+	// For Gaussian noise, use a GaussNoiseLayer.
+	// For masking noise, use a DropoutLayer.
+	// In either case, the layer's Training field should
+	// be set.
+	// If Corruption is nil, the inputs are not corrupted.
+	Corruption Layer
+
+	// TiedWeights indicates that each decoder should use
+	// the transpose of its encoder's weight matrix rather
+	// than a separate weight matrix.
+	TiedWeights bool
+
+	// CostFunc measures reconstruction error.
+	// The decoders are linear, so SigmoidCECost is a good
+	// choice for inputs between 0 and 1.
+	// If CostFunc is nil, MeanSquaredCost is used.
+	CostFunc CostFunc
+
+	StepSize  float64
+	Epochs    int
+	BatchSize int
+}
+
+// TrainDeep pre-trains the DenseLayers in a Network,
+// modifying them in place.
+//
+// Each DenseLayer is grouped with the non-DenseLayer
+// layers after it (e.g. activation functions) to form
+// an encoder.
+// The first encoder is trained on the inputs, and each
+// following encoder is trained on the codes produced
+// by the previous ones.
+// Layers before the first DenseLayer are applied to
+// the inputs but not trained.
+//
+// The resulting Network can be fine-tuned after adding
+// an output layer.
+func (d *DenoisingTrainer) TrainDeep(n Network, inputs []linalg.Vector) {
+	codes := inputs
+	var encoder Network
+	for _, layer := range n {
+		if _, ok := layer.(*DenseLayer); ok {
+			codes = d.trainEncoder(encoder, codes)
+			encoder = nil
+		}
+		encoder = append(encoder, layer)
+	}
+	d.trainEncoder(encoder, codes)
+}
+
+// Train trains an encoder as a denoising autoencoder.
+// The first layer of the encoder must be a DenseLayer.
+// The encoder's other layers are not trained.
+//
+// It returns the decoder, which maps the encoder's
+// outputs back to its inputs.
+func (d *DenoisingTrainer) Train(encoder Network, inputs []linalg.Vector) autofunc.Func {
+	dense, ok := encoder[0].(*DenseLayer)
+	if !ok {
+		panic("encoder must start with a DenseLayer")
+	}
+
+	var decoder denoisingDecoder
+	if d.TiedWeights {
+		decoder = &tiedDecoder{
+			Encoder: dense,
+			Biases:  &autofunc.Variable{Vector: make(linalg.Vector, dense.InputCount)},
+		}
+	} else {
+		untied := &DenseLayer{
+			InputCount:  dense.OutputCount,
+			OutputCount: dense.InputCount,
+		}
+		untied.Randomize()
+		decoder = untied
+	}
+
+	samples := make(sgd.SliceSampleSet, len(inputs))
+	for i, x := range inputs {
+		samples[i] = x
+	}
+	gradienter := &denoisingGradienter{
+		Trainer: d,
+		Encoder: encoder,
+		Decoder: decoder,
+		Params:  append(dense.Parameters(), decoder.Parameters()...),
+	}
+	sgd.SGD(gradienter, samples, d.StepSize, d.Epochs, d.BatchSize)
+
+	return decoder
+}
+
+// trainEncoder trains the encoder (if it starts with a
+// DenseLayer) and returns the encoder's outputs.
+func (d *DenoisingTrainer) trainEncoder(encoder Network, inputs []linalg.Vector) []linalg.Vector {
+	if len(encoder) == 0 {
+		return inputs
+	}
+	if _, ok := encoder[0].(*DenseLayer); ok {
+		d.Train(encoder, inputs)
+	}
+	codes := make([]linalg.Vector, len(inputs))
+	for i, x := range inputs {
+		codes[i] = encoder.Apply(&autofunc.Variable{Vector: x}).Output()
+	}
+	return codes
+}
+
+type denoisingDecoder interface {
+	sgd.Learner
+	autofunc.Func
+}
+
+type denoisingGradienter struct {
+	Trainer *DenoisingTrainer
+	Encoder Network
+	Decoder denoisingDecoder
+	Params  []*autofunc.Variable
+}
+
+func (d *denoisingGradienter) Gradient(s sgd.SampleSet) autofunc.Gradient {
+	costFunc := d.Trainer.CostFunc
+	if costFunc == nil {
+		costFunc = MeanSquaredCost{}
+	}
+	grad := autofunc.NewGradient(d.Params)
+	for i := 0; i < s.Len(); i++ {
+		input := s.GetSample(i).(linalg.Vector)
+		var corrupted autofunc.Result = &autofunc.Variable{Vector: input}
+		if d.Trainer.Corruption != nil {
+			corrupted = d.Trainer.Corruption.Apply(corrupted)
+		}
+		output := d.Decoder.Apply(d.Encoder.Apply(corrupted))
+		cost := costFunc.Cost(input, output)
+		cost.PropagateGradient(linalg.Vector{1}, grad)
+	}
+	return grad
+}
+
+// A tiedDecoder is a decoder which uses the transpose
+// of an encoder's weight matrix.
+type tiedDecoder struct {
+	Encoder *DenseLayer
+	Biases  *autofunc.Variable
+}
+
+// Parameters returns the decoder's biases, since the
+// weights belong to the encoder.
+func (t *tiedDecoder) Parameters() []*autofunc.Variable {
+	return []*autofunc.Variable{t.Biases}
+}
+
+func (t *tiedDecoder) Apply(in autofunc.Result) autofunc.Result {
+	weights := t.Encoder.Weights
+	out := t.Biases.Vector.Copy()
+	inVec := in.Output()
+	for i, x := range inVec {
+		row := weights.Data.Vector[i*weights.Cols : (i+1)*weights.Cols]
+		out.Add(row.Copy().Scale(x))
+	}
+	return &tiedDecoderResult{
+		OutputVec: out,
+		Input:     in,
+		Decoder:   t,
+	}
+}
+
+type tiedDecoderResult struct {
+	OutputVec linalg.Vector
+	Input     autofunc.Result
+	Decoder   *tiedDecoder
+}
+
+func (t *tiedDecoderResult) Output() linalg.Vector {
+	return t.OutputVec
+}
+
+func (t *tiedDecoderResult) Constant(g autofunc.Gradient) bool {
+	if _, ok := g[t.Decoder.Biases]; ok {
+		return false
+	}
+	if _, ok := g[t.Decoder.Encoder.Weights.Data]; ok {
+		return false
+	}
+	return t.Input.Constant(g)
+}
+
+func (t *tiedDecoderResult) PropagateGradient(upstream linalg.Vector, g autofunc.Gradient) {
+	if biasGrad, ok := g[t.Decoder.Biases]; ok {
+		biasGrad.Add(upstream)
+	}
+
+	weights := t.Decoder.Encoder.Weights
+	inVec := t.Input.Output()
+	if weightGrad, ok := g[weights.Data]; ok {
+		for i, x := range inVec {
+			row := weightGrad[i*weights.Cols : (i+1)*weights.Cols]
+			row.Add(upstream.Copy().Scale(x))
+		}
+	}
+
+	if !t.Input.Constant(g) {
+		downstream := make(linalg.Vector, len(inVec))
+		for i := range downstream {
+			row := weights.Data.Vector[i*weights.Cols : (i+1)*weights.Cols]
+			downstream[i] = row.Dot(upstream)
+		}
+		t.Input.PropagateGradient(downstream, g)
+	}
+}
@@ -0,0 +1,121 @@
+package neuralnet
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/unixpickle/autofunc"
+	"github.com/unixpickle/num-analysis/linalg"
+)
+
+func TestTiedDecoderGradient(t *testing.T) {
+	dense := NewDenseLayer(3, 2)
+	decoder := &tiedDecoder{
+		Encoder: dense,
+		Biases:  &autofunc.Variable{Vector: linalg.Vector{0.5, -0.3, 0.2}},
+	}
+	input := &autofunc.Variable{Vector: linalg.Vector{0.7, -1.1}}
+	upstream := linalg.Vector{0.3, -0.5, 0.9}
+	params := []*autofunc.Variable{dense.Weights.Data, decoder.Biases, input}
+
+	objective := func() float64 {
+		return decoder.Apply(input).Output().Dot(upstream)
+	}
+	grad := autofunc.NewGradient(params)
+	decoder.Apply(input).PropagateGradient(upstream, grad)
+
+	const epsilon = 1e-5
+	for _, param := range params {
+		for i := range param.Vector {
+			old := param.Vector[i]
+			param.Vector[i] = old + epsilon
+			plus := objective()
+			param.Vector[i] = old - epsilon
+			minus := objective()
+			param.Vector[i] = old
+			expected := (plus - minus) / (2 * epsilon)
+			if math.Abs(expected-grad[param][i]) > 1e-6 {
+				t.Errorf("expected partial %f but got %f", expected, grad[param][i])
+			}
+		}
+	}
+}
+
+func TestDenoisingTrainerTied(t *testing.T) {
+	testDenoisingTrainer(t, true)
+}
+
+func TestDenoisingTrainerUntied(t *testing.T) {
+	testDenoisingTrainer(t, false)
+}
+
+func testDenoisingTrainer(t *testing.T, tied bool) {
+	rand.Seed(1337)
+	inputs := lowRankInputs(200)
+	encoder := Network{NewDenseLayer(6, 3), HyperbolicTangent{}}
+	trainer := &DenoisingTrainer{
+		Corruption:  &GaussNoiseLayer{Stddev: 0.05, Training: true},
+		TiedWeights: tied,
+		StepSize:    0.01,
+		Epochs:      200,
+		BatchSize:   10,
+	}
+	decoder := trainer.Train(encoder, inputs)
+
+	var totalError, totalMag float64
+	for _, x := range inputs {
+		code := encoder.Apply(&autofunc.Variable{Vector: x})
+		diff := decoder.Apply(code).Output().Copy().Scale(-1).Add(x)
+		totalError += diff.Dot(diff)
+		totalMag += x.Dot(x)
+	}
+	if totalError > totalMag*0.05 {
+		t.Errorf("reconstruction error %f is too large (input magnitude %f)",
+			totalError, totalMag)
+	}
+}
+
+func TestDenoisingTrainerDeep(t *testing.T) {
+	rand.Seed(1337)
+	inputs := lowRankInputs(50)
+	net := Network{
+		&RescaleLayer{Scale: 0.5},
+		NewDenseLayer(6, 4),
+		Sigmoid{},
+		NewDenseLayer(4, 2),
+		Sigmoid{},
+	}
+	var oldWeights []linalg.Vector
+	for _, i := range []int{1, 3} {
+		oldWeights = append(oldWeights, net[i].(*DenseLayer).Weights.Data.Vector.Copy())
+	}
+	trainer := &DenoisingTrainer{
+		Corruption: &DropoutLayer{KeepProbability: 0.9, Training: true},
+		StepSize:   0.01,
+		Epochs:     5,
+		BatchSize:  10,
+	}
+	trainer.TrainDeep(net, inputs)
+	for i, layerIdx := range []int{1, 3} {
+		newWeights := net[layerIdx].(*DenseLayer).Weights.Data.Vector
+		if newWeights.Copy().Scale(-1).Add(oldWeights[i]).MaxAbs() == 0 {
+			t.Errorf("layer %d was not trained", layerIdx)
+		}
+	}
+}
+
+func lowRankInputs(count int) []linalg.Vector {
+	basis := []linalg.Vector{
+		{1, 0.5, -0.5, 0, 0.3, 0.2},
+		{0, -0.4, 0.2, 1, 0.5, -0.6},
+	}
+	res := make([]linalg.Vector, count)
+	for i := range res {
+		res[i] = make(linalg.Vector, 6)
+		for _, b := range basis {
+			res[i].Add(b.Copy().Scale(rand.Float64()*2 - 1))
+		}
+	}
+	return res
+}
@@ -0,0 +1,183 @@
+package neuralnet
+
+import (
+	"math"
+	"sort"
+
+	"github.com/unixpickle/autofunc"
+	"github.com/unixpickle/num-analysis/linalg"
+	"github.com/unixpickle/sgd"
+)
+
+const defaultMultiLabelThreshold = 0.5
+
+// A MultiLabelClassifier uses a Network to decide which
+// of several labels apply to an input.
+//
+// The network should output one value per label, which
+// is fed through a sigmoid to get the probability of
+// the label.
+// Such a network should be trained with SigmoidCECost.
+type MultiLabelClassifier struct {
+	Network Network
+
+	// Thresholds contains one probability threshold per
+	// label.
+	// A label is predicted if its probability is at least
+	// its threshold.
+	Thresholds []float64
+}
+
+// NewMultiLabelClassifier creates a MultiLabelClassifier
+// with a threshold of 0.5 for every label.
+func NewMultiLabelClassifier(n Network, numLabels int) *MultiLabelClassifier {
+	res := &MultiLabelClassifier{
+		Network:    n,
+		Thresholds: make([]float64, numLabels),
+	}
+	for i := range res.Thresholds {
+		res.Thresholds[i] = defaultMultiLabelThreshold
+	}
+	return res
+}
+
+// MultiLabelSampleSet creates a SampleSet of
+// VectorSamples for training a MultiLabelClassifier.
+// Each target vector has a 1 for every label which
+// applies to the input and a 0 for the rest.
+func MultiLabelSampleSet(inputs []linalg.Vector, labels [][]bool) sgd.SampleSet {
+	outputs := make([]linalg.Vector, len(labels))
+	for i, sampleLabels := range labels {
+		outputs[i] = make(linalg.Vector, len(sampleLabels))
+		for j, label := range sampleLabels {
+			if label {
+				outputs[i][j] = 1
+			}
+		}
+	}
+	return VectorSampleSet(inputs, outputs)
+}
+
+// Gradienter creates a BatchRGradienter which trains the
+// network with SigmoidCECost.
+func (m *MultiLabelClassifier) Gradienter() *BatchRGradienter {
+	return &BatchRGradienter{
+		Learner:  m.Network.BatchLearner(),
+		CostFunc: SigmoidCECost{},
+	}
+}
+
+// Probabilities computes the probability of each label
+// for the input.
+func (m *MultiLabelClassifier) Probabilities(in linalg.Vector) linalg.Vector {
+	out := m.Network.Apply(&autofunc.Variable{Vector: in}).Output()
+	res := make(linalg.Vector, len(out))
+	for i, x := range out {
+		res[i] = 1 / (1 + math.Exp(-x))
+	}
+	return res
+}
+
+// Classify predicts which labels apply to the input.
+func (m *MultiLabelClassifier) Classify(in linalg.Vector) []bool {
+	probs := m.Probabilities(in)
+	res := make([]bool, len(probs))
+	for i, p := range probs {
+		res[i] = p >= m.Thresholds[i]
+	}
+	return res
+}
+
+// TuneThresholds sets each label's threshold to maximize
+// the label's F1 score on validation data.
+func (m *MultiLabelClassifier) TuneThresholds(inputs []linalg.Vector, labels [][]bool) {
+	probs := make([]linalg.Vector, len(inputs))
+	for i, in := range inputs {
+		probs[i] = m.Probabilities(in)
+	}
+	for label := range m.Thresholds {
+		labelProbs := make([]float64, len(inputs))
+		actual := make([]bool, len(inputs))
+		for i, p := range probs {
+			labelProbs[i] = p[label]
+			actual[i] = labels[i][label]
+		}
+		m.Thresholds[label] = bestF1Threshold(labelProbs, actual)
+	}
+}
+
+// bestF1Threshold finds the threshold which maximizes
+// the F1 score of a single label's predictions.
+//
+// Thresholds are placed halfway between consecutive
+// distinct probabilities.
+func bestF1Threshold(probs []float64, actual []bool) float64 {
+	order := make([]int, len(probs))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Sort(&thresholdSorter{order: order, probs: probs})
+
+	var positives int
+	for _, a := range actual {
+		if a {
+			positives++
+		}
+	}
+
+	bestThreshold := defaultMultiLabelThreshold
+	bestF1 := -1.0
+	var truePos, falsePos int
+	upper := 1.0
+	for i := 0; i <= len(order); i++ {
+		if i < len(order) && i > 0 && probs[order[i]] == probs[order[i-1]] {
+			if actual[order[i]] {
+				truePos++
+			} else {
+				falsePos++
+			}
+			continue
+		}
+
+		// Every sample before index i is predicted positive.
+		lower := 0.0
+		if i < len(order) {
+			lower = probs[order[i]]
+		}
+		f1 := 1.0
+		if truePos+falsePos+positives > 0 {
+			f1 = 2 * float64(truePos) / float64(truePos+falsePos+positives)
+		}
+		if f1 > bestF1 {
+			bestF1 = f1
+			bestThreshold = (lower + upper) / 2
+		}
+
+		if i < len(order) {
+			if actual[order[i]] {
+				truePos++
+			} else {
+				falsePos++
+			}
+			upper = lower
+		}
+	}
+	return bestThreshold
+}
+
+type thresholdSorter struct {
+	order []int
+	probs []float64
+}
+
+func (t *thresholdSorter) Len() int {
+	return len(t.order)
+}
+
+func (t *thresholdSorter) Less(i, j int) bool {
+	return t.probs[t.order[i]] > t.probs[t.order[j]]
+}
+
+func (t *thresholdSorter) Swap(i, j int) {
+	t.order[i], t.order[j] = t.order[j], t.order[i]
+}
@@ -0,0 +1,67 @@
+package neuralnet
+
+import (
+	"math"
+	"testing"
+
+	"github.com/unixpickle/autofunc"
+	"github.com/unixpickle/num-analysis/linalg"
+)
+
+func TestBestF1Threshold(t *testing.T) {
+	probs := []float64{0.9, 0.2, 0.4, 0.4, 0.1, 0.7}
+	actual := []bool{true, false, true, true, false, false}
+	threshold := bestF1Threshold(probs, actual)
+	if math.Abs(threshold-0.3) > 1e-8 {
+		t.Errorf("expected threshold 0.3 but got %f", threshold)
+	}
+
+	threshold = bestF1Threshold([]float64{0.3, 0.6}, []bool{false, false})
+	if threshold <= 0.6 {
+		t.Errorf("threshold %f predicts a label which never occurs", threshold)
+	}
+}
+
+func TestMultiLabelClassifier(t *testing.T) {
+	dense := &DenseLayer{
+		InputCount:  2,
+		OutputCount: 2,
+		Weights: &autofunc.LinTran{
+			Data: &autofunc.Variable{Vector: []float64{1, 0, 0, 1}},
+			Rows: 2,
+			Cols: 2,
+		},
+		Biases: &autofunc.LinAdd{Var: &autofunc.Variable{Vector: []float64{0, 0}}},
+	}
+	c := NewMultiLabelClassifier(Network{dense}, 2)
+
+	probs := c.Probabilities(linalg.Vector{0, math.Log(3)})
+	if math.Abs(probs[0]-0.5) > 1e-8 || math.Abs(probs[1]-0.75) > 1e-8 {
+		t.Errorf("unexpected probabilities: %v", probs)
+	}
+
+	// The first label applies when the first input is
+	// greater than 1, not 0.
+	var inputs []linalg.Vector
+	var labels [][]bool
+	for _, x := range []float64{-2, -1, 0, 0.5, 1.5, 2, 3} {
+		inputs = append(inputs, linalg.Vector{x, x})
+		labels = append(labels, []bool{x > 1, x > 0})
+	}
+	c.TuneThresholds(inputs, labels)
+	for i, in := range inputs {
+		pred := c.Classify(in)
+		if pred[0] != labels[i][0] || pred[1] != labels[i][1] {
+			t.Errorf("input %v: expected %v but got %v", in, labels[i], pred)
+		}
+	}
+
+	samples := MultiLabelSampleSet(inputs, labels)
+	if samples.Len() != len(inputs) {
+		t.Fatalf("expected %d samples but got %d", len(inputs), samples.Len())
+	}
+	out := samples.GetSample(3).(VectorSample).Output
+	if len(out) != 2 || out[0] != 0 || out[1] != 1 {
+		t.Errorf("expected target [0 1] but got %v", out)
+	}
+}
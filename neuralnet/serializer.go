@@ -3,24 +3,25 @@ package neuralnet
 import "github.com/unixpickle/serializer"
 
 const (
-	serializerTypePrefix            = "github.com/unixpickle/weakai/neuralnet."
-	serializerTypeHyperbolicTangent = serializerTypePrefix + "HyperbolicTangent"
-	serializerTypeSigmoid           = serializerTypePrefix + "Sigmoid"
-	serializerTypeSin               = serializerTypePrefix + "Sin"
-	serializerTypeBorderLayer       = serializerTypePrefix + "BorderLayer"
-	serializerTypeUnstackLayer      = serializerTypePrefix + "UnstackLayer"
-	serializerTypeConvLayer         = serializerTypePrefix + "ConvLayer"
-	serializerTypeDenseLayer        = serializerTypePrefix + "DenseLayer"
-	serializerTypeMaxPoolingLayer   = serializerTypePrefix + "MaxPoolingLayer"
-	serializerTypeSoftmaxLayer      = serializerTypePrefix + "SoftmaxLayer"
-	serializerTypeLogSoftmaxLayer   = serializerTypePrefix + "LogSoftmaxLayer"
-	serializerTypeNetwork           = serializerTypePrefix + "Network"
-	serializerTypeReLU              = serializerTypePrefix + "ReLU"
-	serializerTypeRescaleLayer      = serializerTypePrefix + "RescaleLayer"
-	serializerTypeDropoutLayer      = serializerTypePrefix + "DropoutLayer"
-	serializerTypeVecRescaleLayer   = serializerTypePrefix + "VecRescaleLayer"
-	serializerTypeGaussNoiseLayer   = serializerTypePrefix + "GaussNoiseLayer"
-	serializerTypeResidualLayer     = serializerTypePrefix + "ResidualLayer"
+	serializerTypePrefix             = "github.com/unixpickle/weakai/neuralnet."
+	serializerTypeHyperbolicTangent  = serializerTypePrefix + "HyperbolicTangent"
+	serializerTypeSigmoid            = serializerTypePrefix + "Sigmoid"
+	serializerTypeSin                = serializerTypePrefix + "Sin"
+	serializerTypeBorderLayer        = serializerTypePrefix + "BorderLayer"
+	serializerTypeUnstackLayer       = serializerTypePrefix + "UnstackLayer"
+	serializerTypeConvLayer          = serializerTypePrefix + "ConvLayer"
+	serializerTypeDenseLayer         = serializerTypePrefix + "DenseLayer"
+	serializerTypeMaxPoolingLayer    = serializerTypePrefix + "MaxPoolingLayer"
+	serializerTypeSoftmaxLayer       = serializerTypePrefix + "SoftmaxLayer"
+	serializerTypeLogSoftmaxLayer    = serializerTypePrefix + "LogSoftmaxLayer"
+	serializerTypeNetwork            = serializerTypePrefix + "Network"
+	serializerTypeReLU               = serializerTypePrefix + "ReLU"
+	serializerTypeRescaleLayer       = serializerTypePrefix + "RescaleLayer"
+	serializerTypeDropoutLayer       = serializerTypePrefix + "DropoutLayer"
+	serializerTypeVecRescaleLayer    = serializerTypePrefix + "VecRescaleLayer"
+	serializerTypeGaussNoiseLayer    = serializerTypePrefix + "GaussNoiseLayer"
+	serializerTypeResidualLayer      = serializerTypePrefix + "ResidualLayer"
+	serializerTypeSpatialTransformer = serializerTypePrefix + "SpatialTransformer"
 )
 
 func init() {
@@ -66,4 +67,6 @@ func init() {
 		DeserializeGaussNoiseLayer)
 	serializer.RegisterTypedDeserializer(serializerTypeResidualLayer,
 		DeserializeResidualLayer)
+	serializer.RegisterTypedDeserializer(serializerTypeSpatialTransformer,
+		DeserializeSpatialTransformer)
 }
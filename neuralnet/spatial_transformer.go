@@ -0,0 +1,393 @@
+package neuralnet
+
+import (
+	"errors"
+	"math"
+
+	"github.com/unixpickle/autofunc"
+	"github.com/unixpickle/num-analysis/linalg"
+	"github.com/unixpickle/serializer"
+)
+
+// affineParamCount is the number of parameters in a 2D
+// affine transformation.
+const affineParamCount = 6
+
+// A SpatialTransformer is a layer which resamples its
+// input tensor according to an affine transformation,
+// as described in Jaderberg et al. (2015).
+//
+// The transformation is computed from the input by the
+// Localization network, which must output six values
+// {a, b, c, d, e, f}.
+// Each point (x, y) in the output is then sampled from
+// the point (a*x+b*y+c, d*x+e*y+f) in the input using
+// bilinear interpolation, where coordinates are
+// normalized so that the edges of each tensor are at
+// -1 and 1.
+// Samples which fall outside of the input are treated
+// as zero.
+//
+// The input and output tensors are laid out like the
+// tensors used by ConvLayer.
+// The output tensor has the same depth as the input.
+type SpatialTransformer struct {
+	InputWidth  int
+	InputHeight int
+	InputDepth  int
+
+	OutputWidth  int
+	OutputHeight int
+
+	Localization Network
+}
+
+// DeserializeSpatialTransformer deserializes a
+// SpatialTransformer.
+func DeserializeSpatialTransformer(d []byte) (*SpatialTransformer, error) {
+	var inW, inH, inD, outW, outH serializer.Int
+	var loc Network
+	err := serializer.DeserializeAny(d, &inW, &inH, &inD, &outW, &outH, &loc)
+	if err != nil {
+		return nil, err
+	}
+	return &SpatialTransformer{
+		InputWidth:   int(inW),
+		InputHeight:  int(inH),
+		InputDepth:   int(inD),
+		OutputWidth:  int(outW),
+		OutputHeight: int(outH),
+		Localization: loc,
+	}, nil
+}
+
+// NewIdentityAffineLayer creates a DenseLayer with six
+// outputs which is initialized to produce the identity
+// transformation regardless of its input.
+// It is meant to be used as the last layer of a
+// SpatialTransformer's localization network.
+func NewIdentityAffineLayer(inputCount int) *DenseLayer {
+	res := &DenseLayer{InputCount: inputCount, OutputCount: affineParamCount}
+	res.Randomize()
+	res.Weights.Data.Vector.Scale(0)
+	copy(res.Biases.Var.Vector, []float64{1, 0, 0, 0, 1, 0})
+	return res
+}
+
+// Apply applies the layer to an input tensor.
+func (s *SpatialTransformer) Apply(in autofunc.Result) autofunc.Result {
+	if len(in.Output()) != s.InputWidth*s.InputHeight*s.InputDepth {
+		panic("unexpected input size")
+	}
+	return autofunc.Pool(in, func(in autofunc.Result) autofunc.Result {
+		transform := s.Localization.Apply(in)
+		if len(transform.Output()) != affineParamCount {
+			panic("localization network must output six values")
+		}
+		return &spatialTransformerResult{
+			Layer:     s,
+			Image:     in,
+			Transform: transform,
+			OutputVec: s.sample(in.Output(), transform.Output()),
+		}
+	})
+}
+
+// ApplyR applies the layer to an input tensor.
+func (s *SpatialTransformer) ApplyR(rv autofunc.RVector, in autofunc.RResult) autofunc.RResult {
+	if len(in.Output()) != s.InputWidth*s.InputHeight*s.InputDepth {
+		panic("unexpected input size")
+	}
+	return autofunc.PoolR(in, func(in autofunc.RResult) autofunc.RResult {
+		transform := s.Localization.ApplyR(rv, in)
+		if len(transform.Output()) != affineParamCount {
+			panic("localization network must output six values")
+		}
+		return &spatialTransformerRResult{
+			Layer:     s,
+			Image:     in,
+			Transform: transform,
+			OutputVec: s.sample(in.Output(), transform.Output()),
+			ROutputVec: s.sampleR(in.Output(), in.ROutput(), transform.Output(),
+				transform.ROutput()),
+		}
+	})
+}
+
+// Parameters returns the parameters of the localization
+// network.
+func (s *SpatialTransformer) Parameters() []*autofunc.Variable {
+	return s.Localization.Parameters()
+}
+
+// SerializerType returns the unique ID used to serialize
+// a SpatialTransformer with the serializer package.
+func (s *SpatialTransformer) SerializerType() string {
+	return serializerTypeSpatialTransformer
+}
+
+// Serialize serializes the layer.
+func (s *SpatialTransformer) Serialize() ([]byte, error) {
+	if s.Localization == nil {
+		return nil, errors.New("missing localization network")
+	}
+	return serializer.SerializeAny(
+		serializer.Int(s.InputWidth),
+		serializer.Int(s.InputHeight),
+		serializer.Int(s.InputDepth),
+		serializer.Int(s.OutputWidth),
+		serializer.Int(s.OutputHeight),
+		s.Localization,
+	)
+}
+
+// A bilinearCorner is one of the four input points used
+// to interpolate an output point.
+type bilinearCorner struct {
+	// Index is the index of the input point, or -1 if
+	// the point is out of bounds.
+	Index int
+
+	// WeightX and WeightY are the interpolation weights,
+	// and DerivX and DerivY are their derivatives with
+	// respect to the input coordinates.
+	WeightX float64
+	WeightY float64
+	DerivX  float64
+	DerivY  float64
+}
+
+// A samplePoint stores information about where an
+// output point was sampled from.
+type samplePoint struct {
+	// OutX and OutY are the normalized output
+	// coordinates.
+	OutX float64
+	OutY float64
+
+	Corners [4]bilinearCorner
+}
+
+// samplePoints computes the sample locations for every
+// output point, in order.
+func (s *SpatialTransformer) samplePoints(transform linalg.Vector) []samplePoint {
+	res := make([]samplePoint, 0, s.OutputWidth*s.OutputHeight)
+	for y := 0; y < s.OutputHeight; y++ {
+		outY := normalizedCoord(y, s.OutputHeight)
+		for x := 0; x < s.OutputWidth; x++ {
+			outX := normalizedCoord(x, s.OutputWidth)
+			srcX := transform[0]*outX + transform[1]*outY + transform[2]
+			srcY := transform[3]*outX + transform[4]*outY + transform[5]
+			pixelX := (srcX + 1) * s.xScale()
+			pixelY := (srcY + 1) * s.yScale()
+			point := samplePoint{OutX: outX, OutY: outY}
+			x0 := int(math.Floor(pixelX))
+			y0 := int(math.Floor(pixelY))
+			for i := range point.Corners {
+				cx := x0 + (i & 1)
+				cy := y0 + (i >> 1)
+				corner := bilinearCorner{
+					Index:   -1,
+					WeightX: 1 - math.Abs(pixelX-float64(cx)),
+					WeightY: 1 - math.Abs(pixelY-float64(cy)),
+					DerivX:  float64(2*(i&1) - 1),
+					DerivY:  float64(2*(i>>1) - 1),
+				}
+				if cx >= 0 && cy >= 0 && cx < s.InputWidth && cy < s.InputHeight {
+					corner.Index = (cy*s.InputWidth + cx) * s.InputDepth
+				}
+				point.Corners[i] = corner
+			}
+			res = append(res, point)
+		}
+	}
+	return res
+}
+
+func (s *SpatialTransformer) sample(image, transform linalg.Vector) linalg.Vector {
+	res := make(linalg.Vector, s.OutputWidth*s.OutputHeight*s.InputDepth)
+	for i, point := range s.samplePoints(transform) {
+		out := res[i*s.InputDepth : (i+1)*s.InputDepth]
+		for _, c := range point.Corners {
+			if c.Index < 0 {
+				continue
+			}
+			weight := c.WeightX * c.WeightY
+			for z := range out {
+				out[z] += weight * image[c.Index+z]
+			}
+		}
+	}
+	return res
+}
+
+func (s *SpatialTransformer) sampleR(image, imageR, transform,
+	transformR linalg.Vector) linalg.Vector {
+	res := make(linalg.Vector, s.OutputWidth*s.OutputHeight*s.InputDepth)
+	for i, point := range s.samplePoints(transform) {
+		pixelXR, pixelYR := s.pixelCoordsR(point, transformR)
+		out := res[i*s.InputDepth : (i+1)*s.InputDepth]
+		for _, c := range point.Corners {
+			if c.Index < 0 {
+				continue
+			}
+			weight := c.WeightX * c.WeightY
+			weightR := c.DerivX*pixelXR*c.WeightY + c.WeightX*c.DerivY*pixelYR
+			for z := range out {
+				out[z] += weight*imageR[c.Index+z] + weightR*image[c.Index+z]
+			}
+		}
+	}
+	return res
+}
+
+// pixelCoordsR computes the derivatives of a point's
+// input pixel coordinates with respect to R.
+func (s *SpatialTransformer) pixelCoordsR(p samplePoint,
+	transformR linalg.Vector) (float64, float64) {
+	x := (transformR[0]*p.OutX + transformR[1]*p.OutY + transformR[2]) * s.xScale()
+	y := (transformR[3]*p.OutX + transformR[4]*p.OutY + transformR[5]) * s.yScale()
+	return x, y
+}
+
+// addTransformGrad back-propagates gradients with
+// respect to a point's input pixel coordinates to the
+// transformation parameters.
+func (s *SpatialTransformer) addTransformGrad(dest linalg.Vector, p samplePoint,
+	gradX, gradY float64) {
+	gradX *= s.xScale()
+	gradY *= s.yScale()
+	dest[0] += gradX * p.OutX
+	dest[1] += gradX * p.OutY
+	dest[2] += gradX
+	dest[3] += gradY * p.OutX
+	dest[4] += gradY * p.OutY
+	dest[5] += gradY
+}
+
+func (s *SpatialTransformer) xScale() float64 {
+	return float64(s.InputWidth-1) / 2
+}
+
+func (s *SpatialTransformer) yScale() float64 {
+	return float64(s.InputHeight-1) / 2
+}
+
+func normalizedCoord(i, size int) float64 {
+	if size == 1 {
+		return 0
+	}
+	return 2*float64(i)/float64(size-1) - 1
+}
+
+type spatialTransformerResult struct {
+	Layer     *SpatialTransformer
+	Image     autofunc.Result
+	Transform autofunc.Result
+	OutputVec linalg.Vector
+}
+
+func (s *spatialTransformerResult) Output() linalg.Vector {
+	return s.OutputVec
+}
+
+func (s *spatialTransformerResult) Constant(g autofunc.Gradient) bool {
+	return s.Image.Constant(g) && s.Transform.Constant(g)
+}
+
+func (s *spatialTransformerResult) PropagateGradient(upstream linalg.Vector,
+	g autofunc.Gradient) {
+	depth := s.Layer.InputDepth
+	image := s.Image.Output()
+	imageGrad := make(linalg.Vector, len(image))
+	transformGrad := make(linalg.Vector, affineParamCount)
+	for i, point := range s.Layer.samplePoints(s.Transform.Output()) {
+		u := upstream[i*depth : (i+1)*depth]
+		var gradX, gradY float64
+		for _, c := range point.Corners {
+			if c.Index < 0 {
+				continue
+			}
+			weight := c.WeightX * c.WeightY
+			for z, x := range u {
+				imageGrad[c.Index+z] += weight * x
+				gradX += x * image[c.Index+z] * c.DerivX * c.WeightY
+				gradY += x * image[c.Index+z] * c.WeightX * c.DerivY
+			}
+		}
+		s.Layer.addTransformGrad(transformGrad, point, gradX, gradY)
+	}
+	if !s.Image.Constant(g) {
+		s.Image.PropagateGradient(imageGrad, g)
+	}
+	if !s.Transform.Constant(g) {
+		s.Transform.PropagateGradient(transformGrad, g)
+	}
+}
+
+type spatialTransformerRResult struct {
+	Layer      *SpatialTransformer
+	Image      autofunc.RResult
+	Transform  autofunc.RResult
+	OutputVec  linalg.Vector
+	ROutputVec linalg.Vector
+}
+
+func (s *spatialTransformerRResult) Output() linalg.Vector {
+	return s.OutputVec
+}
+
+func (s *spatialTransformerRResult) ROutput() linalg.Vector {
+	return s.ROutputVec
+}
+
+func (s *spatialTransformerRResult) Constant(rg autofunc.RGradient, g autofunc.Gradient) bool {
+	return s.Image.Constant(rg, g) && s.Transform.Constant(rg, g)
+}
+
+func (s *spatialTransformerRResult) PropagateRGradient(upstream, upstreamR linalg.Vector,
+	rg autofunc.RGradient, g autofunc.Gradient) {
+	depth := s.Layer.InputDepth
+	image := s.Image.Output()
+	imageR := s.Image.ROutput()
+	transformR := s.Transform.ROutput()
+	imageGrad := make(linalg.Vector, len(image))
+	imageGradR := make(linalg.Vector, len(image))
+	transformGrad := make(linalg.Vector, affineParamCount)
+	transformGradR := make(linalg.Vector, affineParamCount)
+	for i, point := range s.Layer.samplePoints(s.Transform.Output()) {
+		pixelXR, pixelYR := s.Layer.pixelCoordsR(point, transformR)
+		u := upstream[i*depth : (i+1)*depth]
+		uR := upstreamR[i*depth : (i+1)*depth]
+		var gradX, gradY, gradXR, gradYR float64
+		for _, c := range point.Corners {
+			if c.Index < 0 {
+				continue
+			}
+			weight := c.WeightX * c.WeightY
+			weightR := c.DerivX*pixelXR*c.WeightY + c.WeightX*c.DerivY*pixelYR
+			derivX := c.DerivX * c.WeightY
+			derivY := c.WeightX * c.DerivY
+			derivXR := c.DerivX * c.DerivY * pixelYR
+			derivYR := c.DerivX * pixelXR * c.DerivY
+			for z, x := range u {
+				pix := image[c.Index+z]
+				pixR := imageR[c.Index+z]
+				imageGrad[c.Index+z] += weight * x
+				imageGradR[c.Index+z] += weight*uR[z] + weightR*x
+				gradX += x * pix * derivX
+				gradY += x * pix * derivY
+				gradXR += uR[z]*pix*derivX + x*pixR*derivX + x*pix*derivXR
+				gradYR += uR[z]*pix*derivY + x*pixR*derivY + x*pix*derivYR
+			}
+		}
+		s.Layer.addTransformGrad(transformGrad, point, gradX, gradY)
+		s.Layer.addTransformGrad(transformGradR, point, gradXR, gradYR)
+	}
+	if !s.Image.Constant(rg, g) {
+		s.Image.PropagateRGradient(imageGrad, imageGradR, rg, g)
+	}
+	if !s.Transform.Constant(rg, g) {
+		s.Transform.PropagateRGradient(transformGrad, transformGradR, rg, g)
+	}
+}
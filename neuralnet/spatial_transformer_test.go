@@ -0,0 +1,124 @@
+package neuralnet
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/unixpickle/autofunc"
+	"github.com/unixpickle/autofunc/functest"
+	"github.com/unixpickle/num-analysis/linalg"
+	"github.com/unixpickle/serializer"
+)
+
+func TestSpatialTransformerIdentity(t *testing.T) {
+	layer := &SpatialTransformer{
+		InputWidth:   5,
+		InputHeight:  4,
+		InputDepth:   3,
+		OutputWidth:  5,
+		OutputHeight: 4,
+		Localization: Network{NewIdentityAffineLayer(5 * 4 * 3)},
+	}
+	input := make(linalg.Vector, 5*4*3)
+	for i := range input {
+		input[i] = rand.NormFloat64()
+	}
+	output := layer.Apply(&autofunc.Variable{Vector: input}).Output()
+	for i, x := range input {
+		if math.Abs(output[i]-x) > 1e-8 {
+			t.Fatalf("entry %d should be %f but got %f", i, x, output[i])
+		}
+	}
+}
+
+func TestSpatialTransformerFlip(t *testing.T) {
+	loc := NewIdentityAffineLayer(3 * 2)
+	loc.Biases.Var.Vector[0] = -1
+	layer := &SpatialTransformer{
+		InputWidth:   3,
+		InputHeight:  2,
+		InputDepth:   1,
+		OutputWidth:  3,
+		OutputHeight: 2,
+		Localization: Network{loc},
+	}
+	input := linalg.Vector{1, 2, 3, 4, 5, 6}
+	expected := linalg.Vector{3, 2, 1, 6, 5, 4}
+	output := layer.Apply(&autofunc.Variable{Vector: input}).Output()
+	for i, x := range expected {
+		if math.Abs(output[i]-x) > 1e-8 {
+			t.Fatalf("expected %v but got %v", expected, output)
+		}
+	}
+}
+
+func TestSpatialTransformerRProp(t *testing.T) {
+	loc := &DenseLayer{InputCount: 4 * 3 * 2, OutputCount: 6}
+	loc.Randomize()
+	loc.Weights.Data.Vector.Scale(0.1)
+	copy(loc.Biases.Var.Vector, []float64{0.9, 0.2, 0.1, -0.3, 1.1, 0.05})
+	layer := &SpatialTransformer{
+		InputWidth:   4,
+		InputHeight:  3,
+		InputDepth:   2,
+		OutputWidth:  3,
+		OutputHeight: 5,
+		Localization: Network{loc},
+	}
+
+	input := &autofunc.Variable{Vector: make(linalg.Vector, 4*3*2)}
+	for i := range input.Vector {
+		input.Vector[i] = rand.Float64()*2 - 1
+	}
+	vars := append([]*autofunc.Variable{input}, layer.Parameters()...)
+	rVec := autofunc.RVector{}
+	for _, v := range vars {
+		rVec[v] = make(linalg.Vector, len(v.Vector))
+		for i := range rVec[v] {
+			rVec[v][i] = rand.Float64()*2 - 1
+		}
+	}
+
+	funcTest := &functest.RFuncChecker{
+		F:     layer,
+		Vars:  vars,
+		Input: input,
+		RV:    rVec,
+	}
+	funcTest.FullCheck(t)
+}
+
+func TestSpatialTransformerSerialize(t *testing.T) {
+	layer := &SpatialTransformer{
+		InputWidth:   4,
+		InputHeight:  3,
+		InputDepth:   2,
+		OutputWidth:  3,
+		OutputHeight: 5,
+		Localization: Network{NewIdentityAffineLayer(4 * 3 * 2)},
+	}
+	data, err := serializer.SerializeWithType(layer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj, err := serializer.DeserializeWithType(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	layer1, ok := obj.(*SpatialTransformer)
+	if !ok {
+		t.Fatalf("unexpected type: %T", obj)
+	}
+	if layer1.InputWidth != 4 || layer1.InputHeight != 3 || layer1.InputDepth != 2 ||
+		layer1.OutputWidth != 3 || layer1.OutputHeight != 5 {
+		t.Errorf("unexpected dimensions: %+v", layer1)
+	}
+	if len(layer1.Localization) != 1 {
+		t.Fatal("unexpected localization network")
+	}
+	biases := layer1.Localization[0].(*DenseLayer).Biases.Var.Vector
+	if biases[0] != 1 || biases[4] != 1 {
+		t.Errorf("unexpected biases: %v", biases)
+	}
+}
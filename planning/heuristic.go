@@ -0,0 +1,120 @@
+package planning
+
+import "math"
+
+// A Heuristic estimates the cost of reaching the goal
+// from a state.
+// It should return math.Inf(1) if it can prove that
+// the goal is unreachable.
+type Heuristic func(p *Problem, s State) float64
+
+// BlindHeuristic returns 0 for every state, turning
+// A* into uniform-cost search.
+func BlindHeuristic(p *Problem, s State) float64 {
+	return 0
+}
+
+// HMax estimates the cost of a set of facts as the
+// cost of the most expensive fact in the delete
+// relaxation.
+// It is admissible, so A* with HMax finds optimal
+// plans.
+func HMax(p *Problem, s State) float64 {
+	costs, _ := relaxedCosts(p, s, math.Max)
+	return goalCost(p.Goal, costs, math.Max)
+}
+
+// HAdd estimates the cost of a set of facts as the
+// sum of the fact costs in the delete relaxation.
+// It is more informative than HMax, but it is not
+// admissible.
+func HAdd(p *Problem, s State) float64 {
+	costs, _ := relaxedCosts(p, s, sum)
+	return goalCost(p.Goal, costs, sum)
+}
+
+// HFF returns the cost of a relaxed plan, extracted
+// using the best supporters found by HAdd, like the
+// heuristic in the FF planner.
+// It is not admissible.
+func HFF(p *Problem, s State) float64 {
+	costs, supporters := relaxedCosts(p, s, sum)
+	if math.IsInf(goalCost(p.Goal, costs, sum), 1) {
+		return math.Inf(1)
+	}
+
+	used := map[*Action]bool{}
+	visited := map[Fact]bool{}
+	var res float64
+	var achieve func(f Fact)
+	achieve = func(f Fact) {
+		if visited[f] || s[f] {
+			return
+		}
+		visited[f] = true
+		a := supporters[f]
+		for _, pre := range a.Preconditions {
+			achieve(pre)
+		}
+		if !used[a] {
+			used[a] = true
+			res += a.Cost
+		}
+	}
+	for _, g := range p.Goal {
+		achieve(g)
+	}
+	return res
+}
+
+// relaxedCosts computes the cost of achieving each
+// fact in the delete relaxation, combining the costs
+// of preconditions with the agg function.
+// It also finds the cheapest achiever of every fact
+// that is not true in s.
+//
+// Unreachable facts are left out of the cost map.
+func relaxedCosts(p *Problem, s State,
+	agg func(x, y float64) float64) (map[Fact]float64, map[Fact]*Action) {
+	costs := map[Fact]float64{}
+	for f := range s {
+		costs[f] = 0
+	}
+	supporters := map[Fact]*Action{}
+
+	changed := true
+	for changed {
+		changed = false
+		for _, a := range p.Actions {
+			preCost := goalCost(a.Preconditions, costs, agg)
+			if math.IsInf(preCost, 1) {
+				continue
+			}
+			cost := preCost + a.Cost
+			for _, f := range a.Add {
+				if old, ok := costs[f]; !ok || cost < old {
+					costs[f] = cost
+					supporters[f] = a
+					changed = true
+				}
+			}
+		}
+	}
+	return costs, supporters
+}
+
+func goalCost(facts []Fact, costs map[Fact]float64, agg func(x, y float64) float64) float64 {
+	var res float64
+	for _, f := range facts {
+		c, ok := costs[f]
+		if !ok {
+			return math.Inf(1)
+		}
+		res = agg(res, c)
+	}
+	return res
+}
+
+func sum(x, y float64) float64 {
+	return x + y
+}
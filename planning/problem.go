@@ -0,0 +1,136 @@
+// Package planning implements STRIPS-style classical
+// planning with heuristic forward search.
+package planning
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// A Fact is a ground atom, such as "on a b".
+type Fact string
+
+// An Action is a ground STRIPS operator.
+type Action struct {
+	Name string
+
+	// Preconditions must all hold for the action to be
+	// applicable.
+	Preconditions []Fact
+
+	// Add lists the facts made true by the action.
+	Add []Fact
+
+	// Delete lists the facts made false by the action.
+	// Deletes are applied before adds, so a fact in both
+	// lists will be true after the action.
+	Delete []Fact
+
+	// Cost is the non-negative cost of the action.
+	Cost float64
+}
+
+// A State is a set of facts which are true.
+// Facts which are not in the set are false.
+type State map[Fact]bool
+
+// NewState creates a state from a list of facts.
+func NewState(facts []Fact) State {
+	res := State{}
+	for _, f := range facts {
+		res[f] = true
+	}
+	return res
+}
+
+// Satisfies checks if every fact in the list is
+// true in the state.
+func (s State) Satisfies(facts []Fact) bool {
+	for _, f := range facts {
+		if !s[f] {
+			return false
+		}
+	}
+	return true
+}
+
+// Applicable checks if an action's preconditions are
+// satisfied in the state.
+func (s State) Applicable(a *Action) bool {
+	return s.Satisfies(a.Preconditions)
+}
+
+// Apply creates a new state by applying the action.
+// It does not check the action's preconditions.
+func (s State) Apply(a *Action) State {
+	res := State{}
+	for f := range s {
+		res[f] = true
+	}
+	for _, f := range a.Delete {
+		delete(res, f)
+	}
+	for _, f := range a.Add {
+		res[f] = true
+	}
+	return res
+}
+
+// key returns a canonical string for the state.
+func (s State) key() string {
+	facts := make([]string, 0, len(s))
+	for f := range s {
+		facts = append(facts, string(f))
+	}
+	sort.Strings(facts)
+	return strings.Join(facts, "\x00")
+}
+
+// A Problem is a planning problem.
+type Problem struct {
+	Actions []*Action
+	Init    []Fact
+	Goal    []Fact
+}
+
+// A Plan is a sequence of actions.
+type Plan []*Action
+
+// Cost returns the total cost of the plan.
+func (p Plan) Cost() float64 {
+	var res float64
+	for _, a := range p {
+		res += a.Cost
+	}
+	return res
+}
+
+// String returns the action names, one per line.
+func (p Plan) String() string {
+	names := make([]string, len(p))
+	for i, a := range p {
+		names[i] = a.Name
+	}
+	return strings.Join(names, "\n")
+}
+
+// Validate checks that a plan can be executed from
+// the initial state and that it achieves the goal.
+func (p *Problem) Validate(plan Plan) error {
+	state := NewState(p.Init)
+	for i, a := range plan {
+		for _, f := range a.Preconditions {
+			if !state[f] {
+				return fmt.Errorf("step %d (%s): precondition not met: %s", i, a.Name, f)
+			}
+		}
+		state = state.Apply(a)
+	}
+	for _, f := range p.Goal {
+		if !state[f] {
+			return fmt.Errorf("goal not achieved: %s", f)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package planning
+
+import "strings"
+
+// An ActionSchema is a parameterized action, similar
+// to an action in PDDL.
+//
+// Parameters are words starting with "?", and they may
+// appear as words in the facts and the name.
+// For example, a schema with parameters "?x" and "?y"
+// might have the precondition "clear ?y".
+type ActionSchema struct {
+	Name          string
+	Params        []string
+	Preconditions []Fact
+	Add           []Fact
+	Delete        []Fact
+	Cost          float64
+}
+
+// Ground creates one action for every assignment of
+// objects to the schema's parameters.
+//
+// If distinct is true, assignments which bind two
+// parameters to the same object are skipped.
+func (a *ActionSchema) Ground(objects []string, distinct bool) []*Action {
+	var res []*Action
+	binding := map[string]string{}
+	used := map[string]bool{}
+	var recurse func(paramIdx int)
+	recurse = func(paramIdx int) {
+		if paramIdx == len(a.Params) {
+			res = append(res, a.instantiate(binding))
+			return
+		}
+		for _, obj := range objects {
+			if distinct && used[obj] {
+				continue
+			}
+			used[obj] = true
+			binding[a.Params[paramIdx]] = obj
+			recurse(paramIdx + 1)
+			used[obj] = false
+		}
+	}
+	recurse(0)
+	return res
+}
+
+func (a *ActionSchema) instantiate(binding map[string]string) *Action {
+	return &Action{
+		Name:          substituteWords(a.Name, binding),
+		Preconditions: substituteFacts(a.Preconditions, binding),
+		Add:           substituteFacts(a.Add, binding),
+		Delete:        substituteFacts(a.Delete, binding),
+		Cost:          a.Cost,
+	}
+}
+
+func substituteFacts(facts []Fact, binding map[string]string) []Fact {
+	res := make([]Fact, len(facts))
+	for i, f := range facts {
+		res[i] = Fact(substituteWords(string(f), binding))
+	}
+	return res
+}
+
+func substituteWords(s string, binding map[string]string) string {
+	words := strings.Fields(s)
+	for i, w := range words {
+		if val, ok := binding[w]; ok {
+			words[i] = val
+		}
+	}
+	return strings.Join(words, " ")
+}
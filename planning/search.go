@@ -0,0 +1,165 @@
+package planning
+
+import (
+	"container/heap"
+	"errors"
+	"math"
+)
+
+// ErrNoPlan is returned when the search space is
+// exhausted without reaching the goal.
+var ErrNoPlan = errors.New("no plan exists")
+
+// ErrExpansionLimit is returned when a Planner expands
+// too many states.
+var ErrExpansionLimit = errors.New("expansion limit reached")
+
+// A Planner performs best-first forward search
+// through the state space.
+//
+// Nodes are ordered by g + Weight*h, where g is the
+// cost so far and h is the heuristic estimate.
+// With a Weight of 1 and an admissible heuristic, this
+// is A* and the resulting plans are optimal.
+type Planner struct {
+	// Heuristic is the heuristic used to guide the
+	// search.
+	// If it is nil, BlindHeuristic is used.
+	Heuristic Heuristic
+
+	// Weight scales the heuristic.
+	// If it is 0, a weight of 1 is used.
+	Weight float64
+
+	// Greedy, if true, orders nodes only by their
+	// heuristic values, ignoring the cost so far.
+	Greedy bool
+
+	// MaxExpansions is the maximum number of states to
+	// expand before giving up.
+	// If it is 0, there is no limit.
+	MaxExpansions int
+}
+
+// Plan searches for a plan which solves the problem.
+func (p *Planner) Plan(prob *Problem) (Plan, error) {
+	heuristic := p.Heuristic
+	if heuristic == nil {
+		heuristic = BlindHeuristic
+	}
+	weight := p.Weight
+	if weight == 0 {
+		weight = 1
+	}
+
+	start := NewState(prob.Init)
+	startNode := &searchNode{state: start, h: heuristic(prob, start)}
+	if math.IsInf(startNode.h, 1) {
+		return nil, ErrNoPlan
+	}
+	startNode.priority = p.priority(startNode, weight)
+
+	queue := &nodeQueue{startNode}
+	bestCosts := map[string]float64{start.key(): 0}
+	closed := map[string]bool{}
+	var expansions int
+
+	for queue.Len() > 0 {
+		node := heap.Pop(queue).(*searchNode)
+		key := node.state.key()
+		if closed[key] {
+			continue
+		}
+		closed[key] = true
+
+		if node.state.Satisfies(prob.Goal) {
+			return node.plan(), nil
+		}
+
+		expansions++
+		if p.MaxExpansions != 0 && expansions > p.MaxExpansions {
+			return nil, ErrExpansionLimit
+		}
+
+		for _, a := range prob.Actions {
+			if !node.state.Applicable(a) {
+				continue
+			}
+			next := node.state.Apply(a)
+			nextKey := next.key()
+			cost := node.g + a.Cost
+			if old, ok := bestCosts[nextKey]; (ok && old <= cost) || closed[nextKey] {
+				continue
+			}
+			bestCosts[nextKey] = cost
+			child := &searchNode{
+				state:  next,
+				parent: node,
+				action: a,
+				g:      cost,
+				h:      heuristic(prob, next),
+			}
+			if math.IsInf(child.h, 1) {
+				continue
+			}
+			child.priority = p.priority(child, weight)
+			heap.Push(queue, child)
+		}
+	}
+
+	return nil, ErrNoPlan
+}
+
+func (p *Planner) priority(n *searchNode, weight float64) float64 {
+	if p.Greedy {
+		return n.h
+	}
+	return n.g + weight*n.h
+}
+
+type searchNode struct {
+	state    State
+	parent   *searchNode
+	action   *Action
+	g        float64
+	h        float64
+	priority float64
+}
+
+func (s *searchNode) plan() Plan {
+	var res Plan
+	for n := s; n.parent != nil; n = n.parent {
+		res = append(res, n.action)
+	}
+	for i := 0; i < len(res)/2; i++ {
+		res[i], res[len(res)-1-i] = res[len(res)-1-i], res[i]
+	}
+	return res
+}
+
+type nodeQueue []*searchNode
+
+func (n nodeQueue) Len() int {
+	return len(n)
+}
+
+func (n nodeQueue) Less(i, j int) bool {
+	if n[i].priority == n[j].priority {
+		return n[i].h < n[j].h
+	}
+	return n[i].priority < n[j].priority
+}
+
+func (n nodeQueue) Swap(i, j int) {
+	n[i], n[j] = n[j], n[i]
+}
+
+func (n *nodeQueue) Push(x interface{}) {
+	*n = append(*n, x.(*searchNode))
+}
+
+func (n *nodeQueue) Pop() interface{} {
+	res := (*n)[len(*n)-1]
+	*n = (*n)[:len(*n)-1]
+	return res
+}
@@ -0,0 +1,168 @@
+package planning
+
+import (
+	"math"
+	"testing"
+)
+
+func blocksWorld(blocks []string, init, goal []Fact) *Problem {
+	schemas := []*ActionSchema{
+		{
+			Name:          "stack ?x ?y",
+			Params:        []string{"?x", "?y"},
+			Preconditions: []Fact{"holding ?x", "clear ?y"},
+			Add:           []Fact{"on ?x ?y", "clear ?x", "handempty"},
+			Delete:        []Fact{"holding ?x", "clear ?y"},
+			Cost:          1,
+		},
+		{
+			Name:          "unstack ?x ?y",
+			Params:        []string{"?x", "?y"},
+			Preconditions: []Fact{"on ?x ?y", "clear ?x", "handempty"},
+			Add:           []Fact{"holding ?x", "clear ?y"},
+			Delete:        []Fact{"on ?x ?y", "clear ?x", "handempty"},
+			Cost:          1,
+		},
+		{
+			Name:          "pickup ?x",
+			Params:        []string{"?x"},
+			Preconditions: []Fact{"ontable ?x", "clear ?x", "handempty"},
+			Add:           []Fact{"holding ?x"},
+			Delete:        []Fact{"ontable ?x", "clear ?x", "handempty"},
+			Cost:          1,
+		},
+		{
+			Name:          "putdown ?x",
+			Params:        []string{"?x"},
+			Preconditions: []Fact{"holding ?x"},
+			Add:           []Fact{"ontable ?x", "clear ?x", "handempty"},
+			Delete:        []Fact{"holding ?x"},
+			Cost:          1,
+		},
+	}
+	var actions []*Action
+	for _, s := range schemas {
+		actions = append(actions, s.Ground(blocks, true)...)
+	}
+	return &Problem{Actions: actions, Init: init, Goal: goal}
+}
+
+// sussmanProblem creates the Sussman anomaly, whose
+// optimal solution takes 6 steps.
+func sussmanProblem() *Problem {
+	return blocksWorld(
+		[]string{"a", "b", "c"},
+		[]Fact{"on c a", "ontable a", "ontable b", "clear c", "clear b", "handempty"},
+		[]Fact{"on a b", "on b c"},
+	)
+}
+
+func TestGround(t *testing.T) {
+	prob := sussmanProblem()
+	// 6 stack, 6 unstack, 3 pickup, 3 putdown.
+	if len(prob.Actions) != 18 {
+		t.Fatalf("expected 18 actions but got %d", len(prob.Actions))
+	}
+	a := prob.Actions[0]
+	if a.Name != "stack a b" || a.Preconditions[1] != "clear b" {
+		t.Errorf("unexpected action: %+v", a)
+	}
+}
+
+func TestPlannerOptimal(t *testing.T) {
+	prob := sussmanProblem()
+	for _, h := range []Heuristic{BlindHeuristic, HMax} {
+		plan, err := (&Planner{Heuristic: h}).Plan(prob)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := prob.Validate(plan); err != nil {
+			t.Error(err)
+		}
+		if plan.Cost() != 6 {
+			t.Errorf("expected cost 6 but got %f:\n%s", plan.Cost(), plan)
+		}
+	}
+}
+
+func TestPlannerSatisficing(t *testing.T) {
+	blocks := []string{"a", "b", "c", "d", "e"}
+	prob := blocksWorld(
+		blocks,
+		[]Fact{"on a b", "on b c", "on c d", "on d e", "ontable e", "clear a",
+			"handempty"},
+		[]Fact{"on e d", "on d c", "on c b", "on b a"},
+	)
+	planners := []*Planner{
+		{Heuristic: HAdd, Greedy: true},
+		{Heuristic: HFF, Greedy: true},
+		{Heuristic: HFF, Weight: 2},
+	}
+	for i, planner := range planners {
+		plan, err := planner.Plan(prob)
+		if err != nil {
+			t.Errorf("planner %d: %s", i, err)
+			continue
+		}
+		if err := prob.Validate(plan); err != nil {
+			t.Errorf("planner %d: %s", i, err)
+		}
+	}
+}
+
+func TestHeuristics(t *testing.T) {
+	prob := sussmanProblem()
+	init := NewState(prob.Init)
+	// Stacking a on b requires unstack, pickup, stack.
+	if h := HMax(prob, init); h != 3 {
+		t.Errorf("expected HMax 3 but got %f", h)
+	}
+	if h := HAdd(prob, init); h < HMax(prob, init) {
+		t.Errorf("HAdd (%f) should not be less than HMax", h)
+	}
+	if h := HFF(prob, init); h > HAdd(prob, init) {
+		t.Errorf("HFF (%f) should not be more than HAdd", h)
+	}
+	goal := NewState([]Fact{"on a b", "on b c"})
+	for i, h := range []Heuristic{HMax, HAdd, HFF} {
+		if x := h(prob, goal); x != 0 {
+			t.Errorf("heuristic %d: expected 0 at goal but got %f", i, x)
+		}
+	}
+}
+
+func TestUnsolvable(t *testing.T) {
+	prob := sussmanProblem()
+	prob.Goal = append(prob.Goal, "on a a")
+	if h := HFF(prob, NewState(prob.Init)); !math.IsInf(h, 1) {
+		t.Errorf("expected infinite heuristic but got %f", h)
+	}
+	if _, err := (&Planner{Heuristic: HAdd}).Plan(prob); err != ErrNoPlan {
+		t.Errorf("expected ErrNoPlan but got %v", err)
+	}
+	if _, err := (&Planner{MaxExpansions: 2}).Plan(sussmanProblem()); err != ErrExpansionLimit {
+		t.Errorf("expected ErrExpansionLimit but got %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	prob := sussmanProblem()
+	byName := map[string]*Action{}
+	for _, a := range prob.Actions {
+		byName[a.Name] = a
+	}
+	good := Plan{
+		byName["unstack c a"], byName["putdown c"],
+		byName["pickup b"], byName["stack b c"],
+		byName["pickup a"], byName["stack a b"],
+	}
+	if err := prob.Validate(good); err != nil {
+		t.Error(err)
+	}
+	if err := prob.Validate(good[:4]); err == nil {
+		t.Error("expected error for incomplete plan")
+	}
+	if err := prob.Validate(good[1:]); err == nil {
+		t.Error("expected error for inapplicable action")
+	}
+}
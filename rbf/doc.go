@@ -40,4 +40,8 @@
 //         return true
 //     })
 //
+// For streaming data, a RAN can grow a network one
+// sample at a time, adding centers as the samples move
+// into new regions of the input space.
+//
 package rbf
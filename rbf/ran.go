@@ -0,0 +1,258 @@
+package rbf
+
+import (
+	"math"
+
+	"github.com/unixpickle/autofunc"
+	"github.com/unixpickle/num-analysis/linalg"
+	"github.com/unixpickle/weakai/neuralnet"
+)
+
+// A RAN is a Resource-Allocating Network, an RBF network
+// which grows as it learns from a stream of samples, as
+// described in Platt (1991).
+//
+// When a sample is far from every existing center and
+// the network's prediction for it is poor, a new center
+// is added at the sample.
+// Otherwise, the centers and output weights are adapted
+// with a gradient step.
+// Optionally, centers whose contributions stay small for
+// a window of samples are pruned, like in the minimal
+// resource-allocating network (MRAN).
+type RAN struct {
+	// Net is the underlying network.
+	// It is nil until the first center is added.
+	// Centers may be added to or removed from Net after
+	// every call to Learn.
+	Net *Network
+
+	// MaxDist and MinDist bound the distance threshold
+	// for adding a new center.
+	// The threshold starts at MaxDist and decays by a
+	// factor of DistDecay after every sample until it
+	// reaches MinDist.
+	MaxDist   float64
+	MinDist   float64
+	DistDecay float64
+
+	// ErrorThreshold is the minimum prediction error
+	// (as a Euclidean norm) needed to add a new center.
+	ErrorThreshold float64
+
+	// Overlap scales the distance from a new center to
+	// the nearest existing center to get the width of
+	// the new center.
+	Overlap float64
+
+	// StepSize is the step size used to adapt the
+	// centers and output weights.
+	StepSize float64
+
+	// PruneThreshold is the relative contribution below
+	// which a center is considered insignificant.
+	// A center's relative contribution is the magnitude
+	// of its contribution to the output divided by the
+	// largest such magnitude among all the centers.
+	PruneThreshold float64
+
+	// PruneWindow is the number of consecutive samples
+	// for which a center must be insignificant before it
+	// is pruned.
+	// If it is 0, no pruning is performed.
+	PruneWindow int
+
+	steps         int
+	insignificant []int
+}
+
+// Predict applies the network to an input.
+// If no centers have been added yet, it returns nil.
+func (r *RAN) Predict(in linalg.Vector) linalg.Vector {
+	if r.Net == nil {
+		return nil
+	}
+	return r.Net.Apply(&autofunc.Variable{Vector: in}).Output()
+}
+
+// NumCenters returns the current number of centers.
+func (r *RAN) NumCenters() int {
+	if r.Net == nil {
+		return 0
+	}
+	return r.Net.DistLayer.NumCenters()
+}
+
+// Learn updates the network for a new sample.
+// It returns true if a center was added.
+//
+// The first sample always becomes a center.
+func (r *RAN) Learn(in, target linalg.Vector) bool {
+	defer func() {
+		r.steps++
+	}()
+
+	if r.Net == nil {
+		r.addCenter(in, target, r.Overlap*r.MaxDist)
+		return true
+	}
+
+	errVec := r.Predict(in).Scale(-1).Add(target)
+	nearest := math.Inf(1)
+	for i := 0; i < r.NumCenters(); i++ {
+		nearest = math.Min(nearest, r.center(i).Copy().Scale(-1).Add(in).Mag())
+	}
+	if nearest > r.distThreshold() && errVec.Mag() > r.ErrorThreshold {
+		r.addCenter(in, errVec, r.Overlap*nearest)
+		return true
+	}
+
+	r.adapt(in, target)
+	if r.PruneWindow > 0 {
+		r.prune(in)
+	}
+	return false
+}
+
+func (r *RAN) distThreshold() float64 {
+	return math.Max(r.MinDist, r.MaxDist*math.Pow(r.DistDecay, float64(r.steps)))
+}
+
+func (r *RAN) adapt(in, target linalg.Vector) {
+	vars := []*autofunc.Variable{
+		r.Net.DistLayer.centers,
+		r.Net.OutLayer.Weights.Data,
+		r.Net.OutLayer.Biases.Var,
+	}
+	grad := autofunc.NewGradient(vars)
+	out := r.Net.Apply(&autofunc.Variable{Vector: in})
+	upstream := out.Output().Copy().Add(target.Copy().Scale(-1))
+	out.PropagateGradient(upstream, grad)
+	for _, v := range vars {
+		v.Vector.Add(grad[v].Scale(-r.StepSize))
+	}
+}
+
+func (r *RAN) prune(in linalg.Vector) {
+	if len(r.insignificant) != r.NumCenters() {
+		r.insignificant = make([]int, r.NumCenters())
+	}
+	comp := autofunc.ComposedFunc{r.Net.DistLayer, r.Net.ScaleLayer, r.Net.ExpLayer}
+	activations := comp.Apply(&autofunc.Variable{Vector: in}).Output()
+	contribs := make([]float64, len(activations))
+	var maxContrib float64
+	for i, a := range activations {
+		var sum float64
+		for j := 0; j < r.Net.OutLayer.OutputCount; j++ {
+			w := r.Net.OutLayer.Weights.Data.Vector[j*len(activations)+i]
+			sum += w * w
+		}
+		contribs[i] = a * math.Sqrt(sum)
+		maxContrib = math.Max(maxContrib, contribs[i])
+	}
+	for i := len(contribs) - 1; i >= 0; i-- {
+		if maxContrib > 0 && contribs[i]/maxContrib >= r.PruneThreshold {
+			r.insignificant[i] = 0
+			continue
+		}
+		r.insignificant[i]++
+		if r.insignificant[i] >= r.PruneWindow && r.NumCenters() > 1 {
+			r.removeCenter(i)
+		}
+	}
+}
+
+func (r *RAN) center(i int) linalg.Vector {
+	inSize := len(r.Net.DistLayer.centers.Vector) / r.NumCenters()
+	return r.Net.DistLayer.centers.Vector[i*inSize : (i+1)*inSize]
+}
+
+func (r *RAN) addCenter(center, weights linalg.Vector, width float64) {
+	r.insignificant = append(r.insignificant, 0)
+	scale := 1 / (width * width)
+	if r.Net == nil {
+		r.Net = &Network{
+			DistLayer:  NewDistLayer(len(center), 1, 0),
+			ScaleLayer: NewScaleLayer(1, scale),
+			ExpLayer:   &ExpLayer{},
+			OutLayer:   neuralnet.NewDenseLayer(1, len(weights)),
+		}
+		r.Net.DistLayer.SetCenters([]linalg.Vector{center})
+		copy(r.Net.OutLayer.Weights.Data.Vector, weights)
+		r.Net.OutLayer.Biases.Var.Vector.Scale(0)
+		return
+	}
+
+	oldCount := r.NumCenters()
+	dist := r.Net.DistLayer
+	dist.centers.Vector = append(dist.centers.Vector, center...)
+	dist.outputCount++
+
+	r.expandScale(oldCount)
+	scaleVar := r.Net.ScaleLayer.scale
+	scaleVar.Vector = append(scaleVar.Vector, scale)
+
+	r.resizeOutLayer(func(col int) int {
+		return col
+	}, oldCount+1)
+	outWeights := r.Net.OutLayer.Weights.Data.Vector
+	for i, w := range weights {
+		outWeights[i*(oldCount+1)+oldCount] = w
+	}
+}
+
+func (r *RAN) removeCenter(idx int) {
+	r.insignificant = append(r.insignificant[:idx], r.insignificant[idx+1:]...)
+	r.expandScale(r.NumCenters())
+
+	inSize := len(r.center(idx))
+	dist := r.Net.DistLayer
+	dist.centers.Vector = append(dist.centers.Vector[:idx*inSize],
+		dist.centers.Vector[(idx+1)*inSize:]...)
+	dist.outputCount--
+
+	scaleVar := r.Net.ScaleLayer.scale
+	scaleVar.Vector = append(scaleVar.Vector[:idx], scaleVar.Vector[idx+1:]...)
+
+	r.resizeOutLayer(func(col int) int {
+		if col >= idx {
+			return col + 1
+		}
+		return col
+	}, dist.outputCount)
+}
+
+// expandScale turns a shared scale into per-center
+// scales, given the current number of centers.
+func (r *RAN) expandScale(count int) {
+	scaleVar := r.Net.ScaleLayer.scale
+	if len(scaleVar.Vector) == 1 && count > 1 {
+		shared := scaleVar.Vector[0]
+		scaleVar.Vector = make(linalg.Vector, count)
+		for i := range scaleVar.Vector {
+			scaleVar.Vector[i] = shared
+		}
+	}
+}
+
+// resizeOutLayer replaces the output layer with one that
+// has newCount inputs.
+// The srcCol function maps each column of the new weight
+// matrix to a column of the old one.
+// Columns that map past the end of the old matrix are
+// set to zero.
+func (r *RAN) resizeOutLayer(srcCol func(col int) int, newCount int) {
+	old := r.Net.OutLayer
+	res := neuralnet.NewDenseLayer(newCount, old.OutputCount)
+	copy(res.Biases.Var.Vector, old.Biases.Var.Vector)
+	for row := 0; row < old.OutputCount; row++ {
+		for col := 0; col < newCount; col++ {
+			var val float64
+			if src := srcCol(col); src < old.InputCount {
+				val = old.Weights.Data.Vector[row*old.InputCount+src]
+			}
+			res.Weights.Data.Vector[row*newCount+col] = val
+		}
+	}
+	r.Net.OutLayer = res
+}
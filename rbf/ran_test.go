@@ -0,0 +1,112 @@
+package rbf
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/unixpickle/autofunc"
+	"github.com/unixpickle/num-analysis/linalg"
+	"github.com/unixpickle/weakai/neuralnet"
+)
+
+func TestRANShifting(t *testing.T) {
+	r := &RAN{
+		MaxDist:        2,
+		MinDist:        0.2,
+		DistDecay:      0.995,
+		ErrorThreshold: 0.05,
+		Overlap:        0.9,
+		StepSize:       0.05,
+	}
+	for _, region := range [][2]float64{{0, 3}, {3, 6}} {
+		oldCount := r.NumCenters()
+		for i := 0; i < 3000; i++ {
+			x := rand.Float64()*(region[1]-region[0]) + region[0]
+			r.Learn(linalg.Vector{x}, linalg.Vector{math.Sin(x)})
+		}
+		if r.NumCenters() <= oldCount {
+			t.Errorf("region %v: no centers were added", region)
+		}
+		var sqErr float64
+		for i := 0; i < 100; i++ {
+			x := rand.Float64()*(region[1]-region[0]) + region[0]
+			diff := r.Predict(linalg.Vector{x})[0] - math.Sin(x)
+			sqErr += diff * diff
+		}
+		if rms := math.Sqrt(sqErr / 100); rms > 0.1 {
+			t.Errorf("region %v: RMS error is %f with %d centers", region, rms,
+				r.NumCenters())
+		}
+	}
+}
+
+func TestRANPrune(t *testing.T) {
+	r := &RAN{
+		MaxDist:        1,
+		MinDist:        1,
+		DistDecay:      1,
+		ErrorThreshold: 0.01,
+		Overlap:        0.3,
+		PruneThreshold: 0.01,
+		PruneWindow:    5,
+	}
+	r.Learn(linalg.Vector{0}, linalg.Vector{1})
+	r.Learn(linalg.Vector{5}, linalg.Vector{-1})
+	if r.NumCenters() != 2 {
+		t.Fatalf("expected 2 centers but got %d", r.NumCenters())
+	}
+	for i := 0; i < 4; i++ {
+		r.Learn(linalg.Vector{0}, linalg.Vector{1})
+	}
+	if r.NumCenters() != 2 {
+		t.Fatalf("pruned too early")
+	}
+	r.Learn(linalg.Vector{0}, linalg.Vector{1})
+	if r.NumCenters() != 1 {
+		t.Fatalf("expected 1 center but got %d", r.NumCenters())
+	}
+	if out := r.Predict(linalg.Vector{0})[0]; math.Abs(out-1) > 1e-5 {
+		t.Errorf("expected output 1 but got %f", out)
+	}
+}
+
+func TestRANPruneSharedScale(t *testing.T) {
+	net := &Network{
+		DistLayer:  NewDistLayer(1, 3, 0),
+		ScaleLayer: NewScaleLayerShared(0.01),
+		ExpLayer:   &ExpLayer{},
+		OutLayer:   neuralnet.NewDenseLayer(3, 1),
+	}
+	net.DistLayer.SetCenters([]linalg.Vector{{0}, {5}, {10}})
+	copy(net.OutLayer.Weights.Data.Vector, []float64{1, 0, -1})
+	net.OutLayer.Biases.Var.Vector.Scale(0)
+
+	var expected []float64
+	for _, x := range []float64{0, 5, 10} {
+		expected = append(expected, net.Apply(&autofunc.Variable{Vector: linalg.Vector{x}}).Output()[0])
+	}
+
+	r := &RAN{
+		Net:            net,
+		MaxDist:        100,
+		MinDist:        100,
+		DistDecay:      1,
+		PruneThreshold: 0.01,
+		PruneWindow:    1,
+	}
+	r.Learn(linalg.Vector{0}, linalg.Vector{1})
+	if r.NumCenters() != 2 {
+		t.Fatalf("expected 2 centers but got %d", r.NumCenters())
+	}
+	scales := net.ScaleLayer.scale.Vector
+	if len(scales) != 2 || scales[0] != 0.01 || scales[1] != 0.01 {
+		t.Errorf("unexpected scales: %v", scales)
+	}
+	for i, x := range []float64{0, 5, 10} {
+		out := r.Predict(linalg.Vector{x})[0]
+		if math.Abs(out-expected[i]) > 1e-5 {
+			t.Errorf("input %f: expected %f but got %f", x, expected[i], out)
+		}
+	}
+}
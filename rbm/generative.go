@@ -0,0 +1,312 @@
+package rbm
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/unixpickle/num-analysis/kahan"
+	"github.com/unixpickle/num-analysis/linalg"
+)
+
+// A DirectedLayer stores untied recognition and
+// generative connections between a lower layer and an
+// upper layer of binary units.
+type DirectedLayer struct {
+	// RecWeights and RecBiases compute the upper layer
+	// from the lower layer.
+	// RecWeights has one row per upper unit.
+	RecWeights *linalg.Matrix
+	RecBiases  linalg.Vector
+
+	// GenWeights and GenBiases compute the lower layer
+	// from the upper layer.
+	// GenWeights has one row per lower unit.
+	GenWeights *linalg.Matrix
+	GenBiases  linalg.Vector
+}
+
+// ExpectedUpper returns the expected value of the upper
+// layer given the lower layer, using the recognition
+// connections.
+func (d *DirectedLayer) ExpectedUpper(lower []bool) linalg.Vector {
+	return directedExpectation(d.RecWeights, d.RecBiases, lower)
+}
+
+// ExpectedLower returns the expected value of the lower
+// layer given the upper layer, using the generative
+// connections.
+func (d *DirectedLayer) ExpectedLower(upper []bool) linalg.Vector {
+	return directedExpectation(d.GenWeights, d.GenBiases, upper)
+}
+
+// A GenerativeDBN is a Deep Belief Network with untied
+// recognition and generative weights, as described in
+// Hinton, Osindero, and Teh (2006).
+//
+// The top two layers form an undirected associative
+// memory (an RBM), while the lower layers are connected
+// by directed generative connections.
+// Optionally, the visible layer of the top RBM may end
+// with a group of softmax label units, making it
+// possible to generate samples for a given label.
+type GenerativeDBN struct {
+	// Layers contains the directed layers, ordered from
+	// the input layer up.
+	Layers []*DirectedLayer
+
+	// Top is the associative memory.
+	// Its visible units are the upper units of the last
+	// directed layer, followed by LabelCount label units.
+	Top *RBM
+
+	// LabelCount is the number of label units in the
+	// visible layer of Top.
+	LabelCount int
+}
+
+// NewGenerativeDBN creates a GenerativeDBN by untying
+// the weights of a pre-trained DBN.
+// The last RBM in d becomes the associative memory, so
+// its visible layer should include labelCount label
+// units at the end.
+//
+// The resulting GenerativeDBN does not share memory
+// with d.
+func NewGenerativeDBN(d DBN, labelCount int) *GenerativeDBN {
+	if len(d) == 0 {
+		panic("DBN must have at least one layer")
+	}
+	res := &GenerativeDBN{LabelCount: labelCount}
+	for _, layer := range d[:len(d)-1] {
+		res.Layers = append(res.Layers, &DirectedLayer{
+			RecWeights: layer.Weights.Copy(),
+			RecBiases:  layer.HiddenBiases.Copy(),
+			GenWeights: layer.Weights.Transpose(),
+			GenBiases:  layer.VisibleBiases.Copy(),
+		})
+	}
+	top := d[len(d)-1]
+	res.Top = &RBM{
+		Weights:       top.Weights.Copy(),
+		HiddenBiases:  top.HiddenBiases.Copy(),
+		VisibleBiases: top.VisibleBiases.Copy(),
+	}
+	if labelCount > len(top.VisibleBiases) {
+		panic("label count exceeds top RBM visible size")
+	}
+	if len(res.Layers) > 0 {
+		lastLayer := res.Layers[len(res.Layers)-1]
+		if len(lastLayer.RecBiases) != res.topInputSize() {
+			panic("top RBM visible size does not match DBN")
+		}
+	}
+	return res
+}
+
+// Recognize samples a representation of the input at
+// each layer using the recognition connections.
+// The first entry of the result is the input, and the
+// last entry is the input to the associative memory
+// (excluding label units).
+//
+// If r is nil, this uses the rand package's default
+// generator.
+func (g *GenerativeDBN) Recognize(r *rand.Rand, input []bool) [][]bool {
+	res := [][]bool{input}
+	for _, layer := range g.Layers {
+		expected := layer.ExpectedUpper(res[len(res)-1])
+		upper := make([]bool, len(expected))
+		sampleVector(r, upper, expected)
+		res = append(res, upper)
+	}
+	return res
+}
+
+// Sample generates an input vector by running Gibbs
+// sampling in the associative memory and then
+// propagating the result down through the generative
+// connections.
+//
+// If the network has label units, they are sampled
+// freely along with the rest of the associative
+// memory.
+//
+// If r is nil, this uses the rand package's default
+// generator.
+func (g *GenerativeDBN) Sample(r *rand.Rand, gibbsSteps int) []bool {
+	return g.sample(r, -1, gibbsSteps)
+}
+
+// SampleLabel is like Sample, but it clamps the label
+// units of the associative memory to the given label.
+func (g *GenerativeDBN) SampleLabel(r *rand.Rand, label, gibbsSteps int) []bool {
+	if label < 0 || label >= g.LabelCount {
+		panic("label out of range")
+	}
+	return g.sample(r, label, gibbsSteps)
+}
+
+// LabelProbs computes the probability of each label
+// given an input vector.
+//
+// The input is passed up through the recognition
+// connections using expected values (rather than
+// samples), and then the label probabilities are
+// computed exactly from the free energy of the
+// associative memory.
+func (g *GenerativeDBN) LabelProbs(input []bool) linalg.Vector {
+	if g.LabelCount == 0 {
+		panic("network has no label units")
+	}
+	top := boolsToVector(input)
+	for _, layer := range g.Layers {
+		top = weightedInputs(layer.RecWeights, layer.RecBiases, top)
+		mapSigmoid(top)
+	}
+	hiddenIn := weightedInputs(g.Top.Weights, g.Top.HiddenBiases, top)
+
+	labelStart := g.topInputSize()
+	res := make(linalg.Vector, g.LabelCount)
+	for label := range res {
+		col := labelStart + label
+		logProb := g.Top.VisibleBiases[col]
+		for i, x := range hiddenIn {
+			logProb += softplus(x + g.Top.Weights.Get(i, col))
+		}
+		res[label] = logProb
+	}
+	softmax(res)
+	return res
+}
+
+func (g *GenerativeDBN) sample(r *rand.Rand, label, gibbsSteps int) []bool {
+	visible := make([]bool, len(g.Top.VisibleBiases))
+	hidden := make([]bool, len(g.Top.HiddenBiases))
+	labelStart := g.topInputSize()
+	for i := range visible[:labelStart] {
+		visible[i] = randFloat(r) < 0.5
+	}
+	if g.LabelCount > 0 {
+		if label < 0 {
+			visible[labelStart+randIntn(r, g.LabelCount)] = true
+		} else {
+			visible[labelStart+label] = true
+		}
+	}
+
+	for i := 0; i < gibbsSteps; i++ {
+		g.Top.SampleHidden(r, hidden, visible)
+		g.sampleTopVisible(r, visible, hidden, label < 0)
+	}
+
+	lower := visible[:labelStart]
+	for i := len(g.Layers) - 1; i >= 0; i-- {
+		expected := g.Layers[i].ExpectedLower(lower)
+		lower = make([]bool, len(expected))
+		sampleVector(r, lower, expected)
+	}
+	return lower
+}
+
+// sampleTopVisible samples the visible layer of the
+// associative memory, treating the label units as a
+// single softmax unit.
+// If sampleLabels is false, the label units are left
+// unchanged.
+func (g *GenerativeDBN) sampleTopVisible(r *rand.Rand, visible, hidden []bool,
+	sampleLabels bool) {
+	labelStart := g.topInputSize()
+	activations := g.Top.visibleActivations(hidden)
+	expected := activations[:labelStart]
+	mapSigmoid(expected)
+	sampleVector(r, visible[:labelStart], expected)
+	if !sampleLabels || g.LabelCount == 0 {
+		return
+	}
+	labelProbs := activations[labelStart:]
+	softmax(labelProbs)
+	label := sampleIndex(r, labelProbs)
+	for i := range labelProbs {
+		visible[labelStart+i] = (i == label)
+	}
+}
+
+func (g *GenerativeDBN) topInputSize() int {
+	return len(g.Top.VisibleBiases) - g.LabelCount
+}
+
+func directedExpectation(weights *linalg.Matrix, biases linalg.Vector,
+	in []bool) linalg.Vector {
+	result := weightedInputs(weights, biases, boolsToVector(in))
+	mapSigmoid(result)
+	return result
+}
+
+// weightedInputs computes weights*in + biases, ignoring
+// any columns of weights beyond the length of in.
+func weightedInputs(weights *linalg.Matrix, biases, in linalg.Vector) linalg.Vector {
+	result := make(linalg.Vector, weights.Rows)
+	for i := range result {
+		var sum kahan.Summer64
+		for j, x := range in {
+			sum.Add(weights.Get(i, j) * x)
+		}
+		result[i] = sum.Sum() + biases[i]
+	}
+	return result
+}
+
+func boolsToVector(b []bool) linalg.Vector {
+	res := make(linalg.Vector, len(b))
+	for i, x := range b {
+		if x {
+			res[i] = 1
+		}
+	}
+	return res
+}
+
+func softmax(v linalg.Vector) {
+	max := math.Inf(-1)
+	for _, x := range v {
+		max = math.Max(max, x)
+	}
+	var sum float64
+	for i, x := range v {
+		v[i] = math.Exp(x - max)
+		sum += v[i]
+	}
+	v.Scale(1 / sum)
+}
+
+func softplus(x float64) float64 {
+	if x > 30 {
+		return x
+	}
+	return math.Log1p(math.Exp(x))
+}
+
+func sampleIndex(r *rand.Rand, probs linalg.Vector) int {
+	num := randFloat(r)
+	for i, p := range probs {
+		num -= p
+		if num < 0 {
+			return i
+		}
+	}
+	return len(probs) - 1
+}
+
+func randFloat(r *rand.Rand) float64 {
+	if r != nil {
+		return r.Float64()
+	}
+	return rand.Float64()
+}
+
+func randIntn(r *rand.Rand, n int) int {
+	if r != nil {
+		return r.Intn(n)
+	}
+	return rand.Intn(n)
+}
@@ -0,0 +1,104 @@
+package rbm
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestNewGenerativeDBN(t *testing.T) {
+	dbn := DBN{NewRBM(5, 4), NewRBM(6, 3)}
+	for _, layer := range dbn {
+		layer.Randomize(1)
+		for i := range layer.HiddenBiases {
+			layer.HiddenBiases[i] = rand.NormFloat64()
+		}
+		for i := range layer.VisibleBiases {
+			layer.VisibleBiases[i] = rand.NormFloat64()
+		}
+	}
+	g := NewGenerativeDBN(dbn, 2)
+
+	lower := []bool{true, false, true, true, false}
+	upper := []bool{false, true, true, false}
+	pairs := [][2][]float64{
+		{g.Layers[0].ExpectedUpper(lower), dbn[0].ExpectedHidden(lower)},
+		{g.Layers[0].ExpectedLower(upper), dbn[0].ExpectedVisible(upper)},
+	}
+	for i, pair := range pairs {
+		for j, x := range pair[0] {
+			if math.Abs(x-pair[1][j]) > 1e-8 {
+				t.Errorf("pair %d: expected %v but got %v", i, pair[1], pair[0])
+				break
+			}
+		}
+	}
+
+	g.Layers[0].GenWeights.Set(0, 0, 1337)
+	if dbn[0].Weights.Get(0, 0) == 1337 {
+		t.Error("generative weights share memory with DBN")
+	}
+}
+
+func TestGenerativeDBNLabels(t *testing.T) {
+	prototypes := [][]bool{
+		{true, true, true, true, true, false, false, false, false, false},
+		{false, false, false, false, false, true, true, true, true, true},
+	}
+	var inputs [][]bool
+	var labels []int
+	for i := 0; i < 100; i++ {
+		label := i % 2
+		input := append([]bool{}, prototypes[label]...)
+		if idx := rand.Intn(len(input) * 2); idx < len(input) {
+			input[idx] = !input[idx]
+		}
+		inputs = append(inputs, input)
+		labels = append(labels, label)
+	}
+
+	dbn := DBN{NewRBM(10, 16), NewRBM(18, 32)}
+	for _, layer := range dbn {
+		layer.Randomize(0.1)
+	}
+	trainer := &Trainer{
+		GibbsSteps: 5,
+		StepSize:   0.05,
+		Epochs:     50,
+		BatchSize:  10,
+	}
+	trainer.TrainDeepLabeled(dbn, inputs, labels, 2)
+
+	g := NewGenerativeDBN(dbn, 2)
+	upDown := &UpDownTrainer{
+		GibbsSteps: 3,
+		StepSize:   0.02,
+		Epochs:     200,
+	}
+	upDown.Train(g, inputs, labels)
+
+	for label, proto := range prototypes {
+		probs := g.LabelProbs(proto)
+		if probs[label] < 0.5 {
+			t.Errorf("label %d: bad label probabilities %v", label, probs)
+		}
+
+		var matches, total int
+		for i := 0; i < 100; i++ {
+			sample := g.SampleLabel(nil, label, 200)
+			for j, x := range sample {
+				if x == proto[j] {
+					matches++
+				}
+				total++
+			}
+		}
+		if frac := float64(matches) / float64(total); frac < 0.6 {
+			t.Errorf("label %d: samples only match prototype %.2f of the time", label, frac)
+		}
+	}
+
+	if len(g.Sample(nil, 20)) != len(prototypes[0]) {
+		t.Error("unexpected sample length")
+	}
+}
@@ -63,20 +63,8 @@ func (r *RBM) SampleHidden(ra *rand.Rand, output, visibleValues []bool) {
 // ExpectedVisible returns the expected value of
 // the visible layer given a hidden vector.
 func (r *RBM) ExpectedVisible(hidden []bool) linalg.Vector {
-	result := make(linalg.Vector, len(r.VisibleBiases))
-	for i := range result {
-		var sum kahan.Summer64
-		for j, h := range hidden {
-			if h {
-				sum.Add(r.Weights.Get(j, i))
-			}
-		}
-		result[i] = sum.Sum()
-	}
-
-	result.Add(r.VisibleBiases)
+	result := r.visibleActivations(hidden)
 	mapSigmoid(result)
-
 	return result
 }
 
@@ -100,6 +88,23 @@ func (r *RBM) ExpectedHidden(visible []bool) linalg.Vector {
 	return result
 }
 
+// visibleActivations computes the input to each
+// visible unit's sigmoid given a hidden vector.
+func (r *RBM) visibleActivations(hidden []bool) linalg.Vector {
+	result := make(linalg.Vector, len(r.VisibleBiases))
+	for i := range result {
+		var sum kahan.Summer64
+		for j, h := range hidden {
+			if h {
+				sum.Add(r.Weights.Get(j, i))
+			}
+		}
+		result[i] = sum.Sum()
+	}
+	result.Add(r.VisibleBiases)
+	return result
+}
+
 func mapSigmoid(v linalg.Vector) {
 	for i, x := range v {
 		e := math.Exp(x)
@@ -75,3 +75,32 @@ func (t *Trainer) TrainDeep(layers DBN, inputs [][]bool) {
 		layerInputs = newInputs
 	}
 }
+
+// TrainDeepLabeled is like TrainDeep, but it appends a
+// one-hot label vector to the input of the last layer.
+// This pre-trains a DBN which can be passed to
+// NewGenerativeDBN with labelCount label units.
+func (t *Trainer) TrainDeepLabeled(layers DBN, inputs [][]bool, labels []int,
+	labelCount int) {
+	if len(layers) == 0 {
+		panic("DBN must have at least one layer")
+	}
+	if len(labels) != len(inputs) {
+		panic("label count must match input count")
+	}
+	if len(layers) > 1 {
+		t.TrainDeep(layers[:len(layers)-1], inputs)
+	}
+	topInputs := make([][]bool, len(inputs))
+	for i, input := range inputs {
+		for _, layer := range layers[:len(layers)-1] {
+			output := make([]bool, len(layer.HiddenBiases))
+			layer.SampleHidden(nil, output, input)
+			input = output
+		}
+		topInputs[i] = make([]bool, len(input)+labelCount)
+		copy(topInputs[i], input)
+		topInputs[i][len(input)+labels[i]] = true
+	}
+	t.Train(layers[len(layers)-1], topInputs)
+}
@@ -0,0 +1,128 @@
+package rbm
+
+import (
+	"math/rand"
+
+	"github.com/unixpickle/num-analysis/linalg"
+)
+
+// An UpDownTrainer fine-tunes a GenerativeDBN using the
+// up-down variant of the wake-sleep algorithm from
+// Hinton, Osindero, and Teh (2006).
+//
+// During the up pass, the recognition connections are
+// used to sample states for every layer, and the
+// generative connections are adjusted to reconstruct
+// each layer from the layer above it.
+// The associative memory is then trained with
+// contrastive divergence, starting from the sampled
+// top-level state.
+// During the down pass, the generative connections
+// are used to sample states starting from the result
+// of the Gibbs sampling, and the recognition
+// connections are adjusted to recognize them.
+type UpDownTrainer struct {
+	GibbsSteps int
+	StepSize   float64
+	Epochs     int
+}
+
+// Train fine-tunes the network on the given inputs.
+//
+// If the network has label units, labels must contain
+// one label per input.
+// Otherwise, labels should be nil.
+func (u *UpDownTrainer) Train(g *GenerativeDBN, inputs [][]bool, labels []int) {
+	if g.LabelCount > 0 && len(labels) != len(inputs) {
+		panic("label count must match input count")
+	}
+	for i := 0; i < u.Epochs; i++ {
+		for _, j := range rand.Perm(len(inputs)) {
+			label := -1
+			if g.LabelCount > 0 {
+				label = labels[j]
+			}
+			u.step(g, inputs[j], label)
+		}
+	}
+}
+
+func (u *UpDownTrainer) step(g *GenerativeDBN, input []bool, label int) {
+	wake := g.Recognize(nil, input)
+	for i, layer := range g.Layers {
+		predicted := layer.ExpectedLower(wake[i+1])
+		updateDirected(layer.GenWeights, layer.GenBiases, wake[i], wake[i+1],
+			predicted, u.StepSize)
+	}
+
+	labelStart := g.topInputSize()
+	posVisible := make([]bool, len(g.Top.VisibleBiases))
+	copy(posVisible, wake[len(wake)-1])
+	if label >= 0 {
+		posVisible[labelStart+label] = true
+	}
+	posHidden := g.Top.ExpectedHidden(posVisible)
+
+	negVisible := append([]bool{}, posVisible...)
+	negHidden := posHidden
+	hiddenState := make([]bool, len(posHidden))
+	for i := 0; i < u.GibbsSteps; i++ {
+		sampleVector(nil, hiddenState, negHidden)
+		g.sampleTopVisible(nil, negVisible, hiddenState, true)
+		negHidden = g.Top.ExpectedHidden(negVisible)
+	}
+	updateTop(g.Top, posVisible, posHidden, negVisible, negHidden, u.StepSize)
+
+	sleep := make([][]bool, len(g.Layers)+1)
+	sleep[len(g.Layers)] = negVisible[:labelStart]
+	for i := len(g.Layers) - 1; i >= 0; i-- {
+		expected := g.Layers[i].ExpectedLower(sleep[i+1])
+		sleep[i] = make([]bool, len(expected))
+		sampleVector(nil, sleep[i], expected)
+	}
+	for i, layer := range g.Layers {
+		predicted := layer.ExpectedUpper(sleep[i])
+		updateDirected(layer.RecWeights, layer.RecBiases, sleep[i+1], sleep[i],
+			predicted, u.StepSize)
+	}
+}
+
+// updateDirected performs a delta rule update on
+// directed connections from an input layer to a target
+// layer, given the current predictions for the target.
+func updateDirected(weights *linalg.Matrix, biases linalg.Vector, target, in []bool,
+	predicted linalg.Vector, stepSize float64) {
+	for i, t := range target {
+		diff := -predicted[i]
+		if t {
+			diff += 1
+		}
+		diff *= stepSize
+		biases[i] += diff
+		for j, x := range in {
+			if x {
+				weights.Set(i, j, weights.Get(i, j)+diff)
+			}
+		}
+	}
+}
+
+// updateTop performs a contrastive divergence update on
+// an RBM.
+func updateTop(r *RBM, posVisible []bool, posHidden linalg.Vector, negVisible []bool,
+	negHidden linalg.Vector, stepSize float64) {
+	posVec := boolsToVector(posVisible)
+	negVec := boolsToVector(negVisible)
+	for i := range r.VisibleBiases {
+		r.VisibleBiases[i] += stepSize * (posVec[i] - negVec[i])
+	}
+	for i := range r.HiddenBiases {
+		r.HiddenBiases[i] += stepSize * (posHidden[i] - negHidden[i])
+		for j := range r.VisibleBiases {
+			delta := posHidden[i]*posVec[j] - negHidden[i]*negVec[j]
+			if delta != 0 {
+				r.Weights.Set(i, j, r.Weights.Get(i, j)+stepSize*delta)
+			}
+		}
+	}
+}
@@ -0,0 +1,386 @@
+package rnn
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/unixpickle/num-analysis/linalg"
+	"github.com/unixpickle/weakai/neuralnet"
+)
+
+// A CompiledBlock is an inference-only form of a Block
+// which can be stepped through time without allocating
+// memory.
+//
+// A CompiledBlock itself is never modified, so many
+// Goroutines can share it as long as each one uses its
+// own CompiledRunner.
+type CompiledBlock struct {
+	inputSize int
+	block     compiledBlock
+}
+
+// CompileBlock compiles a Block for inputs of the given
+// size.
+//
+// Only NetworkBlocks, LSTMs, and StackedBlocks made of
+// these are supported.
+// The networks inside NetworkBlocks must be supported
+// by neuralnet.Compile.
+//
+// The parameters of the block are copied, so later
+// changes to the block will not affect the result.
+func CompileBlock(b Block, inputSize int) (*CompiledBlock, error) {
+	compiled, err := compileBlock(b, inputSize)
+	if err != nil {
+		return nil, err
+	}
+	return &CompiledBlock{inputSize: inputSize, block: compiled}, nil
+}
+
+// InputSize returns the size of each input vector.
+func (c *CompiledBlock) InputSize() int {
+	return c.inputSize
+}
+
+// OutputSize returns the size of each output vector.
+func (c *CompiledBlock) OutputSize() int {
+	return c.block.outputSize()
+}
+
+// NewRunner creates a CompiledRunner for the block,
+// starting at time 0.
+func (c *CompiledBlock) NewRunner() *CompiledRunner {
+	res := &CompiledRunner{
+		block:     c,
+		workspace: c.block.newWorkspace(),
+		state:     make(linalg.Vector, c.block.stateSize()),
+	}
+	res.Reset()
+	return res
+}
+
+// A CompiledRunner is like a Runner, but it evaluates a
+// CompiledBlock using preallocated buffers.
+// A CompiledRunner should not be used by more than one
+// Goroutine at once.
+type CompiledRunner struct {
+	block     *CompiledBlock
+	workspace blockWorkspace
+	state     linalg.Vector
+}
+
+// Reset resets the current state, effectively going
+// back to time 0.
+func (c *CompiledRunner) Reset() {
+	c.block.block.startState(c.state)
+}
+
+// StepTime evaluates the block with the current state
+// and input, updating the internal state and returning
+// the output.
+//
+// The result is owned by the CompiledRunner and is only
+// valid until the next call to StepTime.
+func (c *CompiledRunner) StepTime(input linalg.Vector) linalg.Vector {
+	if len(input) != c.block.inputSize {
+		panic(fmt.Sprintf("bad input length %d (expected %d)", len(input),
+			c.block.inputSize))
+	}
+	return c.workspace.step(c.state, input)
+}
+
+type compiledBlock interface {
+	outputSize() int
+	stateSize() int
+
+	// startState writes the start state to dest.
+	startState(dest linalg.Vector)
+
+	newWorkspace() blockWorkspace
+}
+
+type blockWorkspace interface {
+	// step updates the state in place and returns the
+	// output, which is owned by the workspace.
+	step(state, in linalg.Vector) linalg.Vector
+}
+
+func compileBlock(b Block, inputSize int) (compiledBlock, error) {
+	switch b := b.(type) {
+	case *NetworkBlock:
+		return compileNetworkBlock(b, inputSize)
+	case *LSTM:
+		return compileLSTM(b, inputSize)
+	case StackedBlock:
+		var res compiledStackedBlock
+		for i, sub := range b {
+			compiled, err := compileBlock(sub, inputSize)
+			if err != nil {
+				return nil, fmt.Errorf("block %d: %s", i, err)
+			}
+			res = append(res, compiled)
+			inputSize = compiled.outputSize()
+		}
+		if len(res) == 0 {
+			return nil, errors.New("empty StackedBlock")
+		}
+		return res, nil
+	default:
+		return nil, fmt.Errorf("cannot compile block of type %T", b)
+	}
+}
+
+type compiledNetworkBlock struct {
+	network *neuralnet.CompiledNetwork
+	start   linalg.Vector
+}
+
+func compileNetworkBlock(b *NetworkBlock, inputSize int) (*compiledNetworkBlock, error) {
+	stateSize := b.batcherBlock.StateSize
+	network, err := neuralnet.Compile(b.network, inputSize+stateSize, 1)
+	if err != nil {
+		return nil, err
+	}
+	if network.OutputSize() < stateSize {
+		return nil, fmt.Errorf("network output size %d is less than state size %d",
+			network.OutputSize(), stateSize)
+	}
+	return &compiledNetworkBlock{
+		network: network,
+		start:   b.batcherBlock.Start.Vector.Copy(),
+	}, nil
+}
+
+func (c *compiledNetworkBlock) outputSize() int {
+	return c.network.OutputSize() - len(c.start)
+}
+
+func (c *compiledNetworkBlock) stateSize() int {
+	return len(c.start)
+}
+
+func (c *compiledNetworkBlock) startState(dest linalg.Vector) {
+	copy(dest, c.start)
+}
+
+func (c *compiledNetworkBlock) newWorkspace() blockWorkspace {
+	return &networkBlockWorkspace{
+		inPool:    make(linalg.Vector, c.network.InputSize()),
+		workspace: c.network.NewWorkspace(),
+	}
+}
+
+type networkBlockWorkspace struct {
+	inPool    linalg.Vector
+	workspace *neuralnet.Workspace
+}
+
+func (n *networkBlockWorkspace) step(state, in linalg.Vector) linalg.Vector {
+	copy(n.inPool, in)
+	copy(n.inPool[len(in):], state)
+	out := n.workspace.Apply(n.inPool)
+	outSize := len(out) - len(state)
+	copy(state, out[outSize:])
+	return out[:outSize]
+}
+
+type compiledLSTM struct {
+	hiddenSize int
+	inputSize  int
+	start      linalg.Vector
+
+	inputValue   *compiledLSTMGate
+	inputGate    *compiledLSTMGate
+	rememberGate *compiledLSTMGate
+	outputGate   *compiledLSTMGate
+}
+
+func compileLSTM(l *LSTM, inputSize int) (*compiledLSTM, error) {
+	if inputSize != l.inputSize() {
+		return nil, fmt.Errorf("expected input size %d but got %d", l.inputSize(),
+			inputSize)
+	}
+	res := &compiledLSTM{
+		hiddenSize: l.hiddenSize,
+		inputSize:  inputSize,
+		start:      l.initState.Vector.Copy(),
+	}
+	gates := []*lstmGate{l.inputValue, l.inputGate, l.rememberGate, l.outputGate}
+	dests := []**compiledLSTMGate{&res.inputValue, &res.inputGate, &res.rememberGate,
+		&res.outputGate}
+	for i, gate := range gates {
+		compiled, err := compileLSTMGate(gate)
+		if err != nil {
+			return nil, err
+		}
+		*dests[i] = compiled
+	}
+	return res, nil
+}
+
+func (c *compiledLSTM) outputSize() int {
+	return c.hiddenSize
+}
+
+func (c *compiledLSTM) stateSize() int {
+	return 2 * c.hiddenSize
+}
+
+func (c *compiledLSTM) startState(dest linalg.Vector) {
+	copy(dest, c.start)
+}
+
+func (c *compiledLSTM) newWorkspace() blockWorkspace {
+	return &lstmWorkspace{
+		lstm:         c,
+		gateIn:       make(linalg.Vector, c.inputSize+c.hiddenSize),
+		newState:     make(linalg.Vector, c.hiddenSize),
+		output:       make(linalg.Vector, c.hiddenSize),
+		inputValue:   c.inputValue.newWorkspace(),
+		inputGate:    c.inputGate.newWorkspace(),
+		rememberGate: c.rememberGate.newWorkspace(),
+		outputGate:   c.outputGate.newWorkspace(),
+	}
+}
+
+type lstmWorkspace struct {
+	lstm     *compiledLSTM
+	gateIn   linalg.Vector
+	newState linalg.Vector
+	output   linalg.Vector
+
+	inputValue   *lstmGateWorkspace
+	inputGate    *lstmGateWorkspace
+	rememberGate *lstmGateWorkspace
+	outputGate   *lstmGateWorkspace
+}
+
+// step evaluates the LSTM with a state laid out like the
+// LSTM's start state: cell state, then last output.
+func (l *lstmWorkspace) step(state, in linalg.Vector) linalg.Vector {
+	hidden := l.lstm.hiddenSize
+	internal, lastOut := state[:hidden], state[hidden:]
+
+	copy(l.gateIn, in)
+	copy(l.gateIn[len(in):], lastOut)
+
+	inValue := l.inputValue.apply(l.gateIn, internal)
+	inGate := l.inputGate.apply(l.gateIn, internal)
+	rememberGate := l.rememberGate.apply(l.gateIn, internal)
+	for i, x := range internal {
+		l.newState[i] = rememberGate[i]*x + inValue[i]*inGate[i]
+	}
+
+	outGate := l.outputGate.apply(l.gateIn, l.newState)
+	for i, x := range l.newState {
+		l.output[i] = outGate[i] * math.Tanh(x)
+	}
+
+	copy(internal, l.newState)
+	copy(lastOut, l.output)
+	return l.output
+}
+
+// A compiledLSTMGate computes an LSTM gate's activation.
+// If the gate has no peephole, the activation is fused
+// into the dense network.
+type compiledLSTMGate struct {
+	dense      *neuralnet.CompiledNetwork
+	activation *neuralnet.CompiledNetwork
+	peephole   linalg.Vector
+}
+
+func compileLSTMGate(g *lstmGate) (*compiledLSTMGate, error) {
+	res := &compiledLSTMGate{}
+	var err error
+	if g.Peephole == nil {
+		net := neuralnet.Network{g.Dense, g.Activation}
+		res.dense, err = neuralnet.Compile(net, g.Dense.InputCount, 1)
+		return res, err
+	}
+	inputCount := g.Dense.InputCount
+	res.dense, err = neuralnet.Compile(neuralnet.Network{g.Dense}, inputCount, 1)
+	if err != nil {
+		return nil, err
+	}
+	res.activation, err = neuralnet.Compile(neuralnet.Network{g.Activation},
+		g.Dense.OutputCount, 1)
+	if err != nil {
+		return nil, err
+	}
+	res.peephole = g.Peephole.Vector.Copy()
+	return res, nil
+}
+
+func (c *compiledLSTMGate) newWorkspace() *lstmGateWorkspace {
+	res := &lstmGateWorkspace{
+		gate:  c,
+		dense: c.dense.NewWorkspace(),
+	}
+	if c.activation != nil {
+		res.activation = c.activation.NewWorkspace()
+	}
+	return res
+}
+
+type lstmGateWorkspace struct {
+	gate       *compiledLSTMGate
+	dense      *neuralnet.Workspace
+	activation *neuralnet.Workspace
+}
+
+func (l *lstmGateWorkspace) apply(in, peepholeIn linalg.Vector) linalg.Vector {
+	out := l.dense.Apply(in)
+	if l.activation == nil {
+		return out
+	}
+	for i, x := range l.gate.peephole {
+		out[i] += x * peepholeIn[i]
+	}
+	return l.activation.Apply(out)
+}
+
+type compiledStackedBlock []compiledBlock
+
+func (c compiledStackedBlock) outputSize() int {
+	return c[len(c)-1].outputSize()
+}
+
+func (c compiledStackedBlock) stateSize() int {
+	var res int
+	for _, b := range c {
+		res += b.stateSize()
+	}
+	return res
+}
+
+func (c compiledStackedBlock) startState(dest linalg.Vector) {
+	for _, b := range c {
+		b.startState(dest[:b.stateSize()])
+		dest = dest[b.stateSize():]
+	}
+}
+
+func (c compiledStackedBlock) newWorkspace() blockWorkspace {
+	res := make(stackedWorkspace, len(c))
+	for i, b := range c {
+		res[i].stateSize = b.stateSize()
+		res[i].workspace = b.newWorkspace()
+	}
+	return res
+}
+
+type stackedWorkspace []struct {
+	stateSize int
+	workspace blockWorkspace
+}
+
+func (s stackedWorkspace) step(state, in linalg.Vector) linalg.Vector {
+	for _, sub := range s {
+		in = sub.workspace.step(state[:sub.stateSize], in)
+		state = state[sub.stateSize:]
+	}
+	return in
+}
@@ -0,0 +1,113 @@
+package rnn
+
+import (
+	"github.com/unixpickle/autofunc"
+	"github.com/unixpickle/serializer"
+)
+
+func init() {
+	var d DenseBlock
+	serializer.RegisterTypedDeserializer(d.SerializerType(), DeserializeDenseBlock)
+}
+
+// A DenseBlock is a deep Block in which each block is fed
+// the concatenation of the DenseBlock's input and the
+// outputs of all the previous blocks in the stack.
+//
+// The input size of each block must be the sum of the
+// DenseBlock's input size and the output sizes of the
+// blocks before it.
+// The output of a DenseBlock is the output of the last
+// block in the stack.
+type DenseBlock []Block
+
+// DeserializeDenseBlock deserializes a DenseBlock.
+func DeserializeDenseBlock(d []byte) (DenseBlock, error) {
+	sb, err := DeserializeStackedBlock(d)
+	if err != nil {
+		return nil, err
+	}
+	return DenseBlock(sb), nil
+}
+
+// StartState generates a start state which encapsulates
+// the start states of all the nested blocks.
+func (d DenseBlock) StartState() State {
+	d.assertNotEmpty()
+	return StackedBlock(d).StartState()
+}
+
+// StartRState is like StartState.
+func (d DenseBlock) StartRState(rv autofunc.RVector) RState {
+	d.assertNotEmpty()
+	return StackedBlock(d).StartRState(rv)
+}
+
+// PropagateStart back-propagates through all the child
+// Blocks.
+func (d DenseBlock) PropagateStart(s []State, u []StateGrad, g autofunc.Gradient) {
+	d.assertNotEmpty()
+	StackedBlock(d).PropagateStart(s, u, g)
+}
+
+// PropagateStartR is like PropagateStart.
+func (d DenseBlock) PropagateStartR(s []RState, u []RStateGrad, rg autofunc.RGradient,
+	g autofunc.Gradient) {
+	d.assertNotEmpty()
+	StackedBlock(d).PropagateStartR(s, u, rg, g)
+}
+
+// ApplyBlock applies the stack of blocks.
+func (d DenseBlock) ApplyBlock(s []State, in []autofunc.Result) BlockResult {
+	d.assertNotEmpty()
+	return applySkipStack(d, d, s, in)
+}
+
+// ApplyBlockR is like ApplyBlock, but with R-operator
+// support.
+func (d DenseBlock) ApplyBlockR(rv autofunc.RVector, s []RState,
+	in []autofunc.RResult) BlockRResult {
+	d.assertNotEmpty()
+	return applySkipStackR(rv, d, d, s, in)
+}
+
+// Parameters returns the parameters of every Learner
+// sub-block of this block.
+func (d DenseBlock) Parameters() []*autofunc.Variable {
+	return StackedBlock(d).Parameters()
+}
+
+// Serialize attempts to serialize all of the sub-blocks
+// if they implement the Serializer interface.
+func (d DenseBlock) Serialize() ([]byte, error) {
+	return StackedBlock(d).Serialize()
+}
+
+// SerializerType returns the unique ID used to serialize
+// a DenseBlock with the serializer package.
+func (d DenseBlock) SerializerType() string {
+	return "github.com/unixpickle/weakai/rnn.DenseBlock"
+}
+
+// combine concatenates a block's input and output, or
+// returns the output for the last block.
+func (d DenseBlock) combine(layer int, in, out autofunc.Result) autofunc.Result {
+	if layer+1 == len(d) {
+		return out
+	}
+	return autofunc.Concat(in, out)
+}
+
+func (d DenseBlock) combineR(rv autofunc.RVector, layer int,
+	in, out autofunc.RResult) autofunc.RResult {
+	if layer+1 == len(d) {
+		return out
+	}
+	return autofunc.ConcatR(in, out)
+}
+
+func (d DenseBlock) assertNotEmpty() {
+	if len(d) == 0 {
+		panic("cannot use an empty DenseBlock")
+	}
+}
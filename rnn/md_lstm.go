@@ -0,0 +1,519 @@
+package rnn
+
+import (
+	"errors"
+
+	"github.com/unixpickle/autofunc"
+	"github.com/unixpickle/num-analysis/linalg"
+	"github.com/unixpickle/serializer"
+	"github.com/unixpickle/weakai/neuralnet"
+)
+
+func init() {
+	var m MDLSTM
+	serializer.RegisterTypedDeserializer(m.SerializerType(), DeserializeMDLSTM)
+}
+
+// MDLSTM is a two-dimensional LSTM cell, as described
+// in Graves, Fernández, and Schmidhuber (2007).
+//
+// An MDLSTM sweeps over a grid of inputs one row at a
+// time, and each cell receives the state from both its
+// left neighbor and its upper neighbor (relative to the
+// direction of the sweep).
+// Cells on the edge of the grid see zero states in
+// place of their missing neighbors.
+//
+// Unlike the Block types, an MDLSTM is not applied to
+// sequences directly.
+// Rather, it is used through MDLSTMLayer or
+// MDLSTMSeqFunc.
+type MDLSTM struct {
+	// Gates computes the input values, the input gates,
+	// the left forget gates, the upper forget gates, and
+	// the output gates (in that order) from the current
+	// input, the left neighbor's output, and the upper
+	// neighbor's output (in that order).
+	Gates *neuralnet.DenseLayer
+}
+
+// NewMDLSTM creates an MDLSTM with randomly initialized
+// weights.
+func NewMDLSTM(inputSize, hiddenSize int) *MDLSTM {
+	res := &MDLSTM{
+		Gates: &neuralnet.DenseLayer{
+			InputCount:  inputSize + hiddenSize*2,
+			OutputCount: hiddenSize * 5,
+		},
+	}
+	res.Gates.Randomize()
+	biases := res.Gates.Biases.Var.Vector
+	biases.Scale(0)
+	for i := hiddenSize * 2; i < hiddenSize*4; i++ {
+		biases[i] = initialRememberBias
+	}
+	return res
+}
+
+// DeserializeMDLSTM deserializes an MDLSTM.
+func DeserializeMDLSTM(d []byte) (*MDLSTM, error) {
+	gates, err := neuralnet.DeserializeDenseLayer(d)
+	if err != nil {
+		return nil, err
+	}
+	if gates.OutputCount%5 != 0 {
+		return nil, errors.New("invalid MDLSTM output count")
+	}
+	return &MDLSTM{Gates: gates}, nil
+}
+
+// InputSize returns the size of the input at each
+// point in the grid.
+func (m *MDLSTM) InputSize() int {
+	return m.Gates.InputCount - 2*m.HiddenSize()
+}
+
+// HiddenSize returns the number of cells, which is also
+// the size of the output at each point in the grid.
+func (m *MDLSTM) HiddenSize() int {
+	return m.Gates.OutputCount / 5
+}
+
+// Parameters returns the weights and biases of the
+// gates.
+func (m *MDLSTM) Parameters() []*autofunc.Variable {
+	return m.Gates.Parameters()
+}
+
+// SerializerType returns the unique ID used to serialize
+// an MDLSTM with the serializer package.
+func (m *MDLSTM) SerializerType() string {
+	return "github.com/unixpickle/weakai/rnn.MDLSTM"
+}
+
+// Serialize serializes the MDLSTM.
+func (m *MDLSTM) Serialize() ([]byte, error) {
+	return m.Gates.Serialize()
+}
+
+// applyCell computes the concatenation of the new cell
+// state and the new output for a single grid point.
+func (m *MDLSTM) applyCell(in, leftState, leftOut, upState, upOut autofunc.Result) autofunc.Result {
+	h := m.HiddenSize()
+	gates := m.Gates.Apply(autofunc.Concat(in, leftOut, upOut))
+	return autofunc.Pool(gates, func(gates autofunc.Result) autofunc.Result {
+		inValue := neuralnet.HyperbolicTangent{}.Apply(autofunc.Slice(gates, 0, h))
+		sigmoids := neuralnet.Sigmoid{}.Apply(autofunc.Slice(gates, h, h*5))
+		return autofunc.Pool(sigmoids, func(sigmoids autofunc.Result) autofunc.Result {
+			inGate := autofunc.Slice(sigmoids, 0, h)
+			leftForget := autofunc.Slice(sigmoids, h, h*2)
+			upForget := autofunc.Slice(sigmoids, h*2, h*3)
+			outGate := autofunc.Slice(sigmoids, h*3, h*4)
+			newState := autofunc.Add(autofunc.Mul(inGate, inValue),
+				autofunc.Add(autofunc.Mul(leftForget, leftState),
+					autofunc.Mul(upForget, upState)))
+			return autofunc.Pool(newState, func(newState autofunc.Result) autofunc.Result {
+				squashed := neuralnet.HyperbolicTangent{}.Apply(newState)
+				return autofunc.Concat(newState, autofunc.Mul(outGate, squashed))
+			})
+		})
+	})
+}
+
+// applyCellR is like applyCell, but with R-operator
+// support.
+func (m *MDLSTM) applyCellR(rv autofunc.RVector, in, leftState, leftOut, upState,
+	upOut autofunc.RResult) autofunc.RResult {
+	h := m.HiddenSize()
+	gates := m.Gates.ApplyR(rv, autofunc.ConcatR(in, leftOut, upOut))
+	return autofunc.PoolR(gates, func(gates autofunc.RResult) autofunc.RResult {
+		inValue := neuralnet.HyperbolicTangent{}.ApplyR(rv, autofunc.SliceR(gates, 0, h))
+		sigmoids := neuralnet.Sigmoid{}.ApplyR(rv, autofunc.SliceR(gates, h, h*5))
+		return autofunc.PoolR(sigmoids, func(sigmoids autofunc.RResult) autofunc.RResult {
+			inGate := autofunc.SliceR(sigmoids, 0, h)
+			leftForget := autofunc.SliceR(sigmoids, h, h*2)
+			upForget := autofunc.SliceR(sigmoids, h*2, h*3)
+			outGate := autofunc.SliceR(sigmoids, h*3, h*4)
+			newState := autofunc.AddR(autofunc.MulR(inGate, inValue),
+				autofunc.AddR(autofunc.MulR(leftForget, leftState),
+					autofunc.MulR(upForget, upState)))
+			return autofunc.PoolR(newState, func(newState autofunc.RResult) autofunc.RResult {
+				squashed := neuralnet.HyperbolicTangent{}.ApplyR(rv, newState)
+				return autofunc.ConcatR(newState, autofunc.MulR(outGate, squashed))
+			})
+		})
+	})
+}
+
+// mdlstmGrid describes how a grid of vectors is laid
+// out in a single vector.
+type mdlstmGrid struct {
+	Width  int
+	Height int
+
+	// ColumnMajor is true if the grid is stored one
+	// column at a time, rather than one row at a time
+	// like a tensor.
+	ColumnMajor bool
+}
+
+func (m mdlstmGrid) index(x, y int) int {
+	if m.ColumnMajor {
+		return x*m.Height + y
+	}
+	return y*m.Width + x
+}
+
+// sweepIndex returns the grid index for a point which
+// is given in the coordinates of the sweep'th sweep.
+// Sweep 0 goes left to right and top to bottom, sweep 1
+// is flipped horizontally, sweep 2 is flipped
+// vertically, and sweep 3 is flipped both ways.
+func (m mdlstmGrid) sweepIndex(sweep, x, y int) int {
+	if sweep&1 != 0 {
+		x = m.Width - (x + 1)
+	}
+	if sweep&2 != 0 {
+		y = m.Height - (y + 1)
+	}
+	return m.index(x, y)
+}
+
+// mdlstmCell stores the pooled inputs and the result of
+// an MDLSTM at a single grid point.
+type mdlstmCell struct {
+	Input     *autofunc.Variable
+	LeftState *autofunc.Variable
+	LeftOut   *autofunc.Variable
+	UpState   *autofunc.Variable
+	UpOut     *autofunc.Variable
+
+	Result  autofunc.Result
+	RResult autofunc.RResult
+}
+
+func (m *mdlstmCell) vars() []*autofunc.Variable {
+	return []*autofunc.Variable{m.Input, m.LeftState, m.LeftOut, m.UpState, m.UpOut}
+}
+
+type mdlstmGridResult struct {
+	Sweeps   []*MDLSTM
+	Grid     mdlstmGrid
+	Input    autofunc.Result
+	Cells    [][]*mdlstmCell
+	OutVec   linalg.Vector
+	OutDepth int
+}
+
+// applyMDLSTMGrid applies one MDLSTM per sweep to a grid
+// of inputs.
+// The result is a grid with the same layout as the
+// input, where the outputs of the sweeps are joined at
+// each point in the grid.
+func applyMDLSTMGrid(sweeps []*MDLSTM, in autofunc.Result, grid mdlstmGrid) autofunc.Result {
+	if len(sweeps) > 4 {
+		panic("at most four sweeps are supported")
+	}
+	inSize := sweeps[0].InputSize()
+	if len(in.Output()) != grid.Width*grid.Height*inSize {
+		panic("unexpected input size")
+	}
+	res := &mdlstmGridResult{
+		Sweeps:   sweeps,
+		Grid:     grid,
+		Input:    in,
+		Cells:    make([][]*mdlstmCell, len(sweeps)),
+		OutDepth: mdlstmOutputDepth(sweeps),
+	}
+	res.OutVec = make(linalg.Vector, grid.Width*grid.Height*res.OutDepth)
+
+	var depthOffset int
+	for sweepIdx, s := range sweeps {
+		h := s.HiddenSize()
+		zeros := make(linalg.Vector, h)
+		cells := make([]*mdlstmCell, grid.Width*grid.Height)
+		for y := 0; y < grid.Height; y++ {
+			for x := 0; x < grid.Width; x++ {
+				idx := grid.sweepIndex(sweepIdx, x, y)
+				cell := &mdlstmCell{
+					Input: &autofunc.Variable{
+						Vector: in.Output()[idx*inSize : (idx+1)*inSize],
+					},
+					LeftState: &autofunc.Variable{Vector: zeros},
+					LeftOut:   &autofunc.Variable{Vector: zeros},
+					UpState:   &autofunc.Variable{Vector: zeros},
+					UpOut:     &autofunc.Variable{Vector: zeros},
+				}
+				if x > 0 {
+					leftOut := cells[y*grid.Width+x-1].Result.Output()
+					cell.LeftState.Vector = leftOut[:h]
+					cell.LeftOut.Vector = leftOut[h:]
+				}
+				if y > 0 {
+					upOut := cells[(y-1)*grid.Width+x].Result.Output()
+					cell.UpState.Vector = upOut[:h]
+					cell.UpOut.Vector = upOut[h:]
+				}
+				cell.Result = s.applyCell(cell.Input, cell.LeftState, cell.LeftOut,
+					cell.UpState, cell.UpOut)
+				cells[y*grid.Width+x] = cell
+				outStart := idx*res.OutDepth + depthOffset
+				copy(res.OutVec[outStart:outStart+h], cell.Result.Output()[h:])
+			}
+		}
+		res.Cells[sweepIdx] = cells
+		depthOffset += h
+	}
+	return res
+}
+
+func (m *mdlstmGridResult) Output() linalg.Vector {
+	return m.OutVec
+}
+
+func (m *mdlstmGridResult) Constant(g autofunc.Gradient) bool {
+	if !m.Input.Constant(g) {
+		return false
+	}
+	for _, s := range m.Sweeps {
+		for _, p := range s.Parameters() {
+			if _, ok := g[p]; ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (m *mdlstmGridResult) PropagateGradient(upstream linalg.Vector, g autofunc.Gradient) {
+	if m.Constant(g) {
+		return
+	}
+	grid := m.Grid
+	inSize := m.Sweeps[0].InputSize()
+	inGrad := make(linalg.Vector, len(m.Input.Output()))
+
+	var depthOffset int
+	for sweepIdx, s := range m.Sweeps {
+		h := s.HiddenSize()
+		cells := m.Cells[sweepIdx]
+		cellGrads := make([]linalg.Vector, len(cells))
+		for i := range cellGrads {
+			cellGrads[i] = make(linalg.Vector, h*2)
+		}
+		for y := grid.Height - 1; y >= 0; y-- {
+			for x := grid.Width - 1; x >= 0; x-- {
+				idx := grid.sweepIndex(sweepIdx, x, y)
+				cell := cells[y*grid.Width+x]
+				downstream := cellGrads[y*grid.Width+x]
+				outStart := idx*m.OutDepth + depthOffset
+				downstream[h:].Add(upstream[outStart : outStart+h])
+
+				for _, v := range cell.vars() {
+					g[v] = make(linalg.Vector, len(v.Vector))
+				}
+				cell.Result.PropagateGradient(downstream, g)
+
+				inGrad[idx*inSize : (idx+1)*inSize].Add(g[cell.Input])
+				if x > 0 {
+					leftGrad := cellGrads[y*grid.Width+x-1]
+					leftGrad[:h].Add(g[cell.LeftState])
+					leftGrad[h:].Add(g[cell.LeftOut])
+				}
+				if y > 0 {
+					upGrad := cellGrads[(y-1)*grid.Width+x]
+					upGrad[:h].Add(g[cell.UpState])
+					upGrad[h:].Add(g[cell.UpOut])
+				}
+				for _, v := range cell.vars() {
+					delete(g, v)
+				}
+			}
+		}
+		depthOffset += h
+	}
+
+	if !m.Input.Constant(g) {
+		m.Input.PropagateGradient(inGrad, g)
+	}
+}
+
+type mdlstmGridRResult struct {
+	Sweeps   []*MDLSTM
+	Grid     mdlstmGrid
+	Input    autofunc.RResult
+	Cells    [][]*mdlstmCell
+	OutVec   linalg.Vector
+	ROutVec  linalg.Vector
+	OutDepth int
+}
+
+// applyMDLSTMGridR is like applyMDLSTMGrid, but with
+// R-operator support.
+func applyMDLSTMGridR(rv autofunc.RVector, sweeps []*MDLSTM, in autofunc.RResult,
+	grid mdlstmGrid) autofunc.RResult {
+	if len(sweeps) > 4 {
+		panic("at most four sweeps are supported")
+	}
+	inSize := sweeps[0].InputSize()
+	if len(in.Output()) != grid.Width*grid.Height*inSize {
+		panic("unexpected input size")
+	}
+	res := &mdlstmGridRResult{
+		Sweeps:   sweeps,
+		Grid:     grid,
+		Input:    in,
+		Cells:    make([][]*mdlstmCell, len(sweeps)),
+		OutDepth: mdlstmOutputDepth(sweeps),
+	}
+	res.OutVec = make(linalg.Vector, grid.Width*grid.Height*res.OutDepth)
+	res.ROutVec = make(linalg.Vector, len(res.OutVec))
+
+	var depthOffset int
+	for sweepIdx, s := range sweeps {
+		h := s.HiddenSize()
+		zeros := make(linalg.Vector, h)
+		cells := make([]*mdlstmCell, grid.Width*grid.Height)
+		for y := 0; y < grid.Height; y++ {
+			for x := 0; x < grid.Width; x++ {
+				idx := grid.sweepIndex(sweepIdx, x, y)
+				cell := &mdlstmCell{
+					Input: &autofunc.Variable{
+						Vector: in.Output()[idx*inSize : (idx+1)*inSize],
+					},
+					LeftState: &autofunc.Variable{Vector: zeros},
+					LeftOut:   &autofunc.Variable{Vector: zeros},
+					UpState:   &autofunc.Variable{Vector: zeros},
+					UpOut:     &autofunc.Variable{Vector: zeros},
+				}
+				inputR := &autofunc.RVariable{
+					Variable:   cell.Input,
+					ROutputVec: in.ROutput()[idx*inSize : (idx+1)*inSize],
+				}
+				leftStateR := &autofunc.RVariable{Variable: cell.LeftState, ROutputVec: zeros}
+				leftOutR := &autofunc.RVariable{Variable: cell.LeftOut, ROutputVec: zeros}
+				upStateR := &autofunc.RVariable{Variable: cell.UpState, ROutputVec: zeros}
+				upOutR := &autofunc.RVariable{Variable: cell.UpOut, ROutputVec: zeros}
+				if x > 0 {
+					left := cells[y*grid.Width+x-1].RResult
+					cell.LeftState.Vector = left.Output()[:h]
+					cell.LeftOut.Vector = left.Output()[h:]
+					leftStateR.ROutputVec = left.ROutput()[:h]
+					leftOutR.ROutputVec = left.ROutput()[h:]
+				}
+				if y > 0 {
+					up := cells[(y-1)*grid.Width+x].RResult
+					cell.UpState.Vector = up.Output()[:h]
+					cell.UpOut.Vector = up.Output()[h:]
+					upStateR.ROutputVec = up.ROutput()[:h]
+					upOutR.ROutputVec = up.ROutput()[h:]
+				}
+				cell.RResult = s.applyCellR(rv, inputR, leftStateR, leftOutR,
+					upStateR, upOutR)
+				cells[y*grid.Width+x] = cell
+				outStart := idx*res.OutDepth + depthOffset
+				copy(res.OutVec[outStart:outStart+h], cell.RResult.Output()[h:])
+				copy(res.ROutVec[outStart:outStart+h], cell.RResult.ROutput()[h:])
+			}
+		}
+		res.Cells[sweepIdx] = cells
+		depthOffset += h
+	}
+	return res
+}
+
+func (m *mdlstmGridRResult) Output() linalg.Vector {
+	return m.OutVec
+}
+
+func (m *mdlstmGridRResult) ROutput() linalg.Vector {
+	return m.ROutVec
+}
+
+func (m *mdlstmGridRResult) Constant(rg autofunc.RGradient, g autofunc.Gradient) bool {
+	if !m.Input.Constant(rg, g) {
+		return false
+	}
+	for _, s := range m.Sweeps {
+		for _, p := range s.Parameters() {
+			if _, ok := rg[p]; ok {
+				return false
+			}
+			if g != nil {
+				if _, ok := g[p]; ok {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+func (m *mdlstmGridRResult) PropagateRGradient(upstream, upstreamR linalg.Vector,
+	rg autofunc.RGradient, g autofunc.Gradient) {
+	if m.Constant(rg, g) {
+		return
+	}
+	if g == nil {
+		g = autofunc.Gradient{}
+	}
+	grid := m.Grid
+	inSize := m.Sweeps[0].InputSize()
+	inGrad := make(linalg.Vector, len(m.Input.Output()))
+	inGradR := make(linalg.Vector, len(m.Input.Output()))
+
+	var depthOffset int
+	for sweepIdx, s := range m.Sweeps {
+		h := s.HiddenSize()
+		cells := m.Cells[sweepIdx]
+		cellGrads := make([]linalg.Vector, len(cells))
+		cellGradsR := make([]linalg.Vector, len(cells))
+		for i := range cellGrads {
+			cellGrads[i] = make(linalg.Vector, h*2)
+			cellGradsR[i] = make(linalg.Vector, h*2)
+		}
+		for y := grid.Height - 1; y >= 0; y-- {
+			for x := grid.Width - 1; x >= 0; x-- {
+				idx := grid.sweepIndex(sweepIdx, x, y)
+				cell := cells[y*grid.Width+x]
+				downstream := cellGrads[y*grid.Width+x]
+				downstreamR := cellGradsR[y*grid.Width+x]
+				outStart := idx*m.OutDepth + depthOffset
+				downstream[h:].Add(upstream[outStart : outStart+h])
+				downstreamR[h:].Add(upstreamR[outStart : outStart+h])
+
+				for _, v := range cell.vars() {
+					g[v] = make(linalg.Vector, len(v.Vector))
+					rg[v] = make(linalg.Vector, len(v.Vector))
+				}
+				cell.RResult.PropagateRGradient(downstream, downstreamR, rg, g)
+
+				inGrad[idx*inSize : (idx+1)*inSize].Add(g[cell.Input])
+				inGradR[idx*inSize : (idx+1)*inSize].Add(rg[cell.Input])
+				if x > 0 {
+					i := y*grid.Width + x - 1
+					cellGrads[i][:h].Add(g[cell.LeftState])
+					cellGrads[i][h:].Add(g[cell.LeftOut])
+					cellGradsR[i][:h].Add(rg[cell.LeftState])
+					cellGradsR[i][h:].Add(rg[cell.LeftOut])
+				}
+				if y > 0 {
+					i := (y-1)*grid.Width + x
+					cellGrads[i][:h].Add(g[cell.UpState])
+					cellGrads[i][h:].Add(g[cell.UpOut])
+					cellGradsR[i][:h].Add(rg[cell.UpState])
+					cellGradsR[i][h:].Add(rg[cell.UpOut])
+				}
+				for _, v := range cell.vars() {
+					delete(g, v)
+					delete(rg, v)
+				}
+			}
+		}
+		depthOffset += h
+	}
+
+	if !m.Input.Constant(rg, g) {
+		m.Input.PropagateRGradient(inGrad, inGradR, rg, g)
+	}
+}
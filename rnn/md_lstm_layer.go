@@ -0,0 +1,373 @@
+package rnn
+
+import (
+	"errors"
+
+	"github.com/unixpickle/autofunc"
+	"github.com/unixpickle/autofunc/seqfunc"
+	"github.com/unixpickle/num-analysis/linalg"
+	"github.com/unixpickle/serializer"
+)
+
+func init() {
+	var l MDLSTMLayer
+	serializer.RegisterTypedDeserializer(l.SerializerType(), DeserializeMDLSTMLayer)
+	var s MDLSTMSeqFunc
+	serializer.RegisterTypedDeserializer(s.SerializerType(), DeserializeMDLSTMSeqFunc)
+}
+
+// An MDLSTMLayer is a neuralnet.Layer which applies
+// MDLSTMs to an input tensor, using one MDLSTM for each
+// sweep direction.
+//
+// The input and output tensors are laid out like the
+// tensors used by neuralnet.ConvLayer.
+// The output has the same width and height as the
+// input, and its depth is the total hidden size of the
+// sweeps.
+// At each point, the output starts with the output of
+// the first sweep, followed by the second, etc.
+type MDLSTMLayer struct {
+	InputWidth  int
+	InputHeight int
+
+	// Sweeps contains up to four MDLSTMs.
+	// The first sweeps from the top left corner, the
+	// second from the top right, the third from the
+	// bottom left, and the fourth from the bottom right.
+	Sweeps []*MDLSTM
+}
+
+// NewMDLSTMLayer creates an MDLSTMLayer with four
+// randomly initialized sweeps.
+func NewMDLSTMLayer(width, height, depth, hiddenSize int) *MDLSTMLayer {
+	return &MDLSTMLayer{
+		InputWidth:  width,
+		InputHeight: height,
+		Sweeps:      newMDLSTMSweeps(depth, hiddenSize),
+	}
+}
+
+// DeserializeMDLSTMLayer deserializes an MDLSTMLayer.
+func DeserializeMDLSTMLayer(d []byte) (*MDLSTMLayer, error) {
+	slice, err := serializer.DeserializeSlice(d)
+	if err != nil {
+		return nil, err
+	}
+	if len(slice) < 3 {
+		return nil, errors.New("invalid slice length in MDLSTMLayer")
+	}
+	width, ok := slice[0].(serializer.Int)
+	height, ok1 := slice[1].(serializer.Int)
+	if !ok || !ok1 {
+		return nil, errors.New("invalid types in MDLSTMLayer slice")
+	}
+	sweeps, err := deserializeMDLSTMSweeps(slice[2:])
+	if err != nil {
+		return nil, err
+	}
+	return &MDLSTMLayer{
+		InputWidth:  int(width),
+		InputHeight: int(height),
+		Sweeps:      sweeps,
+	}, nil
+}
+
+// OutputWidth returns the width of the output tensor.
+func (m *MDLSTMLayer) OutputWidth() int {
+	return m.InputWidth
+}
+
+// OutputHeight returns the height of the output tensor.
+func (m *MDLSTMLayer) OutputHeight() int {
+	return m.InputHeight
+}
+
+// OutputDepth returns the depth of the output tensor.
+func (m *MDLSTMLayer) OutputDepth() int {
+	return mdlstmOutputDepth(m.Sweeps)
+}
+
+// Apply applies the layer to an input tensor.
+func (m *MDLSTMLayer) Apply(in autofunc.Result) autofunc.Result {
+	return applyMDLSTMGrid(m.Sweeps, in, m.grid())
+}
+
+// ApplyR applies the layer to an input tensor.
+func (m *MDLSTMLayer) ApplyR(rv autofunc.RVector, in autofunc.RResult) autofunc.RResult {
+	return applyMDLSTMGridR(rv, m.Sweeps, in, m.grid())
+}
+
+// Parameters returns the parameters of every sweep.
+func (m *MDLSTMLayer) Parameters() []*autofunc.Variable {
+	return mdlstmParameters(m.Sweeps)
+}
+
+// SerializerType returns the unique ID used to serialize
+// an MDLSTMLayer with the serializer package.
+func (m *MDLSTMLayer) SerializerType() string {
+	return "github.com/unixpickle/weakai/rnn.MDLSTMLayer"
+}
+
+// Serialize serializes the layer.
+func (m *MDLSTMLayer) Serialize() ([]byte, error) {
+	slist := []serializer.Serializer{
+		serializer.Int(m.InputWidth),
+		serializer.Int(m.InputHeight),
+	}
+	for _, s := range m.Sweeps {
+		slist = append(slist, s)
+	}
+	return serializer.SerializeSlice(slist)
+}
+
+func (m *MDLSTMLayer) grid() mdlstmGrid {
+	return mdlstmGrid{Width: m.InputWidth, Height: m.InputHeight}
+}
+
+// An MDLSTMSeqFunc is a seqfunc.RFunc which applies
+// MDLSTMs to variable-width grids, such as images of
+// lines of text or spectrograms.
+//
+// Each input sequence is treated as a grid with one
+// timestep per column.
+// Each timestep is a column of Height points, from
+// top to bottom, each of which has InputSize values.
+// Likewise, each output timestep is a column of Height
+// points, each of which has the joined outputs of the
+// sweeps (like in MDLSTMLayer).
+//
+// To produce one output vector per column (e.g. for
+// CTC), an MDLSTMSeqFunc can be followed by a
+// NetworkSeqFunc.
+type MDLSTMSeqFunc struct {
+	Height int
+
+	// Sweeps is like MDLSTMLayer.Sweeps.
+	Sweeps []*MDLSTM
+}
+
+// NewMDLSTMSeqFunc creates an MDLSTMSeqFunc with four
+// randomly initialized sweeps.
+func NewMDLSTMSeqFunc(height, depth, hiddenSize int) *MDLSTMSeqFunc {
+	return &MDLSTMSeqFunc{
+		Height: height,
+		Sweeps: newMDLSTMSweeps(depth, hiddenSize),
+	}
+}
+
+// DeserializeMDLSTMSeqFunc deserializes an
+// MDLSTMSeqFunc.
+func DeserializeMDLSTMSeqFunc(d []byte) (*MDLSTMSeqFunc, error) {
+	slice, err := serializer.DeserializeSlice(d)
+	if err != nil {
+		return nil, err
+	}
+	if len(slice) < 2 {
+		return nil, errors.New("invalid slice length in MDLSTMSeqFunc")
+	}
+	height, ok := slice[0].(serializer.Int)
+	if !ok {
+		return nil, errors.New("invalid types in MDLSTMSeqFunc slice")
+	}
+	sweeps, err := deserializeMDLSTMSweeps(slice[1:])
+	if err != nil {
+		return nil, err
+	}
+	return &MDLSTMSeqFunc{Height: int(height), Sweeps: sweeps}, nil
+}
+
+// OutputDepth returns the number of outputs at each
+// point in the grid.
+func (m *MDLSTMSeqFunc) OutputDepth() int {
+	return mdlstmOutputDepth(m.Sweeps)
+}
+
+// ApplySeqs applies the MDLSTMs to the sequences.
+func (m *MDLSTMSeqFunc) ApplySeqs(in seqfunc.Result) seqfunc.Result {
+	res := &mdlstmSeqResult{
+		Input:   in,
+		Vars:    make([]*autofunc.Variable, len(in.OutputSeqs())),
+		Results: make([]autofunc.Result, len(in.OutputSeqs())),
+		OutSeqs: make([][]linalg.Vector, len(in.OutputSeqs())),
+	}
+	for i, seq := range in.OutputSeqs() {
+		if len(seq) == 0 {
+			continue
+		}
+		res.Vars[i] = &autofunc.Variable{Vector: joinColumns(seq)}
+		res.Results[i] = applyMDLSTMGrid(m.Sweeps, res.Vars[i], m.grid(len(seq)))
+		res.OutSeqs[i] = splitColumns(res.Results[i].Output(), len(seq))
+	}
+	return res
+}
+
+// ApplySeqsR applies the MDLSTMs to the sequences.
+func (m *MDLSTMSeqFunc) ApplySeqsR(rv autofunc.RVector, in seqfunc.RResult) seqfunc.RResult {
+	res := &mdlstmSeqRResult{
+		Input:    in,
+		Vars:     make([]*autofunc.Variable, len(in.OutputSeqs())),
+		Results:  make([]autofunc.RResult, len(in.OutputSeqs())),
+		OutSeqs:  make([][]linalg.Vector, len(in.OutputSeqs())),
+		ROutSeqs: make([][]linalg.Vector, len(in.OutputSeqs())),
+	}
+	for i, seq := range in.OutputSeqs() {
+		if len(seq) == 0 {
+			continue
+		}
+		res.Vars[i] = &autofunc.Variable{Vector: joinColumns(seq)}
+		inR := &autofunc.RVariable{
+			Variable:   res.Vars[i],
+			ROutputVec: joinColumns(in.ROutputSeqs()[i]),
+		}
+		res.Results[i] = applyMDLSTMGridR(rv, m.Sweeps, inR, m.grid(len(seq)))
+		res.OutSeqs[i] = splitColumns(res.Results[i].Output(), len(seq))
+		res.ROutSeqs[i] = splitColumns(res.Results[i].ROutput(), len(seq))
+	}
+	return res
+}
+
+// Parameters returns the parameters of every sweep.
+func (m *MDLSTMSeqFunc) Parameters() []*autofunc.Variable {
+	return mdlstmParameters(m.Sweeps)
+}
+
+// SerializerType returns the unique ID used to serialize
+// an MDLSTMSeqFunc with the serializer package.
+func (m *MDLSTMSeqFunc) SerializerType() string {
+	return "github.com/unixpickle/weakai/rnn.MDLSTMSeqFunc"
+}
+
+// Serialize serializes the MDLSTMSeqFunc.
+func (m *MDLSTMSeqFunc) Serialize() ([]byte, error) {
+	slist := []serializer.Serializer{serializer.Int(m.Height)}
+	for _, s := range m.Sweeps {
+		slist = append(slist, s)
+	}
+	return serializer.SerializeSlice(slist)
+}
+
+func (m *MDLSTMSeqFunc) grid(width int) mdlstmGrid {
+	return mdlstmGrid{Width: width, Height: m.Height, ColumnMajor: true}
+}
+
+type mdlstmSeqResult struct {
+	Input   seqfunc.Result
+	Vars    []*autofunc.Variable
+	Results []autofunc.Result
+	OutSeqs [][]linalg.Vector
+}
+
+func (m *mdlstmSeqResult) OutputSeqs() [][]linalg.Vector {
+	return m.OutSeqs
+}
+
+func (m *mdlstmSeqResult) PropagateGradient(u [][]linalg.Vector, g autofunc.Gradient) {
+	downstream := make([][]linalg.Vector, len(u))
+	for i, seq := range u {
+		if m.Vars[i] == nil {
+			continue
+		}
+		v := m.Vars[i]
+		g[v] = make(linalg.Vector, len(v.Vector))
+		m.Results[i].PropagateGradient(joinColumns(seq), g)
+		downstream[i] = splitColumns(g[v], len(seq))
+		delete(g, v)
+	}
+	m.Input.PropagateGradient(downstream, g)
+}
+
+type mdlstmSeqRResult struct {
+	Input    seqfunc.RResult
+	Vars     []*autofunc.Variable
+	Results  []autofunc.RResult
+	OutSeqs  [][]linalg.Vector
+	ROutSeqs [][]linalg.Vector
+}
+
+func (m *mdlstmSeqRResult) OutputSeqs() [][]linalg.Vector {
+	return m.OutSeqs
+}
+
+func (m *mdlstmSeqRResult) ROutputSeqs() [][]linalg.Vector {
+	return m.ROutSeqs
+}
+
+func (m *mdlstmSeqRResult) PropagateRGradient(u, uR [][]linalg.Vector, rg autofunc.RGradient,
+	g autofunc.Gradient) {
+	if g == nil {
+		g = autofunc.Gradient{}
+	}
+	downstream := make([][]linalg.Vector, len(u))
+	downstreamR := make([][]linalg.Vector, len(u))
+	for i, seq := range u {
+		if m.Vars[i] == nil {
+			continue
+		}
+		v := m.Vars[i]
+		g[v] = make(linalg.Vector, len(v.Vector))
+		rg[v] = make(linalg.Vector, len(v.Vector))
+		m.Results[i].PropagateRGradient(joinColumns(seq), joinColumns(uR[i]), rg, g)
+		downstream[i] = splitColumns(g[v], len(seq))
+		downstreamR[i] = splitColumns(rg[v], len(seq))
+		delete(g, v)
+		delete(rg, v)
+	}
+	m.Input.PropagateRGradient(downstream, downstreamR, rg, g)
+}
+
+func newMDLSTMSweeps(depth, hiddenSize int) []*MDLSTM {
+	res := make([]*MDLSTM, 4)
+	for i := range res {
+		res[i] = NewMDLSTM(depth, hiddenSize)
+	}
+	return res
+}
+
+func deserializeMDLSTMSweeps(slice []serializer.Serializer) ([]*MDLSTM, error) {
+	if len(slice) > 4 {
+		return nil, errors.New("too many MDLSTM sweeps")
+	}
+	var res []*MDLSTM
+	for _, x := range slice {
+		sweep, ok := x.(*MDLSTM)
+		if !ok {
+			return nil, errors.New("invalid MDLSTM sweep type")
+		}
+		res = append(res, sweep)
+	}
+	return res, nil
+}
+
+func mdlstmOutputDepth(sweeps []*MDLSTM) int {
+	var res int
+	for _, s := range sweeps {
+		res += s.HiddenSize()
+	}
+	return res
+}
+
+func mdlstmParameters(sweeps []*MDLSTM) []*autofunc.Variable {
+	var res []*autofunc.Variable
+	for _, s := range sweeps {
+		res = append(res, s.Parameters()...)
+	}
+	return res
+}
+
+func joinColumns(cols []linalg.Vector) linalg.Vector {
+	var res linalg.Vector
+	for _, c := range cols {
+		res = append(res, c...)
+	}
+	return res
+}
+
+func splitColumns(joined linalg.Vector, count int) []linalg.Vector {
+	res := make([]linalg.Vector, count)
+	colSize := len(joined) / count
+	for i := range res {
+		res[i] = joined[i*colSize : (i+1)*colSize]
+	}
+	return res
+}
@@ -0,0 +1,186 @@
+package rnn
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/unixpickle/autofunc"
+	"github.com/unixpickle/serializer"
+	"github.com/unixpickle/weakai/neuralnet"
+)
+
+func init() {
+	var r ResidualBlock
+	serializer.RegisterTypedDeserializer(r.SerializerType(), DeserializeResidualBlock)
+}
+
+// A ResidualBlock is a deep Block which adds the input
+// of each block in the stack to its output before
+// feeding the sum to the next block.
+// These skip connections make it much easier to train
+// deep RNNs.
+//
+// If a block's output size is different from its input
+// size, the input should be projected to the output
+// size with an entry in Projections.
+type ResidualBlock struct {
+	Blocks []Block
+
+	// Projections contains one Network for each block,
+	// which is applied to the block's input before it is
+	// added to the block's output.
+	// An empty Network (or a missing entry) leaves the
+	// input unchanged.
+	Projections []neuralnet.Network
+}
+
+// NewResidualBlock creates a ResidualBlock with linear
+// projections for every block whose input and output
+// sizes differ.
+// The outSizes slice specifies the output size of each
+// block.
+func NewResidualBlock(inSize int, outSizes []int, blocks ...Block) *ResidualBlock {
+	if len(outSizes) != len(blocks) {
+		panic("output size count must match block count")
+	}
+	res := &ResidualBlock{
+		Blocks:      blocks,
+		Projections: make([]neuralnet.Network, len(blocks)),
+	}
+	for i, outSize := range outSizes {
+		if outSize != inSize {
+			res.Projections[i] = neuralnet.Network{
+				&neuralnet.DenseLayer{InputCount: inSize, OutputCount: outSize},
+			}
+			res.Projections[i].Randomize()
+		}
+		inSize = outSize
+	}
+	return res
+}
+
+// NewResidualLSTM creates a ResidualBlock made up of
+// depth LSTMs with the given hidden size.
+func NewResidualLSTM(inSize, hiddenSize, depth int) *ResidualBlock {
+	blocks := make([]Block, depth)
+	outSizes := make([]int, depth)
+	for i := range blocks {
+		if i == 0 {
+			blocks[i] = NewLSTM(inSize, hiddenSize)
+		} else {
+			blocks[i] = NewLSTM(hiddenSize, hiddenSize)
+		}
+		outSizes[i] = hiddenSize
+	}
+	return NewResidualBlock(inSize, outSizes, blocks...)
+}
+
+// DeserializeResidualBlock deserializes a ResidualBlock.
+func DeserializeResidualBlock(d []byte) (*ResidualBlock, error) {
+	list, err := serializer.DeserializeSlice(d)
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, errors.New("invalid ResidualBlock slice length")
+	}
+	blocks, ok := list[0].(StackedBlock)
+	if !ok {
+		return nil, fmt.Errorf("invalid blocks type: %T", list[0])
+	}
+	res := &ResidualBlock{Blocks: blocks}
+	for i, x := range list[1:] {
+		proj, ok := x.(neuralnet.Network)
+		if !ok {
+			return nil, fmt.Errorf("projection %d (%T) is not a Network", i, x)
+		}
+		res.Projections = append(res.Projections, proj)
+	}
+	return res, nil
+}
+
+// StartState generates a start state which encapsulates
+// the start states of all the nested blocks.
+func (r *ResidualBlock) StartState() State {
+	return StackedBlock(r.Blocks).StartState()
+}
+
+// StartRState is like StartState.
+func (r *ResidualBlock) StartRState(rv autofunc.RVector) RState {
+	return StackedBlock(r.Blocks).StartRState(rv)
+}
+
+// PropagateStart back-propagates through all the child
+// Blocks.
+func (r *ResidualBlock) PropagateStart(s []State, u []StateGrad, g autofunc.Gradient) {
+	StackedBlock(r.Blocks).PropagateStart(s, u, g)
+}
+
+// PropagateStartR is like PropagateStart.
+func (r *ResidualBlock) PropagateStartR(s []RState, u []RStateGrad, rg autofunc.RGradient,
+	g autofunc.Gradient) {
+	StackedBlock(r.Blocks).PropagateStartR(s, u, rg, g)
+}
+
+// ApplyBlock applies the stack of blocks.
+func (r *ResidualBlock) ApplyBlock(s []State, in []autofunc.Result) BlockResult {
+	r.assertNotEmpty()
+	return applySkipStack(r.Blocks, r, s, in)
+}
+
+// ApplyBlockR is like ApplyBlock, but with R-operator
+// support.
+func (r *ResidualBlock) ApplyBlockR(rv autofunc.RVector, s []RState,
+	in []autofunc.RResult) BlockRResult {
+	r.assertNotEmpty()
+	return applySkipStackR(rv, r.Blocks, r, s, in)
+}
+
+// combine adds a block's input to its output, applying
+// the block's projection if it has one.
+func (r *ResidualBlock) combine(layer int, in, out autofunc.Result) autofunc.Result {
+	if layer < len(r.Projections) {
+		in = r.Projections[layer].Apply(in)
+	}
+	return autofunc.Add(in, out)
+}
+
+func (r *ResidualBlock) combineR(rv autofunc.RVector, layer int,
+	in, out autofunc.RResult) autofunc.RResult {
+	if layer < len(r.Projections) {
+		in = r.Projections[layer].ApplyR(rv, in)
+	}
+	return autofunc.AddR(in, out)
+}
+
+// Parameters returns the parameters of every Learner
+// sub-block and of every projection.
+func (r *ResidualBlock) Parameters() []*autofunc.Variable {
+	res := StackedBlock(r.Blocks).Parameters()
+	for _, p := range r.Projections {
+		res = append(res, p.Parameters()...)
+	}
+	return res
+}
+
+// Serialize attempts to serialize the sub-blocks and
+// projections.
+func (r *ResidualBlock) Serialize() ([]byte, error) {
+	slice := []serializer.Serializer{StackedBlock(r.Blocks)}
+	for _, p := range r.Projections {
+		slice = append(slice, p)
+	}
+	return serializer.SerializeSlice(slice)
+}
+
+// SerializerType returns the unique ID used to serialize
+// a ResidualBlock with the serializer package.
+func (r *ResidualBlock) SerializerType() string {
+	return "github.com/unixpickle/weakai/rnn.ResidualBlock"
+}
+
+func (r *ResidualBlock) assertNotEmpty() {
+	if len(r.Blocks) == 0 {
+		panic("cannot use an empty ResidualBlock")
+	}
+}
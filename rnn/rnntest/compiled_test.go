@@ -0,0 +1,92 @@
+package rnntest
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/unixpickle/num-analysis/linalg"
+	"github.com/unixpickle/weakai/neuralnet"
+	"github.com/unixpickle/weakai/rnn"
+)
+
+func TestCompiledLSTM(t *testing.T) {
+	testCompiledBlock(t, rnn.NewLSTM(3, 4), 3)
+}
+
+func TestCompiledNetworkBlock(t *testing.T) {
+	net := neuralnet.Network{
+		neuralnet.NewDenseLayer(3+2, 5),
+		&neuralnet.HyperbolicTangent{},
+	}
+	testCompiledBlock(t, rnn.NewNetworkBlock(net, 2), 3)
+}
+
+func TestCompiledStackedBlock(t *testing.T) {
+	net := neuralnet.Network{
+		neuralnet.NewDenseLayer(4+3, 5),
+		&neuralnet.Sigmoid{},
+	}
+	block := rnn.StackedBlock{
+		rnn.NewLSTM(2, 4),
+		rnn.NewNetworkBlock(net, 3),
+		rnn.NewLSTM(2, 3),
+	}
+	testCompiledBlock(t, block, 2)
+}
+
+func TestCompiledBlockAllocs(t *testing.T) {
+	compiled, err := rnn.CompileBlock(rnn.NewLSTM(3, 4), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner := compiled.NewRunner()
+	in := compiledTestVec(3)
+	allocs := testing.AllocsPerRun(100, func() {
+		runner.StepTime(in)
+	})
+	if allocs != 0 {
+		t.Errorf("expected 0 allocations but got %f", allocs)
+	}
+}
+
+func testCompiledBlock(t *testing.T, b rnn.Block, inSize int) {
+	compiled, err := rnn.CompileBlock(b, inSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compiledRunner := compiled.NewRunner()
+	runner := &rnn.Runner{Block: b}
+	for reset := 0; reset < 2; reset++ {
+		for i := 0; i < 5; i++ {
+			in := compiledTestVec(inSize)
+			expected := runner.StepTime(in)
+			actual := compiledRunner.StepTime(in)
+			if !compiledVecsClose(actual, expected) {
+				t.Fatalf("step %d: expected %v but got %v", i, expected, actual)
+			}
+		}
+		runner.Reset()
+		compiledRunner.Reset()
+	}
+}
+
+func compiledTestVec(size int) linalg.Vector {
+	res := make(linalg.Vector, size)
+	for i := range res {
+		res[i] = rand.NormFloat64()
+	}
+	return res
+}
+
+func compiledVecsClose(v1, v2 linalg.Vector) bool {
+	if len(v1) != len(v2) {
+		return false
+	}
+	for i, x := range v1 {
+		if math.Abs(x-v2[i]) > 1e-8 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,17 @@
+package rnntest
+
+import (
+	"testing"
+
+	"github.com/unixpickle/weakai/rnn"
+)
+
+func TestDenseBlock(t *testing.T) {
+	b := rnn.DenseBlock{rnn.NewLSTM(4, 3), rnn.NewLSTM(7, 2), rnn.NewLSTM(9, 3)}
+	NewChecker4In(b, b).FullCheck(t)
+}
+
+func TestDenseBlockSerialize(t *testing.T) {
+	b := rnn.DenseBlock{rnn.NewLSTM(4, 3), rnn.NewLSTM(7, 2), rnn.NewLSTM(9, 3)}
+	testSerialize(t, b)
+}
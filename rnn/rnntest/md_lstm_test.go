@@ -0,0 +1,99 @@
+package rnntest
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/unixpickle/autofunc"
+	"github.com/unixpickle/autofunc/functest"
+	"github.com/unixpickle/autofunc/seqfunc"
+	"github.com/unixpickle/num-analysis/linalg"
+	"github.com/unixpickle/weakai/rnn"
+)
+
+func TestMDLSTMLayer(t *testing.T) {
+	layer := rnn.NewMDLSTMLayer(3, 2, 2, 2)
+	in := &autofunc.Variable{Vector: mdlstmRandVec(3 * 2 * 2)}
+	vars := append([]*autofunc.Variable{in}, layer.Parameters()...)
+	rv := autofunc.RVector{}
+	for _, v := range vars {
+		rv[v] = mdlstmRandVec(len(v.Vector))
+	}
+	checker := &functest.RFuncChecker{
+		F:     layer,
+		Vars:  vars,
+		Input: in,
+		RV:    rv,
+	}
+	checker.FullCheck(t)
+}
+
+func TestMDLSTMSeqFunc(t *testing.T) {
+	f := rnn.NewMDLSTMSeqFunc(2, 2, 2)
+	vars := append([]*autofunc.Variable{}, f.Parameters()...)
+	rv := autofunc.RVector{}
+	var seqs [][]*autofunc.Variable
+	for _, length := range []int{0, 1, 3} {
+		var seq []*autofunc.Variable
+		for i := 0; i < length; i++ {
+			v := &autofunc.Variable{Vector: mdlstmRandVec(2 * 2)}
+			seq = append(seq, v)
+			vars = append(vars, v)
+		}
+		seqs = append(seqs, seq)
+	}
+	for _, v := range vars {
+		rv[v] = mdlstmRandVec(len(v.Vector))
+	}
+	checker := &functest.SeqRFuncChecker{
+		F:     f,
+		Vars:  vars,
+		Input: seqs,
+		RV:    rv,
+	}
+	checker.FullCheck(t)
+}
+
+func TestMDLSTMSeqFuncLayout(t *testing.T) {
+	const width, height, depth = 4, 3, 2
+	layer := rnn.NewMDLSTMLayer(width, height, depth, 3)
+	seqFunc := &rnn.MDLSTMSeqFunc{Height: height, Sweeps: layer.Sweeps}
+
+	tensor := mdlstmRandVec(width * height * depth)
+	var columns []linalg.Vector
+	for x := 0; x < width; x++ {
+		var col linalg.Vector
+		for y := 0; y < height; y++ {
+			idx := (y*width + x) * depth
+			col = append(col, tensor[idx:idx+depth]...)
+		}
+		columns = append(columns, col)
+	}
+
+	layerOut := layer.Apply(&autofunc.Variable{Vector: tensor}).Output()
+	seqIn := seqfunc.ConstResult([][]linalg.Vector{columns})
+	seqOut := seqFunc.ApplySeqs(seqIn).OutputSeqs()[0]
+
+	outDepth := layer.OutputDepth()
+	for x, col := range seqOut {
+		for y := 0; y < height; y++ {
+			for z := 0; z < outDepth; z++ {
+				expected := layerOut[(y*width+x)*outDepth+z]
+				actual := col[y*outDepth+z]
+				if math.Abs(expected-actual) > 1e-8 {
+					t.Fatalf("output (%d,%d,%d): expected %f but got %f",
+						x, y, z, expected, actual)
+				}
+			}
+		}
+	}
+}
+
+func mdlstmRandVec(size int) linalg.Vector {
+	res := make(linalg.Vector, size)
+	for i := range res {
+		res[i] = rand.NormFloat64()
+	}
+	return res
+}
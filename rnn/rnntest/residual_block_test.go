@@ -0,0 +1,27 @@
+package rnntest
+
+import (
+	"testing"
+
+	"github.com/unixpickle/weakai/rnn"
+)
+
+func TestResidualBlock(t *testing.T) {
+	b := rnn.NewResidualBlock(4, []int{4, 3, 3},
+		rnn.NewLSTM(4, 4), rnn.NewLSTM(4, 3), rnn.NewLSTM(3, 3))
+	NewChecker4In(b, b).FullCheck(t)
+}
+
+func TestResidualLSTM(t *testing.T) {
+	b := rnn.NewResidualLSTM(4, 2, 3)
+	NewChecker4In(b, b).FullCheck(t)
+}
+
+func TestResidualBlockSerialize(t *testing.T) {
+	b := rnn.NewResidualBlock(4, []int{4, 3, 3},
+		rnn.NewLSTM(4, 4), rnn.NewLSTM(4, 3), rnn.NewLSTM(3, 3))
+	if b.Projections[0] != nil || b.Projections[1] == nil || b.Projections[2] != nil {
+		t.Fatal("unexpected projections")
+	}
+	testSerialize(t, b)
+}
@@ -0,0 +1,45 @@
+package rnntest
+
+import (
+	"math"
+	"testing"
+
+	"github.com/unixpickle/autofunc/seqfunc"
+	"github.com/unixpickle/num-analysis/linalg"
+	"github.com/unixpickle/serializer"
+	"github.com/unixpickle/weakai/rnn"
+)
+
+// testSerialize checks that a block produces the same
+// outputs after being serialized and deserialized.
+func testSerialize(t *testing.T, b rnn.Block) {
+	data, err := serializer.SerializeWithType(b.(serializer.Serializer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj, err := serializer.DeserializeWithType(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, ok := obj.(rnn.Block)
+	if !ok {
+		t.Fatalf("unexpected type: %T", obj)
+	}
+
+	inSeqs := [][]linalg.Vector{
+		{{0.098591, -0.595453, -0.751214, 0.266051}, {0.988517, 0.107284, -0.331529, 0.028565}},
+		{{-0.150604, 0.889039, 0.120916, 0.240999}},
+	}
+	expected := (&rnn.BlockSeqFunc{B: b}).ApplySeqs(seqfunc.ConstResult(inSeqs)).OutputSeqs()
+	actual := (&rnn.BlockSeqFunc{B: decoded}).ApplySeqs(seqfunc.ConstResult(inSeqs)).OutputSeqs()
+	for i, seq := range expected {
+		for j, vec := range seq {
+			for k, x := range vec {
+				if math.Abs(actual[i][j][k]-x) > 1e-10 {
+					t.Fatalf("seq %d time %d: expected %v but got %v", i, j, vec,
+						actual[i][j])
+				}
+			}
+		}
+	}
+}
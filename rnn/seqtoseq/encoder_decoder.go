@@ -0,0 +1,536 @@
+package seqtoseq
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/unixpickle/autofunc"
+	"github.com/unixpickle/autofunc/seqfunc"
+	"github.com/unixpickle/num-analysis/linalg"
+	"github.com/unixpickle/serializer"
+	"github.com/unixpickle/sgd"
+	"github.com/unixpickle/weakai/rnn"
+)
+
+func init() {
+	var e EncoderDecoder
+	serializer.RegisterTypedDeserializer(e.SerializerType(), DeserializeEncoderDecoder)
+}
+
+// An EncoderDecoder is a seqfunc.RFunc which reads an
+// input sequence with an encoder Block and then uses
+// the encoder's final State as the start State for a
+// decoder Block.
+// Thus, the encoder and decoder must use compatible
+// State types (e.g. two LSTMs with the same number of
+// hidden units).
+//
+// Each input sequence to an EncoderDecoder is the
+// encoder's input, followed by an empty vector, followed
+// by the decoder's input.
+// The output sequence contains one output per decoder
+// input.
+// This makes it possible to train an EncoderDecoder
+// with a Gradienter using samples produced by
+// TeacherForcingSample.
+type EncoderDecoder struct {
+	Encoder rnn.Block
+	Decoder rnn.Block
+}
+
+// DeserializeEncoderDecoder deserializes an
+// EncoderDecoder.
+func DeserializeEncoderDecoder(d []byte) (*EncoderDecoder, error) {
+	slice, err := serializer.DeserializeSlice(d)
+	if err != nil {
+		return nil, err
+	}
+	if len(slice) != 2 {
+		return nil, errors.New("invalid EncoderDecoder slice length")
+	}
+	encoder, ok1 := slice[0].(rnn.Block)
+	decoder, ok2 := slice[1].(rnn.Block)
+	if !ok1 || !ok2 {
+		return nil, errors.New("invalid EncoderDecoder slice types")
+	}
+	return &EncoderDecoder{Encoder: encoder, Decoder: decoder}, nil
+}
+
+// TeacherForcingSample creates a training Sample for
+// an EncoderDecoder.
+// The decoder is fed the start vector followed by every
+// target except for the last one, so that it learns to
+// predict each target from the previous ones.
+func TeacherForcingSample(inputs, targets []linalg.Vector, start linalg.Vector) Sample {
+	packed := make([]linalg.Vector, 0, len(inputs)+len(targets)+1)
+	packed = append(packed, inputs...)
+	packed = append(packed, linalg.Vector{})
+	if len(targets) > 0 {
+		packed = append(packed, start)
+		packed = append(packed, targets[:len(targets)-1]...)
+	}
+	return Sample{Inputs: packed, Outputs: targets}
+}
+
+// ApplySeqs applies the encoder and decoder to a batch
+// of packed input sequences.
+func (e *EncoderDecoder) ApplySeqs(in seqfunc.Result) seqfunc.Result {
+	seqs := in.OutputSeqs()
+	res := &encoderDecoderResult{
+		Encoder:  e.Encoder,
+		Decoder:  e.Decoder,
+		Input:    in,
+		InPool:   make([][]*autofunc.Variable, len(seqs)),
+		EncStart: make([]rnn.State, len(seqs)),
+	}
+	encIns := make([][]*autofunc.Variable, len(seqs))
+	decIns := make([][]*autofunc.Variable, len(seqs))
+	for lane, seq := range seqs {
+		res.InPool[lane], encIns[lane], decIns[lane] = poolPackedSeq(seq)
+		res.EncStart[lane] = e.Encoder.StartState()
+	}
+	res.EncLens = seqLengths(encIns)
+	res.DecLens = seqLengths(decIns)
+
+	states := append([]rnn.State{}, res.EncStart...)
+	res.EncSteps, _ = applySteps(e.Encoder, states, encIns, res.EncLens)
+	res.DecSteps, res.Output = applySteps(e.Decoder, states, decIns, res.DecLens)
+	return res
+}
+
+// ApplySeqsR is like ApplySeqs, but with R-operator
+// support.
+func (e *EncoderDecoder) ApplySeqsR(rv autofunc.RVector, in seqfunc.RResult) seqfunc.RResult {
+	seqs := in.OutputSeqs()
+	rSeqs := in.ROutputSeqs()
+	res := &encoderDecoderRResult{
+		Encoder:  e.Encoder,
+		Decoder:  e.Decoder,
+		Input:    in,
+		InPool:   make([][]*autofunc.Variable, len(seqs)),
+		EncStart: make([]rnn.RState, len(seqs)),
+	}
+	encIns := make([][]*autofunc.RVariable, len(seqs))
+	decIns := make([][]*autofunc.RVariable, len(seqs))
+	for lane, seq := range seqs {
+		var encVars, decVars []*autofunc.Variable
+		res.InPool[lane], encVars, decVars = poolPackedSeq(seq)
+		encIns[lane] = rVariables(encVars, rSeqs[lane][:len(encVars)])
+		decIns[lane] = rVariables(decVars, rSeqs[lane][len(encVars)+1:])
+		res.EncStart[lane] = e.Encoder.StartRState(rv)
+	}
+	res.EncLens = rSeqLengths(encIns)
+	res.DecLens = rSeqLengths(decIns)
+
+	states := append([]rnn.RState{}, res.EncStart...)
+	res.EncSteps, _, _ = applyStepsR(rv, e.Encoder, states, encIns, res.EncLens)
+	res.DecSteps, res.Output, res.ROutput = applyStepsR(rv, e.Decoder, states, decIns,
+		res.DecLens)
+	return res
+}
+
+// Decode runs the EncoderDecoder on a single input
+// sequence without teacher forcing.
+//
+// The decoder is first fed the start vector.
+// After each decoder step, next is called with the
+// decoder's output to get the next decoder input, and
+// decoding stops once next returns done.
+// Decoding also stops after maxLen outputs if maxLen is
+// non-zero.
+//
+// The result includes every output of the decoder,
+// including the final output for which next returned
+// done.
+func (e *EncoderDecoder) Decode(inputs []linalg.Vector, start linalg.Vector, maxLen int,
+	next func(out linalg.Vector) (in linalg.Vector, done bool)) []linalg.Vector {
+	state := e.Encoder.StartState()
+	for _, x := range inputs {
+		out := e.Encoder.ApplyBlock([]rnn.State{state},
+			[]autofunc.Result{&autofunc.Variable{Vector: x}})
+		state = out.States()[0]
+	}
+	var res []linalg.Vector
+	decIn := start
+	for maxLen == 0 || len(res) < maxLen {
+		out := e.Decoder.ApplyBlock([]rnn.State{state},
+			[]autofunc.Result{&autofunc.Variable{Vector: decIn}})
+		state = out.States()[0]
+		res = append(res, out.Outputs()[0])
+		var done bool
+		decIn, done = next(out.Outputs()[0])
+		if done {
+			break
+		}
+	}
+	return res
+}
+
+// DecodeTokens uses Decode to greedily generate a
+// sequence of tokens.
+//
+// Tokens are fed to the decoder as one-hot vectors, and
+// each decoder output should have one component per
+// token (e.g. log probabilities).
+// At each step, the token with the largest output is
+// chosen.
+// Decoding stops after endToken is produced, and the
+// resulting sequence does not include endToken.
+func (e *EncoderDecoder) DecodeTokens(inputs []linalg.Vector, numTokens, startToken,
+	endToken, maxLen int) []int {
+	var res []int
+	e.Decode(inputs, oneHot(numTokens, startToken), maxLen,
+		func(out linalg.Vector) (linalg.Vector, bool) {
+			var token int
+			for i, x := range out {
+				if x > out[token] {
+					token = i
+				}
+			}
+			if token == endToken {
+				return nil, true
+			}
+			res = append(res, token)
+			return oneHot(numTokens, token), false
+		})
+	return res
+}
+
+// Parameters returns the parameters of the encoder and
+// decoder, ignoring any Block which does not implement
+// sgd.Learner.
+// Parameters shared by the two Blocks are only
+// included once.
+func (e *EncoderDecoder) Parameters() []*autofunc.Variable {
+	var res []*autofunc.Variable
+	seen := map[*autofunc.Variable]bool{}
+	for _, b := range []rnn.Block{e.Encoder, e.Decoder} {
+		if l, ok := b.(sgd.Learner); ok {
+			for _, p := range l.Parameters() {
+				if !seen[p] {
+					seen[p] = true
+					res = append(res, p)
+				}
+			}
+		}
+	}
+	return res
+}
+
+// SerializerType returns the unique ID used to serialize
+// an EncoderDecoder with the serializer package.
+func (e *EncoderDecoder) SerializerType() string {
+	return "github.com/unixpickle/weakai/rnn/seqtoseq.EncoderDecoder"
+}
+
+// Serialize serializes the encoder and decoder.
+// This fails if either Block is not a
+// serializer.Serializer.
+func (e *EncoderDecoder) Serialize() ([]byte, error) {
+	var slice []serializer.Serializer
+	for _, b := range []rnn.Block{e.Encoder, e.Decoder} {
+		s, ok := b.(serializer.Serializer)
+		if !ok {
+			return nil, fmt.Errorf("type is not a Serializer: %T", b)
+		}
+		slice = append(slice, s)
+	}
+	return serializer.SerializeSlice(slice)
+}
+
+type encoderDecoderResult struct {
+	Encoder rnn.Block
+	Decoder rnn.Block
+	Input   seqfunc.Result
+	InPool  [][]*autofunc.Variable
+
+	EncStart []rnn.State
+	EncLens  []int
+	DecLens  []int
+	EncSteps []rnn.BlockResult
+	DecSteps []rnn.BlockResult
+	Output   [][]linalg.Vector
+}
+
+func (e *encoderDecoderResult) OutputSeqs() [][]linalg.Vector {
+	return e.Output
+}
+
+func (e *encoderDecoderResult) PropagateGradient(u [][]linalg.Vector, g autofunc.Gradient) {
+	for _, poolSeq := range e.InPool {
+		for _, poolVar := range poolSeq {
+			g[poolVar] = make(linalg.Vector, len(poolVar.Vector))
+		}
+	}
+
+	stateGrads := make([]rnn.StateGrad, len(e.InPool))
+	propagateSteps(e.DecSteps, e.DecLens, u, stateGrads, g)
+	propagateSteps(e.EncSteps, e.EncLens, nil, stateGrads, g)
+
+	var starts []rnn.State
+	var startGrads []rnn.StateGrad
+	for lane, grad := range stateGrads {
+		if grad != nil {
+			starts = append(starts, e.EncStart[lane])
+			startGrads = append(startGrads, grad)
+		}
+	}
+	if len(startGrads) > 0 {
+		e.Encoder.PropagateStart(starts, startGrads, g)
+	}
+
+	downstream := make([][]linalg.Vector, len(e.InPool))
+	for i, poolSeq := range e.InPool {
+		downstream[i] = make([]linalg.Vector, len(poolSeq))
+		for j, poolVar := range poolSeq {
+			downstream[i][j] = g[poolVar]
+			delete(g, poolVar)
+		}
+	}
+	e.Input.PropagateGradient(downstream, g)
+}
+
+type encoderDecoderRResult struct {
+	Encoder rnn.Block
+	Decoder rnn.Block
+	Input   seqfunc.RResult
+	InPool  [][]*autofunc.Variable
+
+	EncStart []rnn.RState
+	EncLens  []int
+	DecLens  []int
+	EncSteps []rnn.BlockRResult
+	DecSteps []rnn.BlockRResult
+	Output   [][]linalg.Vector
+	ROutput  [][]linalg.Vector
+}
+
+func (e *encoderDecoderRResult) OutputSeqs() [][]linalg.Vector {
+	return e.Output
+}
+
+func (e *encoderDecoderRResult) ROutputSeqs() [][]linalg.Vector {
+	return e.ROutput
+}
+
+func (e *encoderDecoderRResult) PropagateRGradient(u, uR [][]linalg.Vector,
+	rg autofunc.RGradient, g autofunc.Gradient) {
+	if g == nil {
+		g = autofunc.Gradient{}
+	}
+	for _, poolSeq := range e.InPool {
+		for _, poolVar := range poolSeq {
+			g[poolVar] = make(linalg.Vector, len(poolVar.Vector))
+			rg[poolVar] = make(linalg.Vector, len(poolVar.Vector))
+		}
+	}
+
+	stateGrads := make([]rnn.RStateGrad, len(e.InPool))
+	propagateStepsR(e.DecSteps, e.DecLens, u, uR, stateGrads, rg, g)
+	propagateStepsR(e.EncSteps, e.EncLens, nil, nil, stateGrads, rg, g)
+
+	var starts []rnn.RState
+	var startGrads []rnn.RStateGrad
+	for lane, grad := range stateGrads {
+		if grad != nil {
+			starts = append(starts, e.EncStart[lane])
+			startGrads = append(startGrads, grad)
+		}
+	}
+	if len(startGrads) > 0 {
+		e.Encoder.PropagateStartR(starts, startGrads, rg, g)
+	}
+
+	downstream := make([][]linalg.Vector, len(e.InPool))
+	downstreamR := make([][]linalg.Vector, len(e.InPool))
+	for i, poolSeq := range e.InPool {
+		downstream[i] = make([]linalg.Vector, len(poolSeq))
+		downstreamR[i] = make([]linalg.Vector, len(poolSeq))
+		for j, poolVar := range poolSeq {
+			downstream[i][j] = g[poolVar]
+			downstreamR[i][j] = rg[poolVar]
+			delete(g, poolVar)
+			delete(rg, poolVar)
+		}
+	}
+	e.Input.PropagateRGradient(downstream, downstreamR, rg, g)
+}
+
+// poolPackedSeq creates a pool variable for each vector
+// in a packed sequence and splits the variables into
+// encoder and decoder inputs.
+func poolPackedSeq(seq []linalg.Vector) (pool, enc, dec []*autofunc.Variable) {
+	sep := -1
+	for i, x := range seq {
+		pool = append(pool, &autofunc.Variable{Vector: x})
+		if sep < 0 && len(x) == 0 {
+			sep = i
+		}
+	}
+	if sep < 0 {
+		panic("packed sequence is missing empty separator")
+	}
+	return pool, pool[:sep], pool[sep+1:]
+}
+
+func rVariables(vars []*autofunc.Variable, rVecs []linalg.Vector) []*autofunc.RVariable {
+	res := make([]*autofunc.RVariable, len(vars))
+	for i, v := range vars {
+		res[i] = &autofunc.RVariable{Variable: v, ROutputVec: rVecs[i]}
+	}
+	return res
+}
+
+// applySteps runs a Block on a batch of sequences,
+// starting from the given states.
+// The states slice is updated to hold the final state
+// of each lane.
+func applySteps(b rnn.Block, states []rnn.State, seqs [][]*autofunc.Variable,
+	lens []int) ([]rnn.BlockResult, [][]linalg.Vector) {
+	var steps []rnn.BlockResult
+	outputs := make([][]linalg.Vector, len(seqs))
+	for t := 0; t < maxLength(lens); t++ {
+		var stateIn []rnn.State
+		var resIn []autofunc.Result
+		for lane, seq := range seqs {
+			if len(seq) > t {
+				stateIn = append(stateIn, states[lane])
+				resIn = append(resIn, seq[t])
+			}
+		}
+		out := b.ApplyBlock(stateIn, resIn)
+		steps = append(steps, out)
+		outVecs := out.Outputs()
+		outStates := out.States()
+		for lane, seq := range seqs {
+			if len(seq) > t {
+				outputs[lane] = append(outputs[lane], outVecs[0])
+				states[lane] = outStates[0]
+				outVecs = outVecs[1:]
+				outStates = outStates[1:]
+			}
+		}
+	}
+	return steps, outputs
+}
+
+// applyStepsR is like applySteps, but for RStates.
+func applyStepsR(rv autofunc.RVector, b rnn.Block, states []rnn.RState,
+	seqs [][]*autofunc.RVariable, lens []int) (steps []rnn.BlockRResult, outputs,
+	rOutputs [][]linalg.Vector) {
+	outputs = make([][]linalg.Vector, len(seqs))
+	rOutputs = make([][]linalg.Vector, len(seqs))
+	for t := 0; t < maxLength(lens); t++ {
+		var stateIn []rnn.RState
+		var resIn []autofunc.RResult
+		for lane, seq := range seqs {
+			if len(seq) > t {
+				stateIn = append(stateIn, states[lane])
+				resIn = append(resIn, seq[t])
+			}
+		}
+		out := b.ApplyBlockR(rv, stateIn, resIn)
+		steps = append(steps, out)
+		outVecs := out.Outputs()
+		outVecsR := out.ROutputs()
+		outStates := out.RStates()
+		for lane, seq := range seqs {
+			if len(seq) > t {
+				outputs[lane] = append(outputs[lane], outVecs[0])
+				rOutputs[lane] = append(rOutputs[lane], outVecsR[0])
+				states[lane] = outStates[0]
+				outVecs = outVecs[1:]
+				outVecsR = outVecsR[1:]
+				outStates = outStates[1:]
+			}
+		}
+	}
+	return
+}
+
+// propagateSteps back-propagates through the results
+// of applySteps.
+// The stateGrads slice should contain the upstream
+// gradient for each lane's final state, and it is
+// updated to hold the gradient of each start state.
+// If u is nil, the outputs are treated as constants.
+func propagateSteps(steps []rnn.BlockResult, lens []int, u [][]linalg.Vector,
+	stateGrads []rnn.StateGrad, g autofunc.Gradient) {
+	for t := len(steps) - 1; t >= 0; t-- {
+		var upstream []linalg.Vector
+		var upstreamStates []rnn.StateGrad
+		for lane, l := range lens {
+			if l > t {
+				if u != nil {
+					upstream = append(upstream, u[lane][t])
+				}
+				upstreamStates = append(upstreamStates, stateGrads[lane])
+			}
+		}
+		down := steps[t].PropagateGradient(upstream, upstreamStates, g)
+		for lane, l := range lens {
+			if l > t {
+				stateGrads[lane] = down[0]
+				down = down[1:]
+			}
+		}
+	}
+}
+
+// propagateStepsR is like propagateSteps, but for
+// RStateGrads.
+func propagateStepsR(steps []rnn.BlockRResult, lens []int, u, uR [][]linalg.Vector,
+	stateGrads []rnn.RStateGrad, rg autofunc.RGradient, g autofunc.Gradient) {
+	for t := len(steps) - 1; t >= 0; t-- {
+		var upstream, upstreamR []linalg.Vector
+		var upstreamStates []rnn.RStateGrad
+		for lane, l := range lens {
+			if l > t {
+				if u != nil {
+					upstream = append(upstream, u[lane][t])
+					upstreamR = append(upstreamR, uR[lane][t])
+				}
+				upstreamStates = append(upstreamStates, stateGrads[lane])
+			}
+		}
+		down := steps[t].PropagateRGradient(upstream, upstreamR, upstreamStates, rg, g)
+		for lane, l := range lens {
+			if l > t {
+				stateGrads[lane] = down[0]
+				down = down[1:]
+			}
+		}
+	}
+}
+
+func seqLengths(seqs [][]*autofunc.Variable) []int {
+	res := make([]int, len(seqs))
+	for i, s := range seqs {
+		res[i] = len(s)
+	}
+	return res
+}
+
+func rSeqLengths(seqs [][]*autofunc.RVariable) []int {
+	res := make([]int, len(seqs))
+	for i, s := range seqs {
+		res[i] = len(s)
+	}
+	return res
+}
+
+func maxLength(lens []int) int {
+	var res int
+	for _, l := range lens {
+		if l > res {
+			res = l
+		}
+	}
+	return res
+}
+
+func oneHot(size, idx int) linalg.Vector {
+	res := make(linalg.Vector, size)
+	res[idx] = 1
+	return res
+}
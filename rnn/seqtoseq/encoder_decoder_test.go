@@ -0,0 +1,133 @@
+package seqtoseq
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/unixpickle/autofunc"
+	"github.com/unixpickle/autofunc/functest"
+	"github.com/unixpickle/num-analysis/linalg"
+	"github.com/unixpickle/serializer"
+	"github.com/unixpickle/sgd"
+	"github.com/unixpickle/weakai/neuralnet"
+	"github.com/unixpickle/weakai/rnn"
+)
+
+func TestEncoderDecoderGradients(t *testing.T) {
+	encDec := &EncoderDecoder{
+		Encoder: rnn.NewLSTM(2, 4),
+		Decoder: rnn.NewLSTM(3, 4),
+	}
+	rv := autofunc.RVector{}
+	vars := append([]*autofunc.Variable{}, encDec.Parameters()...)
+	for _, v := range vars {
+		rv[v] = randomVector(len(v.Vector))
+	}
+
+	// Lanes with empty encoder or decoder inputs help
+	// test for certain edge cases.
+	lens := [][2]int{{0, 2}, {3, 0}, {2, 3}, {1, 1}}
+	var seqs [][]*autofunc.Variable
+	for _, l := range lens {
+		var seq []*autofunc.Variable
+		for i := 0; i < l[0]; i++ {
+			seq = append(seq, &autofunc.Variable{Vector: randomVector(2)})
+		}
+		seq = append(seq, &autofunc.Variable{Vector: linalg.Vector{}})
+		for i := 0; i < l[1]; i++ {
+			seq = append(seq, &autofunc.Variable{Vector: randomVector(3)})
+		}
+		for _, v := range seq {
+			rv[v] = randomVector(len(v.Vector))
+			vars = append(vars, v)
+		}
+		seqs = append(seqs, seq)
+	}
+
+	checker := &functest.SeqRFuncChecker{
+		F:     encDec,
+		Vars:  vars,
+		Input: seqs,
+		RV:    rv,
+	}
+	checker.FullCheck(t)
+}
+
+func TestEncoderDecoderTraining(t *testing.T) {
+	// Tokens 0 and 1 are symbols, 2 is the start token,
+	// and 3 is the end token.
+	// The model must repeat the input symbol twice.
+	var samples sgd.SliceSampleSet
+	for _, symbol := range []int{0, 1} {
+		inputs := []linalg.Vector{oneHot(2, symbol)}
+		targets := []linalg.Vector{oneHot(4, symbol), oneHot(4, symbol), oneHot(4, 3)}
+		samples = append(samples, TeacherForcingSample(inputs, targets, oneHot(4, 2)))
+	}
+
+	encDec := &EncoderDecoder{
+		Encoder: encoderDecoderTestBlock(2),
+		Decoder: encoderDecoderTestBlock(4),
+	}
+	g := &Gradienter{
+		SeqFunc:  encDec,
+		Learner:  encDec,
+		CostFunc: neuralnet.DotCost{},
+	}
+	sgd.SGD(g, samples, 0.1, 300, 2)
+
+	for _, symbol := range []int{0, 1} {
+		actual := encDec.DecodeTokens([]linalg.Vector{oneHot(2, symbol)}, 4, 2, 3, 10)
+		expected := []int{symbol, symbol}
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("symbol %d: expected %v but got %v", symbol, expected, actual)
+		}
+	}
+}
+
+func TestEncoderDecoderSerialize(t *testing.T) {
+	encDec := &EncoderDecoder{
+		Encoder: rnn.NewLSTM(2, 4),
+		Decoder: rnn.NewLSTM(3, 4),
+	}
+	data, err := serializer.SerializeWithType(encDec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj, err := serializer.DeserializeWithType(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, ok := obj.(*EncoderDecoder)
+	if !ok {
+		t.Fatalf("unexpected type: %T", obj)
+	}
+	if len(decoded.Parameters()) != len(encDec.Parameters()) {
+		t.Fatal("parameter count mismatch")
+	}
+	for i, p := range decoded.Parameters() {
+		if !reflect.DeepEqual(p.Vector, encDec.Parameters()[i].Vector) {
+			t.Errorf("parameter %d does not match", i)
+		}
+	}
+}
+
+func encoderDecoderTestBlock(inSize int) rnn.StackedBlock {
+	outNet := neuralnet.Network{
+		&neuralnet.DenseLayer{InputCount: 8, OutputCount: 4},
+		&neuralnet.LogSoftmaxLayer{},
+	}
+	outNet.Randomize()
+	return rnn.StackedBlock{
+		rnn.NewLSTM(inSize, 8),
+		rnn.NewNetworkBlock(outNet, 0),
+	}
+}
+
+func randomVector(size int) linalg.Vector {
+	res := make(linalg.Vector, size)
+	for i := range res {
+		res[i] = rand.NormFloat64()
+	}
+	return res
+}
@@ -1,6 +1,10 @@
 // Package seqtoseq implements gradient-based training
 // for models which take an input sequence and produce
 // an output sequence of the same length.
+//
+// It also includes EncoderDecoder, which can produce
+// output sequences of a different length by packing
+// the encoder and decoder inputs into one sequence.
 package seqtoseq
 
 import (
@@ -82,7 +82,7 @@ func (g *Gradienter) runBatch(grad autofunc.Gradient, set sgd.SampleSet) {
 func (g *Gradienter) runBatchR(rv autofunc.RVector, rg autofunc.RGradient,
 	grad autofunc.Gradient, set sgd.SampleSet) {
 	seqs := sampleSetSlice(set)
-	seqIns := make([][]linalg.Vector, len(seqs))
+	seqIns := make([][]linalg.Vector, 0, len(seqs))
 	for _, s := range seqs {
 		seqIns = append(seqIns, s.Inputs)
 	}
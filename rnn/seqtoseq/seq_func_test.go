@@ -0,0 +1,63 @@
+package seqtoseq
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/unixpickle/autofunc"
+	"github.com/unixpickle/num-analysis/linalg"
+	"github.com/unixpickle/sgd"
+	"github.com/unixpickle/weakai/neuralnet"
+	"github.com/unixpickle/weakai/rnn"
+)
+
+func TestGradienterRGradient(t *testing.T) {
+	randVec := func(size int) linalg.Vector {
+		res := make(linalg.Vector, size)
+		for i := range res {
+			res[i] = rand.NormFloat64()
+		}
+		return res
+	}
+
+	block := rnn.NewLSTM(2, 3)
+	g := &Gradienter{
+		SeqFunc:  &rnn.BlockSeqFunc{B: block},
+		Learner:  block,
+		CostFunc: neuralnet.MeanSquaredCost{},
+	}
+	var samples sgd.SliceSampleSet
+	for _, length := range []int{1, 3, 2} {
+		var sample Sample
+		for i := 0; i < length; i++ {
+			sample.Inputs = append(sample.Inputs, randVec(2))
+			sample.Outputs = append(sample.Outputs, randVec(3))
+		}
+		samples = append(samples, sample)
+	}
+	rv := autofunc.RVector{}
+	for _, p := range block.Parameters() {
+		rv[p] = randVec(len(p.Vector))
+	}
+
+	expected := g.Gradient(samples)
+	actual, _ := g.RGradient(rv, samples)
+	for _, p := range block.Parameters() {
+		if !vectorsClose(expected[p], actual[p]) {
+			t.Errorf("expected gradient %v but got %v", expected[p], actual[p])
+		}
+	}
+}
+
+func vectorsClose(v1, v2 linalg.Vector) bool {
+	if len(v1) != len(v2) {
+		return false
+	}
+	for i, x := range v1 {
+		if math.Abs(x-v2[i]) > 1e-8 {
+			return false
+		}
+	}
+	return true
+}
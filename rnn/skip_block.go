@@ -0,0 +1,281 @@
+package rnn
+
+import (
+	"github.com/unixpickle/autofunc"
+	"github.com/unixpickle/num-analysis/linalg"
+)
+
+// A skipCombiner produces the input for the next layer
+// in a skip-connected stack, given the input and output
+// of the current layer.
+type skipCombiner interface {
+	combine(layer int, in, out autofunc.Result) autofunc.Result
+	combineR(rv autofunc.RVector, layer int, in, out autofunc.RResult) autofunc.RResult
+}
+
+// applySkipStack applies a stack of blocks, using c to
+// combine each block's input and output before feeding
+// them to the next block.
+// The output of the stack is the final combination.
+func applySkipStack(blocks []Block, c skipCombiner, states []State,
+	in []autofunc.Result) *skipStackResult {
+	res := &skipStackResult{
+		Inputs:   in,
+		InPools:  make([][]*autofunc.Variable, len(blocks)),
+		OutPools: make([][]*autofunc.Variable, len(blocks)),
+		Combined: make([][]autofunc.Result, len(blocks)),
+	}
+	outStates := make([][]State, len(states))
+	cur := in
+	for layer, block := range blocks {
+		var inState []State
+		var inRes []autofunc.Result
+		for lane, x := range cur {
+			inState = append(inState, states[lane].([]State)[layer])
+			poolVar := &autofunc.Variable{Vector: x.Output()}
+			res.InPools[layer] = append(res.InPools[layer], poolVar)
+			inRes = append(inRes, poolVar)
+		}
+		out := block.ApplyBlock(inState, inRes)
+		res.Outs = append(res.Outs, out)
+		for lane, state := range out.States() {
+			outStates[lane] = append(outStates[lane], state)
+		}
+		cur = make([]autofunc.Result, len(in))
+		for lane, outVec := range out.Outputs() {
+			poolVar := &autofunc.Variable{Vector: outVec}
+			res.OutPools[layer] = append(res.OutPools[layer], poolVar)
+			cur[lane] = c.combine(layer, res.InPools[layer][lane], poolVar)
+		}
+		res.Combined[layer] = cur
+	}
+	for _, x := range cur {
+		res.OutVecs = append(res.OutVecs, x.Output())
+	}
+	for _, x := range outStates {
+		res.OutStates = append(res.OutStates, x)
+	}
+	return res
+}
+
+// applySkipStackR is like applySkipStack, but with
+// R-operator support.
+func applySkipStackR(rv autofunc.RVector, blocks []Block, c skipCombiner,
+	states []RState, in []autofunc.RResult) *skipStackRResult {
+	res := &skipStackRResult{
+		Inputs:   in,
+		InPools:  make([][]*autofunc.Variable, len(blocks)),
+		OutPools: make([][]*autofunc.Variable, len(blocks)),
+		Combined: make([][]autofunc.RResult, len(blocks)),
+	}
+	outStates := make([][]RState, len(states))
+	cur := in
+	for layer, block := range blocks {
+		var inState []RState
+		var inRes []autofunc.RResult
+		for lane, x := range cur {
+			inState = append(inState, states[lane].([]RState)[layer])
+			poolVar := &autofunc.Variable{Vector: x.Output()}
+			res.InPools[layer] = append(res.InPools[layer], poolVar)
+			inRes = append(inRes, &autofunc.RVariable{
+				Variable:   poolVar,
+				ROutputVec: x.ROutput(),
+			})
+		}
+		out := block.ApplyBlockR(rv, inState, inRes)
+		res.Outs = append(res.Outs, out)
+		for lane, state := range out.RStates() {
+			outStates[lane] = append(outStates[lane], state)
+		}
+		cur = make([]autofunc.RResult, len(in))
+		for lane, outVec := range out.Outputs() {
+			poolVar := &autofunc.Variable{Vector: outVec}
+			res.OutPools[layer] = append(res.OutPools[layer], poolVar)
+			poolRVar := &autofunc.RVariable{
+				Variable:   poolVar,
+				ROutputVec: out.ROutputs()[lane],
+			}
+			cur[lane] = c.combineR(rv, layer, inRes[lane], poolRVar)
+		}
+		res.Combined[layer] = cur
+	}
+	for _, x := range cur {
+		res.OutVecs = append(res.OutVecs, x.Output())
+		res.ROutVecs = append(res.ROutVecs, x.ROutput())
+	}
+	for _, x := range outStates {
+		res.OutStates = append(res.OutStates, x)
+	}
+	return res
+}
+
+type skipStackResult struct {
+	Inputs []autofunc.Result
+	Outs   []BlockResult
+
+	// The outer index of each of the following slices
+	// corresponds to a layer, and the inner index to a lane.
+	InPools  [][]*autofunc.Variable
+	OutPools [][]*autofunc.Variable
+	Combined [][]autofunc.Result
+
+	OutVecs   []linalg.Vector
+	OutStates []State
+}
+
+func (s *skipStackResult) Outputs() []linalg.Vector {
+	return s.OutVecs
+}
+
+func (s *skipStackResult) States() []State {
+	return s.OutStates
+}
+
+func (s *skipStackResult) PropagateGradient(u []linalg.Vector, su []StateGrad,
+	g autofunc.Gradient) []StateGrad {
+	stateDownstream := make([][]StateGrad, len(s.Inputs))
+	for layer := len(s.Outs) - 1; layer >= 0; layer-- {
+		for lane := range s.Inputs {
+			inPool, outPool := s.InPools[layer][lane], s.OutPools[layer][lane]
+			g[inPool] = make(linalg.Vector, len(inPool.Vector))
+			g[outPool] = make(linalg.Vector, len(outPool.Vector))
+		}
+
+		var blockUpstream []linalg.Vector
+		if u != nil {
+			for lane, combined := range s.Combined[layer] {
+				combined.PropagateGradient(u[lane], g)
+				blockUpstream = append(blockUpstream, g[s.OutPools[layer][lane]])
+			}
+		}
+
+		var stateUpstream []StateGrad
+		if su != nil {
+			for _, laneSU := range su {
+				if laneSU != nil {
+					stateUpstream = append(stateUpstream, laneSU.([]StateGrad)[layer])
+				} else {
+					stateUpstream = append(stateUpstream, nil)
+				}
+			}
+		}
+
+		downstream := s.Outs[layer].PropagateGradient(blockUpstream, stateUpstream, g)
+		for lane, sg := range downstream {
+			stateDownstream[lane] = append([]StateGrad{sg}, stateDownstream[lane]...)
+		}
+
+		u = make([]linalg.Vector, len(s.Inputs))
+		for lane := range s.Inputs {
+			inPool, outPool := s.InPools[layer][lane], s.OutPools[layer][lane]
+			u[lane] = g[inPool]
+			delete(g, inPool)
+			delete(g, outPool)
+		}
+	}
+
+	for lane, input := range s.Inputs {
+		input.PropagateGradient(u[lane], g)
+	}
+
+	res := make([]StateGrad, len(stateDownstream))
+	for i, x := range stateDownstream {
+		res[i] = x
+	}
+	return res
+}
+
+type skipStackRResult struct {
+	Inputs []autofunc.RResult
+	Outs   []BlockRResult
+
+	// The outer index of each of the following slices
+	// corresponds to a layer, and the inner index to a lane.
+	InPools  [][]*autofunc.Variable
+	OutPools [][]*autofunc.Variable
+	Combined [][]autofunc.RResult
+
+	OutVecs   []linalg.Vector
+	ROutVecs  []linalg.Vector
+	OutStates []RState
+}
+
+func (s *skipStackRResult) Outputs() []linalg.Vector {
+	return s.OutVecs
+}
+
+func (s *skipStackRResult) ROutputs() []linalg.Vector {
+	return s.ROutVecs
+}
+
+func (s *skipStackRResult) RStates() []RState {
+	return s.OutStates
+}
+
+func (s *skipStackRResult) PropagateRGradient(u, uR []linalg.Vector, su []RStateGrad,
+	rg autofunc.RGradient, g autofunc.Gradient) []RStateGrad {
+	if g == nil {
+		g = autofunc.Gradient{}
+	}
+	if (u == nil) != (uR == nil) {
+		panic("upstream and upstreamR must match in nil-ness")
+	}
+	stateDownstream := make([][]RStateGrad, len(s.Inputs))
+	for layer := len(s.Outs) - 1; layer >= 0; layer-- {
+		for lane := range s.Inputs {
+			inPool, outPool := s.InPools[layer][lane], s.OutPools[layer][lane]
+			g[inPool] = make(linalg.Vector, len(inPool.Vector))
+			rg[inPool] = make(linalg.Vector, len(inPool.Vector))
+			g[outPool] = make(linalg.Vector, len(outPool.Vector))
+			rg[outPool] = make(linalg.Vector, len(outPool.Vector))
+		}
+
+		var blockUpstream, blockUpstreamR []linalg.Vector
+		if u != nil {
+			for lane, combined := range s.Combined[layer] {
+				combined.PropagateRGradient(u[lane], uR[lane], rg, g)
+				outPool := s.OutPools[layer][lane]
+				blockUpstream = append(blockUpstream, g[outPool])
+				blockUpstreamR = append(blockUpstreamR, rg[outPool])
+			}
+		}
+
+		var stateUpstream []RStateGrad
+		if su != nil {
+			for _, laneSU := range su {
+				if laneSU != nil {
+					stateUpstream = append(stateUpstream, laneSU.([]RStateGrad)[layer])
+				} else {
+					stateUpstream = append(stateUpstream, nil)
+				}
+			}
+		}
+
+		downstream := s.Outs[layer].PropagateRGradient(blockUpstream, blockUpstreamR,
+			stateUpstream, rg, g)
+		for lane, sg := range downstream {
+			stateDownstream[lane] = append([]RStateGrad{sg}, stateDownstream[lane]...)
+		}
+
+		u = make([]linalg.Vector, len(s.Inputs))
+		uR = make([]linalg.Vector, len(s.Inputs))
+		for lane := range s.Inputs {
+			inPool, outPool := s.InPools[layer][lane], s.OutPools[layer][lane]
+			u[lane], uR[lane] = g[inPool], rg[inPool]
+			delete(g, inPool)
+			delete(rg, inPool)
+			delete(g, outPool)
+			delete(rg, outPool)
+		}
+	}
+
+	for lane, input := range s.Inputs {
+		input.PropagateRGradient(u[lane], uR[lane], rg, g)
+	}
+
+	res := make([]RStateGrad, len(stateDownstream))
+	for i, x := range stateDownstream {
+		res[i] = x
+	}
+	return res
+}
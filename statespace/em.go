@@ -0,0 +1,106 @@
+package statespace
+
+import "github.com/unixpickle/num-analysis/linalg"
+
+// EM runs several iterations of expectation
+// maximization to fit the system matrices to a set of
+// observation sequences.
+// It returns the total log likelihood of the sequences
+// before each iteration.
+func (l *LinearModel) EM(seqs [][]linalg.Vector, iterations int) []float64 {
+	var res []float64
+	for i := 0; i < iterations; i++ {
+		res = append(res, l.EMStep(seqs))
+	}
+	return res
+}
+
+// EMStep runs a single iteration of expectation
+// maximization, updating all of the model's matrices
+// (including the initial state) in place.
+// It returns the total log likelihood of the sequences
+// under the model before the update.
+//
+// Missing observations (nil vectors) are ignored when
+// estimating the observation parameters.
+func (l *LinearModel) EMStep(seqs [][]linalg.Vector) float64 {
+	stateSize := l.Transition.Rows
+	obsSize := l.Observation.Rows
+
+	var logLikelihood float64
+	var transitionCount, obsCount float64
+	currentCross := linalg.NewMatrix(stateSize, stateSize)
+	prevSecond := linalg.NewMatrix(stateSize, stateSize)
+	nextSecond := linalg.NewMatrix(stateSize, stateSize)
+	obsCross := linalg.NewMatrix(obsSize, stateSize)
+	obsStateSecond := linalg.NewMatrix(stateSize, stateSize)
+	obsSecond := linalg.NewMatrix(obsSize, obsSize)
+	var initials []Estimate
+
+	for _, seq := range seqs {
+		if len(seq) == 0 {
+			continue
+		}
+		filtered := l.Filter(seq)
+		logLikelihood += filtered.LogLikelihood
+		smoothed := l.Smooth(filtered)
+		initials = append(initials, smoothed.Smoothed[0])
+		for t, est := range smoothed.Smoothed {
+			second := matAdd(est.Cov, outer(est.Mean, est.Mean))
+			if t > 0 {
+				prev := smoothed.Smoothed[t-1]
+				prevSecond = matAdd(prevSecond, matAdd(prev.Cov, outer(prev.Mean, prev.Mean)))
+				nextSecond = matAdd(nextSecond, second)
+				lagCross := matAdd(smoothed.LagCov[t], outer(est.Mean, prev.Mean))
+				currentCross = matAdd(currentCross, lagCross)
+				transitionCount++
+			}
+			if z := seq[t]; z != nil {
+				obsCross = matAdd(obsCross, outer(z, est.Mean))
+				obsStateSecond = matAdd(obsStateSecond, second)
+				obsSecond = matAdd(obsSecond, outer(z, z))
+				obsCount++
+			}
+		}
+	}
+
+	if transitionCount > 0 {
+		l.Transition = currentCross.Mul(mustInvert(prevSecond))
+		residual := matSub(nextSecond, l.Transition.Mul(currentCross.Transpose()))
+		l.ProcessNoise = symmetrize(matScale(residual, 1/transitionCount))
+	}
+	if obsCount > 0 {
+		l.Observation = obsCross.Mul(mustInvert(obsStateSecond))
+		residual := matSub(obsSecond, l.Observation.Mul(obsCross.Transpose()))
+		l.ObservationNoise = symmetrize(matScale(residual, 1/obsCount))
+	}
+	if len(initials) > 0 {
+		l.Initial = averageEstimates(initials)
+	}
+
+	return logLikelihood
+}
+
+// averageEstimates computes the mean and covariance of a
+// mixture of Gaussians with equal weights.
+func averageEstimates(ests []Estimate) Estimate {
+	scale := 1 / float64(len(ests))
+	mean := make(linalg.Vector, len(ests[0].Mean))
+	for _, e := range ests {
+		mean.Add(e.Mean.Copy().Scale(scale))
+	}
+	cov := linalg.NewMatrix(len(mean), len(mean))
+	for _, e := range ests {
+		diff := e.Mean.Copy().Add(mean.Copy().Scale(-1))
+		cov = matAdd(cov, matScale(matAdd(e.Cov, outer(diff, diff)), scale))
+	}
+	return Estimate{Mean: mean, Cov: symmetrize(cov)}
+}
+
+func mustInvert(m *linalg.Matrix) *linalg.Matrix {
+	res, _, err := invert(m)
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
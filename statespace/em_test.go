@@ -0,0 +1,41 @@
+package statespace
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/unixpickle/num-analysis/linalg"
+)
+
+func TestEMLikelihood(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	actual := velocityModel()
+	var seqs [][]linalg.Vector
+	for i := 0; i < 5; i++ {
+		_, obs := sampleLinear(actual, 50, r)
+		obs[r.Intn(len(obs))] = nil
+		seqs = append(seqs, obs)
+	}
+
+	model := NewLinearModel(2, 1)
+	lls := model.EM(seqs, 200)
+	for i := 1; i < len(lls); i++ {
+		if lls[i] < lls[i-1]-1e-6 {
+			t.Errorf("iteration %d: log likelihood decreased from %f to %f",
+				i, lls[i-1], lls[i])
+		}
+	}
+	if lls[len(lls)-1] <= lls[0] {
+		t.Errorf("log likelihood did not improve: %v", lls)
+	}
+
+	var trueLL float64
+	for _, seq := range seqs {
+		trueLL += actual.Filter(seq).LogLikelihood
+	}
+	final := model.EMStep(seqs)
+	if final < trueLL-10 {
+		t.Errorf("final log likelihood %f is much worse than true model's %f",
+			final, trueLL)
+	}
+}
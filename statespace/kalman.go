@@ -0,0 +1,188 @@
+// Package statespace implements state-space models for
+// time series, including the Kalman filter, the
+// Rauch-Tung-Striebel smoother, extended and unscented
+// Kalman filters for non-linear dynamics, and EM
+// learning for linear-Gaussian models.
+package statespace
+
+import (
+	"math"
+
+	"github.com/unixpickle/num-analysis/linalg"
+)
+
+// An Estimate is a Gaussian belief about a state.
+type Estimate struct {
+	Mean linalg.Vector
+	Cov  *linalg.Matrix
+}
+
+// A LinearModel is a linear-Gaussian state-space model:
+//
+//	x[t+1] = Transition*x[t] + w, w ~ N(0, ProcessNoise)
+//	z[t] = Observation*x[t] + v, v ~ N(0, ObservationNoise)
+//
+// The first state is distributed according to Initial.
+type LinearModel struct {
+	Transition       *linalg.Matrix
+	Observation      *linalg.Matrix
+	ProcessNoise     *linalg.Matrix
+	ObservationNoise *linalg.Matrix
+	Initial          Estimate
+}
+
+// NewLinearModel creates a LinearModel with identity
+// transitions, a random observation matrix, identity
+// noise covariances, and a standard normal initial
+// state.
+// It is a reasonable starting point for EM.
+func NewLinearModel(stateSize, obsSize int) *LinearModel {
+	obs := linalg.NewMatrix(obsSize, stateSize)
+	copy(obs.Data, linalg.RandVector(len(obs.Data)))
+	return &LinearModel{
+		Transition:       identity(stateSize),
+		Observation:      obs,
+		ProcessNoise:     identity(stateSize),
+		ObservationNoise: identity(obsSize),
+		Initial: Estimate{
+			Mean: make(linalg.Vector, stateSize),
+			Cov:  identity(stateSize),
+		},
+	}
+}
+
+// A FilterResult stores the results of Kalman
+// filtering.
+type FilterResult struct {
+	// Predicted contains the estimate of each state
+	// given all of the previous observations.
+	Predicted []Estimate
+
+	// Filtered contains the estimate of each state
+	// given all of the observations up to and including
+	// the one for that state.
+	Filtered []Estimate
+
+	// LogLikelihood is the log probability density of
+	// the observations under the model.
+	LogLikelihood float64
+}
+
+// Predict computes the estimate for the next state given
+// an estimate of the current state.
+func (l *LinearModel) Predict(e Estimate) Estimate {
+	return Estimate{
+		Mean: matVec(l.Transition, e.Mean),
+		Cov:  symmetrize(matAdd(quadForm(l.Transition, e.Cov), l.ProcessNoise)),
+	}
+}
+
+// Update incorporates an observation into a predicted
+// estimate.
+// It returns the new estimate and the log likelihood of
+// the observation under the predicted estimate.
+func (l *LinearModel) Update(e Estimate, obs linalg.Vector) (Estimate, float64) {
+	predObs := matVec(l.Observation, e.Mean)
+	innovCov := matAdd(quadForm(l.Observation, e.Cov), l.ObservationNoise)
+	return gaussianUpdate(e, obs, predObs, innovCov, e.Cov.Mul(l.Observation.Transpose()))
+}
+
+// Filter runs the Kalman filter on a sequence of
+// observations.
+// A nil observation is treated as missing, in which case
+// the filtered estimate is the predicted one.
+func (l *LinearModel) Filter(obs []linalg.Vector) *FilterResult {
+	return runFilter(l, l.Initial, obs)
+}
+
+// A SmoothResult stores the results of smoothing.
+type SmoothResult struct {
+	// Smoothed contains the estimate of each state given
+	// all of the observations.
+	Smoothed []Estimate
+
+	// LagCov contains, for each t > 0, the covariance
+	// between state t and state t-1 given all of the
+	// observations.
+	// The first entry is nil.
+	LagCov []*linalg.Matrix
+}
+
+// Smooth runs the Rauch-Tung-Striebel smoother on the
+// result of Filter.
+func (l *LinearModel) Smooth(f *FilterResult) *SmoothResult {
+	n := len(f.Filtered)
+	res := &SmoothResult{
+		Smoothed: make([]Estimate, n),
+		LagCov:   make([]*linalg.Matrix, n),
+	}
+	if n == 0 {
+		return res
+	}
+	res.Smoothed[n-1] = f.Filtered[n-1]
+	for t := n - 2; t >= 0; t-- {
+		filtered := f.Filtered[t]
+		next := f.Predicted[t+1]
+		nextInv, _, err := invert(next.Cov)
+		if err != nil {
+			panic("singular predicted covariance")
+		}
+		gain := filtered.Cov.Mul(l.Transition.Transpose()).Mul(nextInv)
+		smoothedNext := res.Smoothed[t+1]
+
+		meanDiff := smoothedNext.Mean.Copy().Add(next.Mean.Copy().Scale(-1))
+		covDiff := matSub(smoothedNext.Cov, next.Cov)
+		res.Smoothed[t] = Estimate{
+			Mean: filtered.Mean.Copy().Add(matVec(gain, meanDiff)),
+			Cov:  symmetrize(matAdd(filtered.Cov, quadForm(gain, covDiff))),
+		}
+		res.LagCov[t+1] = smoothedNext.Cov.Mul(gain.Transpose())
+	}
+	return res
+}
+
+// A stepper is a model which can be used for
+// recursive filtering.
+type stepper interface {
+	Predict(e Estimate) Estimate
+	Update(e Estimate, obs linalg.Vector) (Estimate, float64)
+}
+
+func runFilter(s stepper, initial Estimate, obs []linalg.Vector) *FilterResult {
+	res := &FilterResult{}
+	estimate := initial
+	for t, z := range obs {
+		if t > 0 {
+			estimate = s.Predict(estimate)
+		}
+		res.Predicted = append(res.Predicted, estimate)
+		if z != nil {
+			var ll float64
+			estimate, ll = s.Update(estimate, z)
+			res.LogLikelihood += ll
+		}
+		res.Filtered = append(res.Filtered, estimate)
+	}
+	return res
+}
+
+// gaussianUpdate performs a Kalman update given the
+// predicted observation, the innovation covariance, and
+// the cross-covariance between the state and the
+// observation.
+func gaussianUpdate(e Estimate, obs, predObs linalg.Vector, innovCov,
+	crossCov *linalg.Matrix) (Estimate, float64) {
+	innovInv, logDet, err := invert(innovCov)
+	if err != nil {
+		panic("singular innovation covariance")
+	}
+	innov := obs.Copy().Add(predObs.Copy().Scale(-1))
+	gain := crossCov.Mul(innovInv)
+	res := Estimate{
+		Mean: e.Mean.Copy().Add(matVec(gain, innov)),
+		Cov:  symmetrize(matSub(e.Cov, quadForm(gain, innovCov))),
+	}
+	mahalanobis := innov.Dot(matVec(innovInv, innov))
+	ll := -0.5 * (mahalanobis + logDet + float64(len(obs))*math.Log(2*math.Pi))
+	return res, ll
+}
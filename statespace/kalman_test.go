@@ -0,0 +1,134 @@
+package statespace
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/unixpickle/num-analysis/linalg"
+)
+
+func TestLinearFilterSmooth(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	model := velocityModel()
+	states, obs := sampleLinear(model, 200, r)
+
+	filtered := model.Filter(obs)
+	smoothed := model.Smooth(filtered)
+
+	var obsErr, filterErr, smoothErr float64
+	for i, x := range states {
+		obsErr += math.Pow(obs[i][0]-x[0], 2)
+		filterErr += math.Pow(filtered.Filtered[i].Mean[0]-x[0], 2)
+		smoothErr += math.Pow(smoothed.Smoothed[i].Mean[0]-x[0], 2)
+	}
+	if filterErr >= obsErr {
+		t.Errorf("filter error %f should be less than observation error %f",
+			filterErr, obsErr)
+	}
+	if smoothErr >= filterErr {
+		t.Errorf("smoother error %f should be less than filter error %f",
+			smoothErr, filterErr)
+	}
+	if math.IsNaN(filtered.LogLikelihood) || math.IsInf(filtered.LogLikelihood, 0) {
+		t.Errorf("invalid log likelihood: %f", filtered.LogLikelihood)
+	}
+}
+
+func TestLinearFilterMissing(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	model := velocityModel()
+	_, obs := sampleLinear(model, 20, r)
+	obs[10] = nil
+
+	filtered := model.Filter(obs)
+	if !vectorsClose(filtered.Filtered[10].Mean, filtered.Predicted[10].Mean) {
+		t.Error("missing observation should not change the estimate")
+	}
+
+	full := model.Filter(obs[:10])
+	partial := model.Filter(append(append([]linalg.Vector{}, obs[:10]...), nil))
+	if math.Abs(full.LogLikelihood-partial.LogLikelihood) > 1e-8 {
+		t.Error("missing observation should not affect the log likelihood")
+	}
+}
+
+func TestLinearLogLikelihood(t *testing.T) {
+	// With a single observation, the likelihood is a
+	// Gaussian density which can be computed directly.
+	model := velocityModel()
+	obs := linalg.Vector{0.7}
+	res := model.Filter([]linalg.Vector{obs})
+
+	variance := quadForm(model.Observation, model.Initial.Cov).Get(0, 0) +
+		model.ObservationNoise.Get(0, 0)
+	diff := obs[0] - matVec(model.Observation, model.Initial.Mean)[0]
+	expected := -0.5 * (diff*diff/variance + math.Log(2*math.Pi*variance))
+	if math.Abs(res.LogLikelihood-expected) > 1e-8 {
+		t.Errorf("expected %f but got %f", expected, res.LogLikelihood)
+	}
+}
+
+// velocityModel creates a model of a particle with
+// noisy position measurements and a random velocity.
+func velocityModel() *LinearModel {
+	return &LinearModel{
+		Transition: &linalg.Matrix{
+			Rows: 2,
+			Cols: 2,
+			Data: []float64{1, 1, 0, 1},
+		},
+		Observation: &linalg.Matrix{
+			Rows: 1,
+			Cols: 2,
+			Data: []float64{1, 0},
+		},
+		ProcessNoise: &linalg.Matrix{
+			Rows: 2,
+			Cols: 2,
+			Data: []float64{0.01, 0, 0, 0.01},
+		},
+		ObservationNoise: &linalg.Matrix{
+			Rows: 1,
+			Cols: 1,
+			Data: []float64{1},
+		},
+		Initial: Estimate{
+			Mean: linalg.Vector{0, 0},
+			Cov:  identity(2),
+		},
+	}
+}
+
+func sampleLinear(m *LinearModel, n int, r *rand.Rand) (states, obs []linalg.Vector) {
+	state := m.Initial.Mean.Copy().Add(sampleNoise(m.Initial.Cov, r))
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			state = matVec(m.Transition, state).Add(sampleNoise(m.ProcessNoise, r))
+		}
+		states = append(states, state)
+		obs = append(obs, matVec(m.Observation, state).Add(sampleNoise(m.ObservationNoise, r)))
+	}
+	return
+}
+
+func sampleNoise(cov *linalg.Matrix, r *rand.Rand) linalg.Vector {
+	root, err := cholesky(cov)
+	if err != nil {
+		panic(err)
+	}
+	normal := make(linalg.Vector, cov.Rows)
+	for i := range normal {
+		normal[i] = r.NormFloat64()
+	}
+	return matVec(root, normal)
+}
+
+func vectorsClose(v1, v2 linalg.Vector) bool {
+	for i, x := range v1 {
+		if math.Abs(x-v2[i]) > 1e-8 {
+			return false
+		}
+	}
+	return true
+}
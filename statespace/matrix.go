@@ -0,0 +1,164 @@
+package statespace
+
+import (
+	"errors"
+	"math"
+
+	"github.com/unixpickle/num-analysis/linalg"
+)
+
+var errSingular = errors.New("matrix is singular")
+
+func identity(n int) *linalg.Matrix {
+	res := linalg.NewMatrix(n, n)
+	for i := 0; i < n; i++ {
+		res.Set(i, i, 1)
+	}
+	return res
+}
+
+// matAdd returns a+b.
+func matAdd(a, b *linalg.Matrix) *linalg.Matrix {
+	res := a.Copy()
+	for i, x := range b.Data {
+		res.Data[i] += x
+	}
+	return res
+}
+
+// matSub returns a-b.
+func matSub(a, b *linalg.Matrix) *linalg.Matrix {
+	res := a.Copy()
+	for i, x := range b.Data {
+		res.Data[i] -= x
+	}
+	return res
+}
+
+// matScale returns s*a.
+func matScale(a *linalg.Matrix, s float64) *linalg.Matrix {
+	res := a.Copy()
+	for i := range res.Data {
+		res.Data[i] *= s
+	}
+	return res
+}
+
+// matVec returns m*v.
+func matVec(m *linalg.Matrix, v linalg.Vector) linalg.Vector {
+	res := make(linalg.Vector, m.Rows)
+	for i := range res {
+		row := m.Data[i*m.Cols : (i+1)*m.Cols]
+		res[i] = row.Dot(v)
+	}
+	return res
+}
+
+// outer returns x*y^T.
+func outer(x, y linalg.Vector) *linalg.Matrix {
+	res := linalg.NewMatrix(len(x), len(y))
+	for i, a := range x {
+		for j, b := range y {
+			res.Set(i, j, a*b)
+		}
+	}
+	return res
+}
+
+// quadForm returns a*m*a^T.
+func quadForm(a, m *linalg.Matrix) *linalg.Matrix {
+	return a.Mul(m).Mul(a.Transpose())
+}
+
+// symmetrize averages a matrix with its transpose to
+// counteract the accumulation of rounding errors.
+func symmetrize(m *linalg.Matrix) *linalg.Matrix {
+	res := m.Copy()
+	for i := 0; i < m.Rows; i++ {
+		for j := 0; j < i; j++ {
+			avg := (m.Get(i, j) + m.Get(j, i)) / 2
+			res.Set(i, j, avg)
+			res.Set(j, i, avg)
+		}
+	}
+	return res
+}
+
+// invert computes the inverse of a square matrix and
+// the log of the absolute value of its determinant using
+// Gauss-Jordan elimination with partial pivoting.
+func invert(m *linalg.Matrix) (*linalg.Matrix, float64, error) {
+	n := m.Rows
+	a := m.Copy()
+	inv := identity(n)
+	var logDet float64
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(a.Get(row, col)) > math.Abs(a.Get(pivot, col)) {
+				pivot = row
+			}
+		}
+		if a.Get(pivot, col) == 0 {
+			return nil, 0, errSingular
+		}
+		swapRows(a, pivot, col)
+		swapRows(inv, pivot, col)
+
+		pivotVal := a.Get(col, col)
+		logDet += math.Log(math.Abs(pivotVal))
+		for j := 0; j < n; j++ {
+			a.Set(col, j, a.Get(col, j)/pivotVal)
+			inv.Set(col, j, inv.Get(col, j)/pivotVal)
+		}
+		for row := 0; row < n; row++ {
+			if row == col {
+				continue
+			}
+			factor := a.Get(row, col)
+			if factor == 0 {
+				continue
+			}
+			for j := 0; j < n; j++ {
+				a.Set(row, j, a.Get(row, j)-factor*a.Get(col, j))
+				inv.Set(row, j, inv.Get(row, j)-factor*inv.Get(col, j))
+			}
+		}
+	}
+	return inv, logDet, nil
+}
+
+func swapRows(m *linalg.Matrix, i, j int) {
+	if i == j {
+		return
+	}
+	for k := 0; k < m.Cols; k++ {
+		x, y := m.Get(i, k), m.Get(j, k)
+		m.Set(i, k, y)
+		m.Set(j, k, x)
+	}
+}
+
+// cholesky computes the lower-triangular matrix L such
+// that L*L^T = m for a positive definite matrix m.
+func cholesky(m *linalg.Matrix) (*linalg.Matrix, error) {
+	n := m.Rows
+	res := linalg.NewMatrix(n, n)
+	for i := 0; i < n; i++ {
+		for j := 0; j <= i; j++ {
+			sum := m.Get(i, j)
+			for k := 0; k < j; k++ {
+				sum -= res.Get(i, k) * res.Get(j, k)
+			}
+			if i == j {
+				if sum <= 0 {
+					return nil, errors.New("matrix is not positive definite")
+				}
+				res.Set(i, i, math.Sqrt(sum))
+			} else {
+				res.Set(i, j, sum/res.Get(j, j))
+			}
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,229 @@
+package statespace
+
+import (
+	"math"
+
+	"github.com/unixpickle/num-analysis/linalg"
+)
+
+const jacobianEpsilon = 1e-5
+
+// A NonlinearModel is a state-space model with additive
+// Gaussian noise but arbitrary dynamics:
+//
+//	x[t+1] = Transition(x[t]) + w, w ~ N(0, ProcessNoise)
+//	z[t] = Observation(x[t]) + v, v ~ N(0, ObservationNoise)
+//
+// The first state is distributed according to Initial.
+type NonlinearModel struct {
+	Transition  func(x linalg.Vector) linalg.Vector
+	Observation func(x linalg.Vector) linalg.Vector
+
+	// TransitionJacobian and ObservationJacobian compute
+	// the Jacobians of the corresponding functions.
+	// They are used by the extended Kalman filter.
+	// If either one is nil, its Jacobian is approximated
+	// with finite differences.
+	TransitionJacobian  func(x linalg.Vector) *linalg.Matrix
+	ObservationJacobian func(x linalg.Vector) *linalg.Matrix
+
+	ProcessNoise     *linalg.Matrix
+	ObservationNoise *linalg.Matrix
+	Initial          Estimate
+}
+
+// An Extended is an extended Kalman filter, which
+// linearizes a NonlinearModel around the current
+// estimate at every timestep.
+type Extended struct {
+	Model *NonlinearModel
+}
+
+// Predict computes the estimate for the next state given
+// an estimate of the current state.
+func (e *Extended) Predict(est Estimate) Estimate {
+	jacobian := jacobianOrApprox(e.Model.TransitionJacobian, e.Model.Transition, est.Mean)
+	return Estimate{
+		Mean: e.Model.Transition(est.Mean),
+		Cov:  symmetrize(matAdd(quadForm(jacobian, est.Cov), e.Model.ProcessNoise)),
+	}
+}
+
+// Update incorporates an observation into a predicted
+// estimate.
+// It returns the new estimate and the approximate log
+// likelihood of the observation.
+func (e *Extended) Update(est Estimate, obs linalg.Vector) (Estimate, float64) {
+	jacobian := jacobianOrApprox(e.Model.ObservationJacobian, e.Model.Observation, est.Mean)
+	innovCov := matAdd(quadForm(jacobian, est.Cov), e.Model.ObservationNoise)
+	crossCov := est.Cov.Mul(jacobian.Transpose())
+	return gaussianUpdate(est, obs, e.Model.Observation(est.Mean), innovCov, crossCov)
+}
+
+// Filter runs the filter on a sequence of observations.
+// A nil observation is treated as missing.
+func (e *Extended) Filter(obs []linalg.Vector) *FilterResult {
+	return runFilter(e, e.Model.Initial, obs)
+}
+
+// An Unscented is an unscented Kalman filter, which
+// propagates a deterministic set of sigma points through
+// the dynamics of a NonlinearModel.
+type Unscented struct {
+	Model *NonlinearModel
+
+	// Alpha controls the spread of the sigma points.
+	// If it is 0, 1 is used.
+	Alpha float64
+
+	// Beta incorporates prior knowledge about the state
+	// distribution; 2 is optimal for Gaussians.
+	Beta float64
+
+	// Kappa is a secondary scaling parameter.
+	Kappa float64
+}
+
+// NewUnscented creates an Unscented with commonly used
+// default parameters.
+func NewUnscented(m *NonlinearModel) *Unscented {
+	return &Unscented{Model: m, Alpha: 1, Beta: 2}
+}
+
+// Predict computes the estimate for the next state given
+// an estimate of the current state.
+func (u *Unscented) Predict(est Estimate) Estimate {
+	points, meanWeights, covWeights := u.sigmaPoints(est)
+	for i, p := range points {
+		points[i] = u.Model.Transition(p)
+	}
+	mean := weightedMean(points, meanWeights)
+	cov := weightedCov(points, mean, points, mean, covWeights)
+	return Estimate{
+		Mean: mean,
+		Cov:  symmetrize(matAdd(cov, u.Model.ProcessNoise)),
+	}
+}
+
+// Update incorporates an observation into a predicted
+// estimate.
+// It returns the new estimate and the approximate log
+// likelihood of the observation.
+func (u *Unscented) Update(est Estimate, obs linalg.Vector) (Estimate, float64) {
+	points, meanWeights, covWeights := u.sigmaPoints(est)
+	obsPoints := make([]linalg.Vector, len(points))
+	for i, p := range points {
+		obsPoints[i] = u.Model.Observation(p)
+	}
+	predObs := weightedMean(obsPoints, meanWeights)
+	innovCov := weightedCov(obsPoints, predObs, obsPoints, predObs, covWeights)
+	innovCov = symmetrize(matAdd(innovCov, u.Model.ObservationNoise))
+	crossCov := weightedCov(points, est.Mean, obsPoints, predObs, covWeights)
+	return gaussianUpdate(est, obs, predObs, innovCov, crossCov)
+}
+
+// Filter runs the filter on a sequence of observations.
+// A nil observation is treated as missing.
+func (u *Unscented) Filter(obs []linalg.Vector) *FilterResult {
+	return runFilter(u, u.Model.Initial, obs)
+}
+
+func (u *Unscented) sigmaPoints(est Estimate) (points []linalg.Vector,
+	meanWeights, covWeights []float64) {
+	alpha := u.Alpha
+	if alpha == 0 {
+		alpha = 1
+	}
+	n := float64(len(est.Mean))
+	lambda := alpha*alpha*(n+u.Kappa) - n
+	if n+lambda <= 0 {
+		panic("sigma point spread must be positive (check Alpha and Kappa)")
+	}
+	root := jitteredCholesky(matScale(est.Cov, n+lambda))
+
+	points = append(points, est.Mean.Copy())
+	meanWeights = append(meanWeights, lambda/(n+lambda))
+	covWeights = append(covWeights, meanWeights[0]+1-alpha*alpha+u.Beta)
+	sideWeight := 1 / (2 * (n + lambda))
+	for i := 0; i < root.Cols; i++ {
+		col := root.Col(i)
+		points = append(points, est.Mean.Copy().Add(col),
+			est.Mean.Copy().Add(col.Copy().Scale(-1)))
+		meanWeights = append(meanWeights, sideWeight, sideWeight)
+		covWeights = append(covWeights, sideWeight, sideWeight)
+	}
+	return
+}
+
+// jitteredCholesky computes the Cholesky decomposition
+// of a covariance matrix, adding a growing multiple of
+// the identity if the matrix is singular (e.g. because
+// part of the state is deterministic).
+func jitteredCholesky(cov *linalg.Matrix) *linalg.Matrix {
+	if root, err := cholesky(cov); err == nil {
+		return root
+	}
+	var maxDiag float64
+	for i := 0; i < cov.Rows; i++ {
+		maxDiag = math.Max(maxDiag, math.Abs(cov.Get(i, i)))
+	}
+	jitter := math.Max(maxDiag, 1e-5) * 1e-10
+	for i := 0; i < 8; i++ {
+		root, err := cholesky(matAdd(cov, matScale(identity(cov.Rows), jitter)))
+		if err == nil {
+			return root
+		}
+		jitter *= 10
+	}
+	panic("covariance is not positive semi-definite")
+}
+
+func weightedMean(points []linalg.Vector, weights []float64) linalg.Vector {
+	res := make(linalg.Vector, len(points[0]))
+	for i, p := range points {
+		res.Add(p.Copy().Scale(weights[i]))
+	}
+	return res
+}
+
+// weightedCov computes the weighted cross-covariance
+// between two sets of points.
+func weightedCov(x []linalg.Vector, xMean linalg.Vector, y []linalg.Vector,
+	yMean linalg.Vector, weights []float64) *linalg.Matrix {
+	res := linalg.NewMatrix(len(xMean), len(yMean))
+	for i, w := range weights {
+		xDiff := x[i].Copy().Add(xMean.Copy().Scale(-1))
+		yDiff := y[i].Copy().Add(yMean.Copy().Scale(-1))
+		res = matAdd(res, matScale(outer(xDiff, yDiff), w))
+	}
+	return res
+}
+
+func jacobianOrApprox(jacobian func(linalg.Vector) *linalg.Matrix,
+	f func(linalg.Vector) linalg.Vector, x linalg.Vector) *linalg.Matrix {
+	if jacobian != nil {
+		return jacobian(x)
+	}
+	return approxJacobian(f, x)
+}
+
+// approxJacobian approximates the Jacobian of f at x
+// using central differences.
+func approxJacobian(f func(linalg.Vector) linalg.Vector, x linalg.Vector) *linalg.Matrix {
+	var res *linalg.Matrix
+	for j := range x {
+		step := jacobianEpsilon * math.Max(1, math.Abs(x[j]))
+		plus := x.Copy()
+		plus[j] += step
+		minus := x.Copy()
+		minus[j] -= step
+		diff := f(plus).Copy().Add(f(minus).Copy().Scale(-1)).Scale(1 / (2 * step))
+		if res == nil {
+			res = linalg.NewMatrix(len(diff), len(x))
+		}
+		for i, d := range diff {
+			res.Set(i, j, d)
+		}
+	}
+	return res
+}
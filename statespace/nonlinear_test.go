@@ -0,0 +1,151 @@
+package statespace
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/unixpickle/num-analysis/linalg"
+)
+
+func TestNonlinearMatchesLinear(t *testing.T) {
+	// On a linear model, both non-linear filters should
+	// reproduce the ordinary Kalman filter.
+	r := rand.New(rand.NewSource(1337))
+	linear := velocityModel()
+	_, obs := sampleLinear(linear, 30, r)
+	nonlinear := &NonlinearModel{
+		Transition: func(x linalg.Vector) linalg.Vector {
+			return matVec(linear.Transition, x)
+		},
+		Observation: func(x linalg.Vector) linalg.Vector {
+			return matVec(linear.Observation, x)
+		},
+		ProcessNoise:     linear.ProcessNoise,
+		ObservationNoise: linear.ObservationNoise,
+		Initial:          linear.Initial,
+	}
+
+	expected := linear.Filter(obs)
+	filters := map[string]*FilterResult{
+		"extended":  (&Extended{Model: nonlinear}).Filter(obs),
+		"unscented": NewUnscented(nonlinear).Filter(obs),
+	}
+	for name, actual := range filters {
+		for i, e := range expected.Filtered {
+			a := actual.Filtered[i]
+			if !vectorsApprox(e.Mean, a.Mean, 1e-5) ||
+				!vectorsApprox(e.Cov.Data, a.Cov.Data, 1e-5) {
+				t.Errorf("%s: step %d: expected %v but got %v", name, i, e, a)
+				break
+			}
+		}
+		if math.Abs(expected.LogLikelihood-actual.LogLikelihood) > 1e-5 {
+			t.Errorf("%s: expected log likelihood %f but got %f", name,
+				expected.LogLikelihood, actual.LogLikelihood)
+		}
+	}
+}
+
+func TestNonlinearTracking(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	model := pendulumModel()
+
+	var states, obs []linalg.Vector
+	state := linalg.Vector{1, 0}
+	for i := 0; i < 200; i++ {
+		if i > 0 {
+			state = model.Transition(state).Add(sampleNoise(model.ProcessNoise, r))
+		}
+		states = append(states, state)
+		obs = append(obs, model.Observation(state).Add(sampleNoise(model.ObservationNoise, r)))
+	}
+
+	priorErr := trackingError(states, (&Extended{Model: model}).Filter(make([]linalg.Vector, len(obs))))
+	filters := map[string]*FilterResult{
+		"extended":  (&Extended{Model: model}).Filter(obs),
+		"unscented": NewUnscented(model).Filter(obs),
+		"zero":      (&Unscented{Model: model}).Filter(obs),
+	}
+	for name, res := range filters {
+		if err := trackingError(states, res); err > priorErr/4 {
+			t.Errorf("%s: error %f is too large (prior error %f)", name, err, priorErr)
+		}
+	}
+}
+
+func TestUnscentedSingular(t *testing.T) {
+	model := pendulumModel()
+	model.Initial.Cov = linalg.NewMatrix(2, 2)
+	res := NewUnscented(model).Filter([]linalg.Vector{{0.5}, {0.4}})
+	for i, est := range res.Filtered {
+		for _, x := range append(append(linalg.Vector{}, est.Mean...), est.Cov.Data...) {
+			if math.IsNaN(x) || math.IsInf(x, 0) {
+				t.Fatalf("step %d: invalid estimate %v", i, est)
+			}
+		}
+	}
+}
+
+func TestApproxJacobian(t *testing.T) {
+	model := pendulumModel()
+	x := linalg.Vector{0.3, -0.7}
+	expected := model.TransitionJacobian(x)
+	actual := approxJacobian(model.Transition, x)
+	if !vectorsApprox(expected.Data, actual.Data, 1e-6) {
+		t.Errorf("expected %v but got %v", expected.Data, actual.Data)
+	}
+}
+
+// pendulumModel models a pendulum whose horizontal
+// position is observed.
+func pendulumModel() *NonlinearModel {
+	const dt = 0.1
+	return &NonlinearModel{
+		Transition: func(x linalg.Vector) linalg.Vector {
+			return linalg.Vector{x[0] + dt*x[1], x[1] - dt*math.Sin(x[0])}
+		},
+		Observation: func(x linalg.Vector) linalg.Vector {
+			return linalg.Vector{math.Sin(x[0])}
+		},
+		TransitionJacobian: func(x linalg.Vector) *linalg.Matrix {
+			return &linalg.Matrix{
+				Rows: 2,
+				Cols: 2,
+				Data: []float64{1, dt, -dt * math.Cos(x[0]), 1},
+			}
+		},
+		ProcessNoise: &linalg.Matrix{
+			Rows: 2,
+			Cols: 2,
+			Data: []float64{1e-4, 0, 0, 1e-3},
+		},
+		ObservationNoise: &linalg.Matrix{
+			Rows: 1,
+			Cols: 1,
+			Data: []float64{0.01},
+		},
+		Initial: Estimate{
+			Mean: linalg.Vector{1, 0},
+			Cov:  matScale(identity(2), 0.1),
+		},
+	}
+}
+
+func trackingError(states []linalg.Vector, res *FilterResult) float64 {
+	var sum float64
+	for i, x := range states {
+		diff := x.Copy().Add(res.Filtered[i].Mean.Copy().Scale(-1))
+		sum += diff.Dot(diff)
+	}
+	return math.Sqrt(sum / float64(len(states)))
+}
+
+func vectorsApprox(v1, v2 []float64, eps float64) bool {
+	for i, x := range v1 {
+		if math.Abs(x-v2[i]) > eps {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,316 @@
+package svm
+
+import (
+	"math"
+	"math/rand"
+)
+
+const (
+	defaultCoordinateTolerance  = 0.1
+	defaultCoordinateIterations = 1000
+
+	logisticMaxNewtonIters = 100
+	logisticInnerTolerance = 1e-2
+)
+
+// A LinearLoss is a loss function which a
+// CoordinateSolver can minimize.
+type LinearLoss int
+
+const (
+	// HingeLoss is the standard SVM loss,
+	// max(0, 1-y*f(x)).
+	HingeLoss LinearLoss = iota
+
+	// SquaredHingeLoss is the L2-loss SVM loss,
+	// max(0, 1-y*f(x))^2.
+	SquaredHingeLoss
+
+	// LogisticLoss is the loss for logistic regression,
+	// log(1+exp(-y*f(x))).
+	LogisticLoss
+)
+
+// A CoordinateSolver solves Problems with linear kernels
+// using dual coordinate descent, as in LIBLINEAR.
+//
+// Unlike GradientDescentSolver, it works directly with
+// the samples' vectors rather than a kernel matrix,
+// making it suitable for very large problems.
+// The Problem's Kernel is ignored.
+//
+// The threshold is learned as the weight of an extra
+// constant feature, so it is regularized along with the
+// other weights.
+type CoordinateSolver struct {
+	// Loss is the loss function to minimize.
+	Loss LinearLoss
+
+	// Tradeoff determines how important a wide
+	// separation margin is, exactly as it does for
+	// GradientDescentSolver.
+	// It must be positive.
+	//
+	// With N samples, this corresponds to LIBLINEAR's
+	// cost parameter C = 1/(2*Tradeoff*N).
+	Tradeoff float64
+
+	// Tolerance is the stopping tolerance on the
+	// projected gradient.
+	// If it is 0, a default of 0.1 is used.
+	Tolerance float64
+
+	// MaxIterations is the maximum number of passes over
+	// the samples.
+	// If it is 0, a default of 1000 is used.
+	MaxIterations int
+
+	// NoShrinking disables the shrinking heuristic,
+	// which temporarily removes samples whose dual
+	// variables seem to be at their bounds.
+	// Shrinking is never used for LogisticLoss.
+	NoShrinking bool
+}
+
+// Solve solves the problem and returns the resulting
+// hyperplane.
+func (c *CoordinateSolver) Solve(p *Problem) *LinearClassifier {
+	samples, labels := coordinateSamples(p)
+	cost := 1 / (2 * c.Tradeoff * float64(len(samples)))
+
+	var weights []float64
+	switch c.Loss {
+	case HingeLoss, SquaredHingeLoss:
+		weights = c.solveHinge(samples, labels, cost)
+	case LogisticLoss:
+		weights = c.solveLogistic(samples, labels, cost)
+	default:
+		panic("unknown loss function")
+	}
+
+	dim := len(weights) - 1
+	return &LinearClassifier{
+		HyperplaneNormal: Sample{V: weights[:dim]},
+		Threshold:        weights[dim],
+		Kernel:           LinearKernel,
+	}
+}
+
+func (c *CoordinateSolver) solveHinge(samples [][]float64, labels []float64,
+	cost float64) []float64 {
+	upperBound := cost
+	var diag float64
+	if c.Loss == SquaredHingeLoss {
+		upperBound = math.Inf(1)
+		diag = 1 / (2 * cost)
+	}
+
+	weights := make([]float64, len(samples[0]))
+	alphas := make([]float64, len(samples))
+	qDiag := make([]float64, len(samples))
+	for i, s := range samples {
+		qDiag[i] = dot(s, s) + diag
+	}
+
+	active := make([]int, len(samples))
+	for i := range active {
+		active[i] = i
+	}
+	activeSize := len(active)
+	maxOld, minOld := math.Inf(1), math.Inf(-1)
+
+	for iter := 0; iter < c.maxIterations(); iter++ {
+		maxNew, minNew := math.Inf(-1), math.Inf(1)
+		shuffle(active[:activeSize])
+
+		for s := 0; s < activeSize; s++ {
+			i := active[s]
+			grad := labels[i]*dot(weights, samples[i]) - 1 + diag*alphas[i]
+
+			var projGrad float64
+			switch {
+			case alphas[i] == 0:
+				if grad > maxOld && !c.NoShrinking {
+					activeSize--
+					active[s], active[activeSize] = active[activeSize], active[s]
+					s--
+					continue
+				} else if grad < 0 {
+					projGrad = grad
+				}
+			case alphas[i] == upperBound:
+				if grad < minOld && !c.NoShrinking {
+					activeSize--
+					active[s], active[activeSize] = active[activeSize], active[s]
+					s--
+					continue
+				} else if grad > 0 {
+					projGrad = grad
+				}
+			default:
+				projGrad = grad
+			}
+
+			maxNew = math.Max(maxNew, projGrad)
+			minNew = math.Min(minNew, projGrad)
+
+			if math.Abs(projGrad) > 1e-12 {
+				oldAlpha := alphas[i]
+				alphas[i] = math.Min(math.Max(alphas[i]-grad/qDiag[i], 0), upperBound)
+				addScaled(weights, samples[i], (alphas[i]-oldAlpha)*labels[i])
+			}
+		}
+
+		if maxNew-minNew <= c.tolerance() {
+			if activeSize == len(samples) {
+				break
+			}
+			// Make sure the shrunken samples are optimal.
+			activeSize = len(samples)
+			maxOld, minOld = math.Inf(1), math.Inf(-1)
+			continue
+		}
+
+		maxOld, minOld = maxNew, minNew
+		if maxOld <= 0 {
+			maxOld = math.Inf(1)
+		}
+		if minOld >= 0 {
+			minOld = math.Inf(-1)
+		}
+	}
+
+	return weights
+}
+
+// solveLogistic minimizes the dual of L2-regularized
+// logistic regression, using a few Newton steps to
+// solve each single-variable sub-problem.
+func (c *CoordinateSolver) solveLogistic(samples [][]float64, labels []float64,
+	cost float64) []float64 {
+	weights := make([]float64, len(samples[0]))
+
+	// Each sample has two dual variables which sum to
+	// the cost, and alphas[i] is the one which weights
+	// the sample in the hyperplane.
+	alphas := make([]float64, len(samples))
+	qDiag := make([]float64, len(samples))
+	for i, s := range samples {
+		alphas[i] = math.Min(0.001*cost, 1e-8)
+		qDiag[i] = dot(s, s)
+		addScaled(weights, s, alphas[i]*labels[i])
+	}
+
+	order := make([]int, len(samples))
+	for i := range order {
+		order[i] = i
+	}
+	innerTolerance := logisticInnerTolerance
+	minInnerTolerance := math.Min(1e-8, c.tolerance())
+
+	for iter := 0; iter < c.maxIterations(); iter++ {
+		shuffle(order)
+		var maxGrad float64
+		for _, i := range order {
+			a := qDiag[i]
+			b := labels[i] * dot(weights, samples[i])
+
+			// Optimize whichever of the two dual variables
+			// leads to the better-conditioned sub-problem.
+			sign := 1.0
+			oldZ := alphas[i]
+			if 0.5*a*(cost-2*alphas[i])+b < 0 {
+				sign = -1
+				oldZ = cost - alphas[i]
+			}
+
+			z := oldZ
+			if cost-z < 0.5*cost {
+				z *= 0.1
+			}
+			grad := a*(z-oldZ) + sign*b + math.Log(z/(cost-z))
+			maxGrad = math.Max(maxGrad, math.Abs(grad))
+
+			var newtonIters int
+			for ; newtonIters < logisticMaxNewtonIters; newtonIters++ {
+				if math.Abs(grad) < innerTolerance {
+					break
+				}
+				secondDeriv := a + cost/(cost-z)/z
+				newZ := z - grad/secondDeriv
+				if newZ <= 0 {
+					z *= 0.1
+				} else {
+					z = newZ
+				}
+				grad = a*(z-oldZ) + sign*b + math.Log(z/(cost-z))
+			}
+
+			if newtonIters > 0 {
+				if sign > 0 {
+					alphas[i] = z
+				} else {
+					alphas[i] = cost - z
+				}
+				addScaled(weights, samples[i], sign*(z-oldZ)*labels[i])
+			}
+		}
+
+		if maxGrad < c.tolerance() {
+			break
+		}
+		innerTolerance = math.Max(minInnerTolerance, 0.1*maxGrad)
+	}
+
+	return weights
+}
+
+func (c *CoordinateSolver) tolerance() float64 {
+	if c.Tolerance == 0 {
+		return defaultCoordinateTolerance
+	}
+	return c.Tolerance
+}
+
+func (c *CoordinateSolver) maxIterations() int {
+	if c.MaxIterations == 0 {
+		return defaultCoordinateIterations
+	}
+	return c.MaxIterations
+}
+
+// coordinateSamples converts a problem into a list of
+// vectors (each with an extra constant feature) and a
+// list of labels.
+func coordinateSamples(p *Problem) (samples [][]float64, labels []float64) {
+	for _, s := range p.Positives {
+		samples = append(samples, append(append([]float64{}, s.V...), 1))
+		labels = append(labels, 1)
+	}
+	for _, s := range p.Negatives {
+		samples = append(samples, append(append([]float64{}, s.V...), 1))
+		labels = append(labels, -1)
+	}
+	return
+}
+
+func dot(v1, v2 []float64) float64 {
+	var res float64
+	for i, x := range v1 {
+		res += x * v2[i]
+	}
+	return res
+}
+
+func addScaled(dest, v []float64, scale float64) {
+	for i, x := range v {
+		dest[i] += x * scale
+	}
+}
+
+func shuffle(list []int) {
+	for i := range list {
+		j := i + rand.Intn(len(list)-i)
+		list[i], list[j] = list[j], list[i]
+	}
+}
@@ -0,0 +1,103 @@
+package svm
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestCoordinateSolverLinear(t *testing.T) {
+	problem, supportVec := linearSVMProblem(20)
+	for _, noShrinking := range []bool{false, true} {
+		solver := &CoordinateSolver{
+			Tradeoff:    0.0001,
+			Tolerance:   1e-8,
+			NoShrinking: noShrinking,
+		}
+		solution := solver.Solve(problem)
+		if math.Abs(solution.Threshold) > 1e-5 {
+			t.Error("unexpected threshold:", solution.Threshold)
+		}
+		normal := solution.HyperplaneNormal.V
+		for i, x := range supportVec {
+			if math.Abs(x-normal[i]) > 1e-5 {
+				t.Errorf("shrinking=%v: unexpected normal: %v", !noShrinking, normal)
+				break
+			}
+		}
+	}
+}
+
+func TestCoordinateSolverLosses(t *testing.T) {
+	problem := randomLinearProblem(rand.New(rand.NewSource(1337)), 500, 10)
+	for _, loss := range []LinearLoss{HingeLoss, SquaredHingeLoss, LogisticLoss} {
+		solver := &CoordinateSolver{Loss: loss, Tradeoff: 1e-4}
+		solution := solver.Solve(problem)
+		var errors int
+		for _, s := range problem.Positives {
+			if !solution.Classify(s) {
+				errors++
+			}
+		}
+		for _, s := range problem.Negatives {
+			if solution.Classify(s) {
+				errors++
+			}
+		}
+		if errors > 5 {
+			t.Errorf("loss %d: got %d errors", loss, errors)
+		}
+	}
+}
+
+func TestCoordinateSolverLogisticOptimality(t *testing.T) {
+	problem := randomLinearProblem(rand.New(rand.NewSource(1337)), 200, 5)
+	solver := &CoordinateSolver{Loss: LogisticLoss, Tradeoff: 0.01, Tolerance: 1e-6}
+	solution := solver.Solve(problem)
+
+	// The primal objective is 1/2*|w|^2 + C*sum(log(1+exp(-y*f(x)))),
+	// where the threshold is treated as a weight.
+	samples, labels := coordinateSamples(problem)
+	cost := 1 / (2 * solver.Tradeoff * float64(len(samples)))
+	weights := append(append([]float64{}, solution.HyperplaneNormal.V...),
+		solution.Threshold)
+	grad := append([]float64{}, weights...)
+	for i, s := range samples {
+		margin := labels[i] * dot(weights, s)
+		addScaled(grad, s, -cost*labels[i]/(1+math.Exp(margin)))
+	}
+	if mag := math.Sqrt(dot(grad, grad)); mag > 1e-3 {
+		t.Errorf("primal gradient has magnitude %f", mag)
+	}
+}
+
+func BenchmarkCoordinateSolver(b *testing.B) {
+	problem := randomLinearProblem(rand.New(rand.NewSource(1337)), 10000,
+		benchmarkDimensionality)
+	solver := &CoordinateSolver{Tradeoff: 1e-4}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		solver.Solve(problem)
+	}
+}
+
+func randomLinearProblem(r *rand.Rand, count, dim int) *Problem {
+	normal := make([]float64, dim)
+	for i := range normal {
+		normal[i] = r.NormFloat64()
+	}
+	problem := &Problem{Kernel: LinearKernel}
+	for i := 0; i < count; i++ {
+		v := make([]float64, dim)
+		for j := range v {
+			v[j] = r.NormFloat64()
+		}
+		if dot(v, normal)+0.5 > 0 {
+			problem.Positives = append(problem.Positives, Sample{V: v})
+		} else {
+			problem.Negatives = append(problem.Negatives, Sample{V: v})
+		}
+	}
+	return problem
+}
@@ -0,0 +1,159 @@
+package tsne
+
+import (
+	"math"
+	"sort"
+
+	"github.com/unixpickle/num-analysis/linalg"
+)
+
+const (
+	perplexityTolerance = 1e-5
+	perplexityMaxSteps  = 200
+)
+
+// An affinity is a single entry in a sparse row of the
+// joint probability matrix P.
+type affinity struct {
+	Index int
+	Prob  float64
+}
+
+// jointAffinities computes the symmetric joint
+// probabilities p_ij for the samples.
+//
+// If neighbors is less than len(samples)-1, then each
+// sample's conditional distribution only includes its
+// nearest neighbors, making P sparse.
+// The neighbors are found with a vantage-point tree, so
+// not every pairwise distance is computed.
+func jointAffinities(samples []linalg.Vector, perplexity float64,
+	neighbors int) [][]affinity {
+	n := len(samples)
+	var tree *vpTree
+	if neighbors < n-1 {
+		tree = newVPTree(samples)
+	}
+	conditional := make([][]affinity, n)
+	for i, x := range samples {
+		var dists []affinity
+		if tree != nil {
+			dists = tree.Nearest(i, neighbors)
+		} else {
+			dists = make([]affinity, 0, n-1)
+			for j, y := range samples {
+				if i != j {
+					dists = append(dists, affinity{Index: j, Prob: squaredDist(x, y)})
+				}
+			}
+		}
+		sqDists := make([]float64, len(dists))
+		for k, d := range dists {
+			sqDists[k] = d.Prob
+		}
+		probs := conditionalProbs(sqDists, perplexity)
+		for k := range dists {
+			dists[k].Prob = probs[k]
+		}
+		conditional[i] = dists
+	}
+
+	joint := make([]map[int]float64, n)
+	for i := range joint {
+		joint[i] = map[int]float64{}
+	}
+	for i, row := range conditional {
+		for _, a := range row {
+			joint[i][a.Index] += a.Prob
+			joint[a.Index][i] += a.Prob
+		}
+	}
+	res := make([][]affinity, n)
+	for i, row := range joint {
+		for j, p := range row {
+			res[i] = append(res[i], affinity{Index: j, Prob: p / float64(2*n)})
+		}
+		sort.Sort(affinitiesByIndex(res[i]))
+	}
+	return res
+}
+
+// conditionalProbs computes a Gaussian distribution
+// over neighbors with the given squared distances.
+// The precision of the Gaussian is found with a binary
+// search so that the distribution has the desired
+// perplexity.
+func conditionalProbs(sqDists []float64, perplexity float64) []float64 {
+	probs := make([]float64, len(sqDists))
+	if len(sqDists) == 0 {
+		return probs
+	}
+	targetEntropy := math.Log(perplexity)
+	minDist := math.Inf(1)
+	for _, d := range sqDists {
+		minDist = math.Min(minDist, d)
+	}
+
+	beta := 1.0
+	minBeta, maxBeta := 0.0, math.Inf(1)
+	for step := 0; step < perplexityMaxSteps; step++ {
+		entropy := gaussianProbs(probs, sqDists, minDist, beta)
+		diff := entropy - targetEntropy
+		if math.Abs(diff) < perplexityTolerance {
+			break
+		}
+		if diff > 0 {
+			minBeta = beta
+			if math.IsInf(maxBeta, 1) {
+				beta *= 2
+			} else {
+				beta = (beta + maxBeta) / 2
+			}
+		} else {
+			maxBeta = beta
+			beta = (beta + minBeta) / 2
+		}
+	}
+	return probs
+}
+
+// gaussianProbs fills probs with a normalized Gaussian
+// distribution with precision beta and returns its
+// entropy (in nats).
+func gaussianProbs(probs, sqDists []float64, minDist, beta float64) float64 {
+	var sum, weightedSum float64
+	for i, d := range sqDists {
+		// Subtracting minDist avoids underflow and does
+		// not change the normalized result.
+		probs[i] = math.Exp(-beta * (d - minDist))
+		sum += probs[i]
+		weightedSum += probs[i] * (d - minDist)
+	}
+	for i := range probs {
+		probs[i] /= sum
+	}
+	return math.Log(sum) + beta*weightedSum/sum
+}
+
+func squaredDist(x, y linalg.Vector) float64 {
+	var res float64
+	for i, a := range x {
+		d := a - y[i]
+		res += d * d
+	}
+	return res
+}
+
+type affinitiesByIndex []affinity
+
+func (a affinitiesByIndex) Len() int {
+	return len(a)
+}
+
+func (a affinitiesByIndex) Swap(i, j int) {
+	a[i], a[j] = a[j], a[i]
+}
+
+func (a affinitiesByIndex) Less(i, j int) bool {
+	return a[i].Index < a[j].Index
+}
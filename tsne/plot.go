@@ -0,0 +1,79 @@
+package tsne
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+
+	"github.com/unixpickle/num-analysis/linalg"
+)
+
+const (
+	plotMargin    = 10
+	plotPointSize = 2
+)
+
+var plotPalette = []color.RGBA{
+	{R: 0x1f, G: 0x77, B: 0xb4, A: 0xff},
+	{R: 0xff, G: 0x7f, B: 0x0e, A: 0xff},
+	{R: 0x2c, G: 0xa0, B: 0x2c, A: 0xff},
+	{R: 0xd6, G: 0x27, B: 0x28, A: 0xff},
+	{R: 0x94, G: 0x67, B: 0xbd, A: 0xff},
+	{R: 0x8c, G: 0x56, B: 0x4b, A: 0xff},
+	{R: 0xe3, G: 0x77, B: 0xc2, A: 0xff},
+	{R: 0x7f, G: 0x7f, B: 0x7f, A: 0xff},
+	{R: 0xbc, G: 0xbd, B: 0x22, A: 0xff},
+	{R: 0x17, G: 0xbe, B: 0xcf, A: 0xff},
+}
+
+// ScatterPlot renders a scatter plot of embedded points
+// as a square image with the given side length.
+//
+// Only the first two coordinates of each point are
+// plotted, so 3D embeddings are projected onto the
+// x-y plane.
+//
+// If labels is non-nil, it contains one label per point
+// and points are colored by label.
+// Labels may be negative.
+func ScatterPlot(points []linalg.Vector, labels []int, size int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	if len(points) == 0 {
+		return img
+	}
+
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, p := range points {
+		minX, maxX = math.Min(minX, p[0]), math.Max(maxX, p[0])
+		minY, maxY = math.Min(minY, p[1]), math.Max(maxY, p[1])
+	}
+	scale := float64(size-2*plotMargin) / math.Max(math.Max(maxX-minX, maxY-minY), 1e-8)
+
+	for i, p := range points {
+		c := plotPalette[0]
+		if labels != nil {
+			n := len(plotPalette)
+			c = plotPalette[((labels[i]%n)+n)%n]
+		}
+		x := plotMargin + int((p[0]-minX)*scale)
+		y := plotMargin + int((p[1]-minY)*scale)
+		for dy := -plotPointSize; dy <= plotPointSize; dy++ {
+			for dx := -plotPointSize; dx <= plotPointSize; dx++ {
+				img.SetRGBA(x+dx, y+dy, c)
+			}
+		}
+	}
+	return img
+}
+
+// WritePNG renders a scatter plot with ScatterPlot and
+// encodes it as a PNG.
+func WritePNG(w io.Writer, points []linalg.Vector, labels []int, size int) error {
+	return png.Encode(w, ScatterPlot(points, labels, size))
+}
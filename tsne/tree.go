@@ -0,0 +1,143 @@
+package tsne
+
+import (
+	"math"
+
+	"github.com/unixpickle/num-analysis/linalg"
+)
+
+// maxTreeDepth limits the depth of a spaceTree, which
+// prevents infinite recursion for duplicate points.
+const maxTreeDepth = 50
+
+// A spaceTree is a quadtree (or octree, etc.) which
+// summarizes the points in each cell by their center
+// of mass, as used in the Barnes-Hut approximation.
+type spaceTree struct {
+	Center    linalg.Vector
+	HalfWidth float64
+
+	Count        int
+	CenterOfMass linalg.Vector
+
+	// Point is the only point in a leaf, or nil if the
+	// node is empty or internal.
+	Point linalg.Vector
+
+	Children []*spaceTree
+	depth    int
+}
+
+// newSpaceTree creates a tree containing the points.
+func newSpaceTree(points []linalg.Vector) *spaceTree {
+	dim := len(points[0])
+	min := make(linalg.Vector, dim)
+	max := make(linalg.Vector, dim)
+	for i := range min {
+		min[i] = math.Inf(1)
+		max[i] = math.Inf(-1)
+	}
+	for _, p := range points {
+		for i, x := range p {
+			min[i] = math.Min(min[i], x)
+			max[i] = math.Max(max[i], x)
+		}
+	}
+	center := make(linalg.Vector, dim)
+	var halfWidth float64
+	for i := range center {
+		center[i] = (min[i] + max[i]) / 2
+		halfWidth = math.Max(halfWidth, (max[i]-min[i])/2)
+	}
+	res := &spaceTree{
+		Center:       center,
+		HalfWidth:    halfWidth + 1e-5,
+		CenterOfMass: make(linalg.Vector, dim),
+	}
+	for _, p := range points {
+		res.insert(p)
+	}
+	return res
+}
+
+func (s *spaceTree) insert(p linalg.Vector) {
+	s.CenterOfMass.Scale(float64(s.Count))
+	s.CenterOfMass.Add(p)
+	s.Count++
+	s.CenterOfMass.Scale(1 / float64(s.Count))
+
+	if s.Count == 1 {
+		s.Point = p
+		return
+	}
+	if s.depth >= maxTreeDepth {
+		return
+	}
+	if s.Children == nil {
+		s.Children = make([]*spaceTree, 1<<uint(len(s.Center)))
+		old := s.Point
+		s.Point = nil
+		s.child(old).insert(old)
+	}
+	s.child(p).insert(p)
+}
+
+func (s *spaceTree) child(p linalg.Vector) *spaceTree {
+	var idx int
+	for i, x := range p {
+		if x > s.Center[i] {
+			idx |= 1 << uint(i)
+		}
+	}
+	if s.Children[idx] == nil {
+		center := make(linalg.Vector, len(s.Center))
+		for i := range center {
+			if idx&(1<<uint(i)) != 0 {
+				center[i] = s.Center[i] + s.HalfWidth/2
+			} else {
+				center[i] = s.Center[i] - s.HalfWidth/2
+			}
+		}
+		s.Children[idx] = &spaceTree{
+			Center:       center,
+			HalfWidth:    s.HalfWidth / 2,
+			CenterOfMass: make(linalg.Vector, len(center)),
+			depth:        s.depth + 1,
+		}
+	}
+	return s.Children[idx]
+}
+
+// repulsion accumulates the unnormalized repulsive
+// force on p into force and returns the contribution
+// to the normalization term Z.
+//
+// The force is the sum of q_ij^2*(p-y_j) over points
+// y_j, where q_ij = 1/(1+||p-y_j||^2).
+// Cells which are small relative to their distance
+// from p (according to theta) are treated as a single
+// point at their center of mass.
+func (s *spaceTree) repulsion(p linalg.Vector, theta float64, force linalg.Vector) float64 {
+	if s.Count == 0 || (s.Point != nil && squaredDist(s.Point, p) == 0) {
+		return 0
+	}
+	sqDist := squaredDist(p, s.CenterOfMass)
+	isSummary := s.Children == nil ||
+		(2*s.HalfWidth)*(2*s.HalfWidth) < theta*theta*sqDist
+	if isSummary {
+		count := float64(s.Count)
+		q := 1 / (1 + sqDist)
+		mult := count * q * q
+		for i, x := range p {
+			force[i] += mult * (x - s.CenterOfMass[i])
+		}
+		return count * q
+	}
+	var z float64
+	for _, c := range s.Children {
+		if c != nil {
+			z += c.repulsion(p, theta, force)
+		}
+	}
+	return z
+}
@@ -0,0 +1,209 @@
+// Package tsne implements t-distributed Stochastic
+// Neighbor Embedding, which embeds high-dimensional
+// vectors in two or three dimensions for visualization.
+//
+// Both the exact algorithm from van der Maaten and
+// Hinton (2008) and the Barnes-Hut approximation from
+// van der Maaten (2014) are supported.
+package tsne
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/unixpickle/autofunc"
+	"github.com/unixpickle/num-analysis/linalg"
+	"github.com/unixpickle/weakai/neuralnet"
+)
+
+const (
+	minGain   = 0.01
+	initScale = 1e-4
+)
+
+// TSNE stores the hyper-parameters for t-SNE.
+type TSNE struct {
+	// Dims is the dimensionality of the embedding,
+	// usually 2 or 3.
+	Dims int
+
+	// Perplexity is the effective number of neighbors
+	// that each sample considers.
+	Perplexity float64
+
+	// LearningRate is the gradient descent step size.
+	LearningRate float64
+
+	// Iterations is the total number of gradient steps.
+	Iterations int
+
+	// During the first ExaggerationIters iterations, the
+	// input affinities are multiplied by Exaggeration to
+	// encourage the formation of tight clusters.
+	Exaggeration      float64
+	ExaggerationIters int
+
+	// InitialMomentum is used during the exaggeration
+	// phase, and FinalMomentum is used afterwards.
+	InitialMomentum float64
+	FinalMomentum   float64
+
+	// Theta is the accuracy parameter for the Barnes-Hut
+	// approximation.
+	// If it is 0, the exact O(N^2) gradient is used.
+	// Otherwise, larger values are faster but less
+	// accurate, and input affinities are only computed
+	// between each sample and its 3*Perplexity nearest
+	// neighbors.
+	Theta float64
+}
+
+// NewTSNE creates a TSNE with commonly used default
+// hyper-parameters.
+// It uses the exact gradient; set Theta (e.g. to 0.5)
+// to use the Barnes-Hut approximation for large inputs.
+func NewTSNE(dims int) *TSNE {
+	return &TSNE{
+		Dims:              dims,
+		Perplexity:        30,
+		LearningRate:      200,
+		Iterations:        1000,
+		Exaggeration:      12,
+		ExaggerationIters: 250,
+		InitialMomentum:   0.5,
+		FinalMomentum:     0.8,
+	}
+}
+
+// Embed computes an embedding of the samples, returning
+// one point per sample.
+//
+// With fewer than two samples, there are no affinities
+// to preserve, so every point is at the origin.
+//
+// If r is nil, this uses the rand package's default
+// generator.
+func (t *TSNE) Embed(samples []linalg.Vector, r *rand.Rand) []linalg.Vector {
+	n := len(samples)
+	if n < 2 {
+		points := make([]linalg.Vector, n)
+		for i := range points {
+			points[i] = make(linalg.Vector, t.Dims)
+		}
+		return points
+	}
+
+	neighbors := n - 1
+	if t.Theta > 0 {
+		neighbors = int(math.Min(float64(n-1), math.Ceil(3*t.Perplexity)))
+	}
+	perplexity := math.Min(t.Perplexity, float64(neighbors))
+	probs := jointAffinities(samples, perplexity, neighbors)
+
+	points := make([]linalg.Vector, n)
+	updates := make([]linalg.Vector, n)
+	gains := make([]linalg.Vector, n)
+	for i := range points {
+		points[i] = make(linalg.Vector, t.Dims)
+		updates[i] = make(linalg.Vector, t.Dims)
+		gains[i] = make(linalg.Vector, t.Dims)
+		for j := range points[i] {
+			if r != nil {
+				points[i][j] = r.NormFloat64() * initScale
+			} else {
+				points[i][j] = rand.NormFloat64() * initScale
+			}
+			gains[i][j] = 1
+		}
+	}
+
+	for iter := 0; iter < t.Iterations; iter++ {
+		exaggeration := 1.0
+		momentum := t.FinalMomentum
+		if iter < t.ExaggerationIters {
+			exaggeration = t.Exaggeration
+			momentum = t.InitialMomentum
+		}
+		grad := t.gradient(points, probs, exaggeration)
+		for i, g := range grad {
+			for j, x := range g {
+				if (x > 0) != (updates[i][j] > 0) {
+					gains[i][j] += 0.2
+				} else {
+					gains[i][j] = math.Max(minGain, gains[i][j]*0.8)
+				}
+				updates[i][j] = momentum*updates[i][j] - t.LearningRate*gains[i][j]*x
+			}
+			points[i].Add(updates[i])
+		}
+		centerPoints(points)
+	}
+	return points
+}
+
+// gradient computes the gradient of the KL divergence
+// with respect to the embedded points.
+func (t *TSNE) gradient(points []linalg.Vector, probs [][]affinity,
+	exaggeration float64) []linalg.Vector {
+	attractive := make([]linalg.Vector, len(points))
+	repulsive := make([]linalg.Vector, len(points))
+	for i := range points {
+		attractive[i] = make(linalg.Vector, t.Dims)
+		repulsive[i] = make(linalg.Vector, t.Dims)
+	}
+
+	for i, row := range probs {
+		for _, a := range row {
+			diff := points[i].Copy().Add(points[a.Index].Copy().Scale(-1))
+			q := 1 / (1 + diff.Dot(diff))
+			attractive[i].Add(diff.Scale(exaggeration * a.Prob * q))
+		}
+	}
+
+	var z float64
+	if t.Theta > 0 {
+		tree := newSpaceTree(points)
+		for i, p := range points {
+			z += tree.repulsion(p, t.Theta, repulsive[i])
+		}
+	} else {
+		for i, p := range points {
+			for j, p1 := range points[:i] {
+				diff := p.Copy().Add(p1.Copy().Scale(-1))
+				q := 1 / (1 + diff.Dot(diff))
+				z += 2 * q
+				diff.Scale(q * q)
+				repulsive[i].Add(diff)
+				repulsive[j].Add(diff.Scale(-1))
+			}
+		}
+	}
+
+	for i, a := range attractive {
+		a.Add(repulsive[i].Scale(-1 / z)).Scale(4)
+	}
+	return attractive
+}
+
+// Activations applies a network to every sample,
+// producing representations which can be embedded.
+// To inspect a hidden layer, pass a prefix of a
+// network, such as net[:2].
+func Activations(net neuralnet.Network, samples []linalg.Vector) []linalg.Vector {
+	res := make([]linalg.Vector, len(samples))
+	for i, s := range samples {
+		res[i] = net.Apply(&autofunc.Variable{Vector: s}).Output()
+	}
+	return res
+}
+
+func centerPoints(points []linalg.Vector) {
+	mean := make(linalg.Vector, len(points[0]))
+	for _, p := range points {
+		mean.Add(p)
+	}
+	mean.Scale(-1 / float64(len(points)))
+	for _, p := range points {
+		p.Add(mean)
+	}
+}
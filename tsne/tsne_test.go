@@ -0,0 +1,194 @@
+package tsne
+
+import (
+	"bytes"
+	"image/png"
+	"math"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/unixpickle/num-analysis/linalg"
+)
+
+func TestConditionalProbs(t *testing.T) {
+	sqDists := make([]float64, 50)
+	for i := range sqDists {
+		sqDists[i] = rand.Float64() * 10
+	}
+	for _, perplexity := range []float64{2, 10, 30} {
+		probs := conditionalProbs(sqDists, perplexity)
+		var entropy, sum float64
+		for _, p := range probs {
+			sum += p
+			if p > 0 {
+				entropy -= p * math.Log(p)
+			}
+		}
+		if math.Abs(sum-1) > 1e-8 {
+			t.Errorf("perplexity %f: probabilities sum to %f", perplexity, sum)
+		}
+		if actual := math.Exp(entropy); math.Abs(actual-perplexity) > 1e-3 {
+			t.Errorf("expected perplexity %f but got %f", perplexity, actual)
+		}
+	}
+}
+
+func TestTSNEClusters(t *testing.T) {
+	samples, labels := clusterSamples(rand.New(rand.NewSource(1337)), 40)
+	for _, theta := range []float64{0, 0.5} {
+		for _, dims := range []int{2, 3} {
+			ts := NewTSNE(dims)
+			ts.Perplexity = 10
+			ts.Iterations = 500
+			ts.Theta = theta
+			points := ts.Embed(samples, rand.New(rand.NewSource(42)))
+			if len(points) != len(samples) || len(points[0]) != dims {
+				t.Fatalf("theta %f: unexpected output shape", theta)
+			}
+			if acc := neighborAccuracy(points, labels); acc < 0.95 {
+				t.Errorf("theta %f, dims %d: neighbor accuracy %f", theta, dims, acc)
+			}
+		}
+	}
+}
+
+func TestTSNESingleSample(t *testing.T) {
+	for _, theta := range []float64{0, 0.5} {
+		ts := NewTSNE(2)
+		ts.Theta = theta
+		points := ts.Embed([]linalg.Vector{{1, 2, 3}}, nil)
+		if len(points) != 1 || !reflect.DeepEqual(points[0], linalg.Vector{0, 0}) {
+			t.Errorf("theta %f: unexpected embedding %v", theta, points)
+		}
+	}
+}
+
+func TestBarnesHutGradient(t *testing.T) {
+	samples, _ := clusterSamples(rand.New(rand.NewSource(1337)), 20)
+	probs := jointAffinities(samples, 10, len(samples)-1)
+	points := make([]linalg.Vector, len(samples))
+	for i := range points {
+		points[i] = linalg.Vector{rand.NormFloat64(), rand.NormFloat64()}
+	}
+	exact := &TSNE{Dims: 2}
+	approx := &TSNE{Dims: 2, Theta: 0.1}
+	expected := exact.gradient(points, probs, 1)
+	actual := approx.gradient(points, probs, 1)
+	for i, x := range expected {
+		diff := x.Copy().Add(actual[i].Copy().Scale(-1))
+		if diff.Mag() > 0.05*x.Mag()+1e-5 {
+			t.Errorf("point %d: expected gradient %v but got %v", i, x, actual[i])
+		}
+	}
+}
+
+func TestWritePNG(t *testing.T) {
+	samples, labels := clusterSamples(rand.New(rand.NewSource(1337)), 10)
+	ts := NewTSNE(2)
+	ts.Perplexity = 5
+	ts.Iterations = 100
+	points := ts.Embed(samples, nil)
+
+	var buf bytes.Buffer
+	if err := WritePNG(&buf, points, labels, 100); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds().Dx() != 100 || img.Bounds().Dy() != 100 {
+		t.Errorf("unexpected bounds: %v", img.Bounds())
+	}
+
+	negLabels := make([]int, len(labels))
+	for i, l := range labels {
+		negLabels[i] = -l - 1
+	}
+	ScatterPlot(points, negLabels, 100)
+}
+
+// clusterSamples generates three well-separated clusters
+// in ten dimensions.
+func TestVPTreeNearest(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	points := make([]linalg.Vector, 200)
+	for i := range points {
+		points[i] = linalg.Vector{r.NormFloat64(), r.NormFloat64(), r.NormFloat64()}
+	}
+	// Duplicate points should not confuse the search.
+	points[10] = points[20].Copy()
+	tree := newVPTree(points)
+	for _, k := range []int{0, 1, 5, 30, len(points) - 1} {
+		for i, x := range points {
+			var expected []float64
+			for j, y := range points {
+				if i != j {
+					expected = append(expected, squaredDist(x, y))
+				}
+			}
+			sort.Float64s(expected)
+			expected = expected[:k]
+
+			var actual []float64
+			seen := map[int]bool{}
+			for _, a := range tree.Nearest(i, k) {
+				if a.Index == i || seen[a.Index] {
+					t.Fatalf("k=%d, point %d: bad neighbor %d", k, i, a.Index)
+				}
+				seen[a.Index] = true
+				actual = append(actual, a.Prob)
+			}
+			sort.Float64s(actual)
+			if len(actual) != k {
+				t.Fatalf("k=%d, point %d: got %d neighbors", k, i, len(actual))
+			}
+			for j, d := range expected {
+				if math.Abs(d-actual[j]) > 1e-8 {
+					t.Fatalf("k=%d, point %d: expected %v but got %v", k, i, expected, actual)
+				}
+			}
+		}
+	}
+}
+
+func clusterSamples(r *rand.Rand, perCluster int) ([]linalg.Vector, []int) {
+	var samples []linalg.Vector
+	var labels []int
+	for label := 0; label < 3; label++ {
+		center := make(linalg.Vector, 10)
+		for i := range center {
+			center[i] = r.NormFloat64() * 10
+		}
+		for i := 0; i < perCluster; i++ {
+			sample := center.Copy()
+			for j := range sample {
+				sample[j] += r.NormFloat64()
+			}
+			samples = append(samples, sample)
+			labels = append(labels, label)
+		}
+	}
+	return samples, labels
+}
+
+func neighborAccuracy(points []linalg.Vector, labels []int) float64 {
+	var correct int
+	for i, p := range points {
+		best := -1
+		bestDist := math.Inf(1)
+		for j, p1 := range points {
+			if i != j {
+				if d := squaredDist(p, p1); d < bestDist {
+					best, bestDist = j, d
+				}
+			}
+		}
+		if labels[best] == labels[i] {
+			correct++
+		}
+	}
+	return float64(correct) / float64(len(points))
+}
@@ -0,0 +1,171 @@
+package tsne
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+
+	"github.com/unixpickle/num-analysis/linalg"
+)
+
+// A vpTree is a vantage-point tree, which is used to
+// find the nearest neighbors of each sample without
+// computing every pairwise distance.
+type vpTree struct {
+	Points []linalg.Vector
+	Root   *vpNode
+}
+
+// A vpNode splits the points beneath it by their
+// distance from the vantage point.
+// Points closer than Radius go in Inside, while the
+// rest go in Outside.
+type vpNode struct {
+	Index  int
+	Radius float64
+
+	Inside  *vpNode
+	Outside *vpNode
+}
+
+// newVPTree creates a tree containing the points.
+func newVPTree(points []linalg.Vector) *vpTree {
+	indices := make([]int, len(points))
+	for i := range indices {
+		indices[i] = i
+	}
+	return &vpTree{
+		Points: points,
+		Root:   buildVPNode(points, indices),
+	}
+}
+
+func buildVPNode(points []linalg.Vector, indices []int) *vpNode {
+	if len(indices) == 0 {
+		return nil
+	}
+	node := &vpNode{Index: indices[0]}
+	rest := make([]vpNeighbor, len(indices)-1)
+	if len(rest) == 0 {
+		return node
+	}
+	vantage := points[node.Index]
+	for i, idx := range indices[1:] {
+		rest[i] = vpNeighbor{Index: idx, Dist: math.Sqrt(squaredDist(vantage, points[idx]))}
+	}
+	sort.Sort(vpNeighborsByDist(rest))
+	mid := len(rest) / 2
+	node.Radius = rest[mid].Dist
+	for i, n := range rest {
+		indices[i+1] = n.Index
+	}
+	node.Inside = buildVPNode(points, indices[1:mid+1])
+	node.Outside = buildVPNode(points, indices[mid+1:])
+	return node
+}
+
+// Nearest finds the k points closest to the point at
+// the given index, excluding the point itself.
+// Each result's Prob is its squared distance.
+func (v *vpTree) Nearest(index, k int) []affinity {
+	s := &vpSearch{
+		points: v.Points,
+		query:  index,
+		k:      k,
+		tau:    math.Inf(1),
+	}
+	s.search(v.Root)
+	res := make([]affinity, len(s.found))
+	for i, n := range s.found {
+		res[i] = affinity{Index: n.Index, Prob: n.Dist * n.Dist}
+	}
+	return res
+}
+
+type vpSearch struct {
+	points []linalg.Vector
+	query  int
+	k      int
+
+	// tau is the distance to the farthest of the k best
+	// points so far, or infinity until k are found.
+	tau   float64
+	found vpNeighborHeap
+}
+
+func (s *vpSearch) search(node *vpNode) {
+	if node == nil || s.k == 0 {
+		return
+	}
+	dist := math.Sqrt(squaredDist(s.points[s.query], s.points[node.Index]))
+	if node.Index != s.query && dist < s.tau {
+		heap.Push(&s.found, vpNeighbor{Index: node.Index, Dist: dist})
+		if len(s.found) > s.k {
+			heap.Pop(&s.found)
+		}
+		if len(s.found) == s.k {
+			s.tau = s.found[0].Dist
+		}
+	}
+	if dist < node.Radius {
+		if dist-s.tau <= node.Radius {
+			s.search(node.Inside)
+		}
+		if dist+s.tau >= node.Radius {
+			s.search(node.Outside)
+		}
+	} else {
+		if dist+s.tau >= node.Radius {
+			s.search(node.Outside)
+		}
+		if dist-s.tau <= node.Radius {
+			s.search(node.Inside)
+		}
+	}
+}
+
+type vpNeighbor struct {
+	Index int
+	Dist  float64
+}
+
+type vpNeighborsByDist []vpNeighbor
+
+func (v vpNeighborsByDist) Len() int {
+	return len(v)
+}
+
+func (v vpNeighborsByDist) Swap(i, j int) {
+	v[i], v[j] = v[j], v[i]
+}
+
+func (v vpNeighborsByDist) Less(i, j int) bool {
+	return v[i].Dist < v[j].Dist
+}
+
+// vpNeighborHeap is a max-heap of neighbors, keeping
+// the farthest neighbor at the top.
+type vpNeighborHeap []vpNeighbor
+
+func (v vpNeighborHeap) Len() int {
+	return len(v)
+}
+
+func (v vpNeighborHeap) Swap(i, j int) {
+	v[i], v[j] = v[j], v[i]
+}
+
+func (v vpNeighborHeap) Less(i, j int) bool {
+	return v[i].Dist > v[j].Dist
+}
+
+func (v *vpNeighborHeap) Push(x interface{}) {
+	*v = append(*v, x.(vpNeighbor))
+}
+
+func (v *vpNeighborHeap) Pop() interface{} {
+	old := *v
+	x := old[len(old)-1]
+	*v = old[:len(old)-1]
+	return x
+}